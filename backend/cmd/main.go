@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/financial-tracker/backend/config"
 	"github.com/financial-tracker/backend/internal/handlers"
+	"github.com/financial-tracker/backend/internal/jobs"
+	"github.com/financial-tracker/backend/internal/mailer"
 	"github.com/financial-tracker/backend/internal/middleware"
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/notifier"
+	"github.com/financial-tracker/backend/internal/oidc"
+	"github.com/financial-tracker/backend/internal/ratelimit"
 	"github.com/financial-tracker/backend/internal/repository"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -33,19 +42,114 @@ func main() {
 	transactionRepo := repository.NewTransactionRepository(db)
 	budgetRepo := repository.NewBudgetRepository(db)
 	creditCardRepo := repository.NewCreditCardRepository(db)
+	installmentRepo := repository.NewInstallmentRepository(db)
 	goldRepo := repository.NewGoldRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	netWorthRepo := repository.NewNetWorthRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	rewardRepo := repository.NewRewardRepository(db)
+	paylaterRepo := repository.NewPaylaterRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+	contactRepo := repository.NewContactRepository(db)
+	iouRepo := repository.NewIOURepository(db)
+	splitRepo := repository.NewSplitRepository(db)
+	householdRepo := repository.NewHouseholdRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	apiTokenRepo := repository.NewAPITokenRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	apiConfigRepo := repository.NewAPIConfigRepository(db)
+
+	// Email goes through SMTP_HOST when set, otherwise it is only logged
+	var mail mailer.Mailer = mailer.LogMailer{}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		from := os.Getenv("MAIL_FROM")
+		if from == "" {
+			from = "no-reply@localhost"
+		}
+		mail = mailer.NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+	}
+
+	// Sign-in providers, e.g. OIDC_PROVIDERS=google,mock, each configured by OIDC_<NAME>_* variables
+	var oidcProviders []*oidc.Provider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		cfg, err := oidc.ConfigFromEnv(name)
+		if err != nil {
+			log.Printf("Skipping sign-in provider %q: %v", name, err)
+			continue
+		}
+		oidcProviders = append(oidcProviders, oidc.NewProvider(cfg))
+	}
+
+	// What users may do through their roles, cached for a minute per user
+	permissions := middleware.NewPermissions(roleRepo, time.Minute)
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(userRepo)
+	// Attempt counters for rate limits and login lockouts. They are kept in memory, so
+	// each instance counts on its own; use a shared ratelimit.Store when running several.
+	limits := ratelimit.NewMemoryStore()
+	loginGuard := ratelimit.NewLoginGuard(limits)
+
+	authHandler := handlers.NewAuthHandler(userRepo, sessionRepo, passwordResetRepo, twoFactorRepo, identityRepo, mail, loginGuard, oidcProviders)
+	adminHandler := handlers.NewAdminHandler(userRepo, apiConfigRepo, goldRepo, roleRepo, permissions)
 	accountHandler := handlers.NewAccountHandler(accountRepo)
 	transactionHandler := handlers.NewTransactionHandler(transactionRepo, accountRepo, creditCardRepo)
-	budgetHandler := handlers.NewBudgetHandler(budgetRepo)
-	creditCardHandler := handlers.NewCreditCardHandler(creditCardRepo)
+	budgetHandler := handlers.NewBudgetHandler(budgetRepo, accountRepo, creditCardRepo)
+	creditCardHandler := handlers.NewCreditCardHandler(creditCardRepo, accountRepo, transactionRepo, installmentRepo, rewardRepo)
 	goldHandler := handlers.NewGoldHandler(goldRepo)
+	forecastHandler := handlers.NewForecastHandler(transactionRepo, accountRepo, creditCardRepo)
+	reportHandler := handlers.NewReportHandler(reportRepo, creditCardRepo, rewardRepo)
+	netWorthHandler := handlers.NewNetWorthHandler(netWorthRepo)
+	notificationHandler := handlers.NewNotificationHandler(notificationRepo)
+	paylaterHandler := handlers.NewPaylaterHandler(accountRepo, paylaterRepo, installmentRepo)
+	loanHandler := handlers.NewLoanHandler(loanRepo, accountRepo, creditCardRepo)
+	contactHandler := handlers.NewContactHandler(contactRepo, iouRepo, userRepo)
+	iouHandler := handlers.NewIOUHandler(iouRepo, contactRepo, accountRepo, transactionRepo)
+	splitHandler := handlers.NewSplitHandler(splitRepo, userRepo, accountRepo)
+	householdHandler := handlers.NewHouseholdHandler(householdRepo, userRepo)
+	apiTokenHandler := handlers.NewAPITokenHandler(apiTokenRepo, userRepo)
+
+	// Notifications are always stored in-app; NOTIFY_WEBHOOK_URL adds webhook delivery
+	channels := []notifier.Channel{notifier.LogChannel{}}
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		channels = append(channels, notifier.NewWebhookChannel(url))
+	}
+	notify := notifier.New(notificationRepo, channels...)
+
+	// Background jobs
+	scheduler := jobs.NewScheduler()
+	scheduler.Every(time.Hour, jobs.NewBudgetRolloverJob(budgetRepo))
+	scheduler.Every(24*time.Hour, jobs.NewNetWorthSnapshotJob(userRepo, netWorthRepo))
+	scheduler.Every(time.Hour, jobs.NewInstallmentPostingJob(creditCardRepo, paylaterRepo, installmentRepo))
+	scheduler.Every(time.Hour, jobs.NewCardReminderJob(creditCardRepo, notify))
+	scheduler.Every(time.Hour, jobs.NewStatementCloseJob(creditCardRepo))
+	scheduler.Start(context.Background())
 
 	// Setup Gin router
 	router := gin.Default()
 
+	// Only believe X-Forwarded-For from our own proxies, otherwise clients pick the IP
+	// that rate limits and sessions see
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
 	// CORS configuration
 	corsOrigins := os.Getenv("CORS_ORIGINS")
 	if corsOrigins == "" {
@@ -69,33 +173,102 @@ func main() {
 	
 	// Public routes (no authentication required)
 	// Auth routes - MUST be defined before protected routes
-	api.POST("/auth/register", authHandler.Register)
-	api.POST("/auth/login", authHandler.Login)
+	// Per-IP limits on the endpoints that check passwords or send email
+	loginRate := ratelimit.ParseRate(os.Getenv("RATE_LIMIT_LOGIN"), ratelimit.Rate{Limit: 10, Window: time.Minute})
+	registerRate := ratelimit.ParseRate(os.Getenv("RATE_LIMIT_REGISTER"), ratelimit.Rate{Limit: 5, Window: time.Hour})
+	emailRate := ratelimit.ParseRate(os.Getenv("RATE_LIMIT_EMAIL"), ratelimit.Rate{Limit: 5, Window: time.Hour})
+	twoFactorRate := ratelimit.ParseRate(os.Getenv("RATE_LIMIT_2FA"), ratelimit.Rate{Limit: 10, Window: time.Minute})
+	oidcRate := ratelimit.ParseRate(os.Getenv("RATE_LIMIT_OIDC"), ratelimit.Rate{Limit: 20, Window: time.Minute})
+
+	api.POST("/auth/register", middleware.RateLimit(limits, "register", registerRate), authHandler.Register)
+	api.POST("/auth/login", middleware.RateLimit(limits, "login", loginRate), authHandler.Login)
+	api.POST("/auth/refresh", authHandler.Refresh)
+	api.POST("/auth/forgot-password", middleware.RateLimit(limits, "email", emailRate), authHandler.ForgotPassword)
+	api.POST("/auth/reset-password", authHandler.ResetPassword)
+	api.POST("/auth/verify-email", authHandler.VerifyEmail)
+	api.POST("/auth/2fa/verify", middleware.RateLimit(limits, "two-factor", twoFactorRate), authHandler.VerifyTwoFactor)
+	api.GET("/auth/oidc/providers", authHandler.GetOIDCProviders)
+	api.POST("/auth/oidc/:provider/start", middleware.RateLimit(limits, "oidc-start", oidcRate), authHandler.StartOIDCLogin)
+	api.POST("/auth/oidc/:provider/callback", middleware.RateLimit(limits, "oidc-callback", oidcRate), authHandler.OIDCCallback)
 	
 	// Public gold price endpoints
 	api.GET("/gold/price", goldHandler.GetLatestPrice)
 	api.GET("/gold/price/history", goldHandler.GetPriceHistory)
 
+	// Public IOU summary behind a contact's share link
+	api.GET("/shared/ious/:token", contactHandler.GetSharedSummary)
+
 	// Protected routes (authentication required)
 	// Create separate groups for each resource to avoid conflicts
+	// Personal access tokens are accepted too; each group checks their scopes with RequireScope
+	requireAuth := middleware.AuthMiddleware(sessionRepo, apiTokenRepo)
+
+	// Features kept from users until they verify their email address
+	restrictions, ok := os.LookupEnv("UNVERIFIED_EMAIL_RESTRICTIONS")
+	if !ok {
+		restrictions = middleware.DefaultUnverifiedRestrictions
+	}
+	verification := middleware.NewVerificationPolicy(userRepo, restrictions)
+	// Groups touching accounts, budgets or cards also load the user's household roles
+	householdRoles := middleware.HouseholdRoles(householdRepo)
+
+	// Account settings stay reachable for users who still have to set up required 2FA
 	authProtected := api.Group("/auth")
-	authProtected.Use(middleware.AuthMiddleware())
+	authProtected.Use(middleware.AccountAuthMiddleware(sessionRepo))
 	{
 		authProtected.GET("/me", authHandler.GetMe)
+		authProtected.POST("/logout", authHandler.Logout)
+		authProtected.POST("/logout-all", authHandler.LogoutAll)
+		authProtected.POST("/change-password", authHandler.ChangePassword)
+		authProtected.POST("/verify-email/resend", authHandler.ResendVerification)
+		authProtected.GET("/sessions", authHandler.GetSessions)
+		authProtected.DELETE("/sessions/:id", authHandler.RevokeSession)
+		authProtected.GET("/2fa", authHandler.GetTwoFactorStatus)
+		authProtected.POST("/2fa/setup", authHandler.SetupTwoFactor)
+		authProtected.POST("/2fa/enable", authHandler.EnableTwoFactor)
+		authProtected.POST("/2fa/disable", authHandler.DisableTwoFactor)
+		authProtected.POST("/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+		authProtected.GET("/tokens", apiTokenHandler.GetAll)
+		authProtected.POST("/tokens", apiTokenHandler.Create)
+		authProtected.DELETE("/tokens/:id", apiTokenHandler.Revoke)
+		authProtected.GET("/identities", authHandler.GetIdentities)
+	}
+
+	// Each admin route needs its own permission, granted through roles
+	admin := api.Group("/admin")
+	admin.Use(requireAuth)
+	{
+		admin.GET("/stats", permissions.Require(models.PermStatsRead), adminHandler.GetDashboardStats)
+		admin.GET("/users", permissions.Require(models.PermUsersRead), adminHandler.GetAllUsers)
+		admin.GET("/users/:id", permissions.Require(models.PermUsersRead), adminHandler.GetUser)
+		admin.PUT("/users/:id", permissions.Require(models.PermUsersWrite), adminHandler.UpdateUser)
+		admin.DELETE("/users/:id", permissions.Require(models.PermUsersWrite), adminHandler.DeleteUser)
+		admin.PUT("/users/:id/two-factor", permissions.Require(models.PermUsersWrite), adminHandler.SetTwoFactorRequired)
+		admin.GET("/users/:id/roles", permissions.Require(models.PermUsersRead), adminHandler.GetUserRoles)
+		admin.PUT("/users/:id/roles", permissions.Require(models.PermRolesWrite), adminHandler.SetUserRoles)
+		admin.GET("/roles", permissions.Require(models.PermUsersRead), adminHandler.GetRoles)
+		admin.GET("/api-configs", permissions.Require(models.PermAPIConfigsRead), adminHandler.GetAllAPIConfigs)
+		admin.GET("/api-configs/:id", permissions.Require(models.PermAPIConfigsRead), adminHandler.GetAPIConfig)
+		admin.PUT("/api-configs/:id", permissions.Require(models.PermAPIConfigsWrite), adminHandler.UpdateAPIConfig)
 	}
 
 	accounts := api.Group("/accounts")
-	accounts.Use(middleware.AuthMiddleware())
+	accounts.Use(requireAuth, middleware.RequireScope("accounts"), householdRoles)
 	{
 		accounts.POST("", accountHandler.Create)
 		accounts.GET("", accountHandler.GetAll)
 		accounts.GET("/:id", accountHandler.GetByID)
 		accounts.PUT("/:id", accountHandler.Update)
 		accounts.DELETE("/:id", accountHandler.Delete)
+		accounts.PUT("/:id/household", accountHandler.Share)
+		accounts.GET("/:id/paylater", paylaterHandler.GetProfile)
+		accounts.PUT("/:id/paylater", paylaterHandler.UpsertProfile)
+		accounts.POST("/:id/paylater/installments", paylaterHandler.CreatePurchase)
+		accounts.DELETE("/:id/paylater/installments/:planId", paylaterHandler.DeletePurchase)
 	}
 
 	transactions := api.Group("/transactions")
-	transactions.Use(middleware.AuthMiddleware())
+	transactions.Use(requireAuth, middleware.RequireScope("transactions"), householdRoles)
 	{
 		transactions.POST("", transactionHandler.Create)
 		transactions.GET("", transactionHandler.GetAll)
@@ -106,36 +279,165 @@ func main() {
 	}
 
 	budgets := api.Group("/budgets")
-	budgets.Use(middleware.AuthMiddleware())
+	budgets.Use(requireAuth, middleware.RequireScope("budgets"), householdRoles)
 	{
 		budgets.POST("", budgetHandler.Create)
 		budgets.GET("", budgetHandler.GetAll)
+		budgets.GET("/progress", budgetHandler.GetProgress)
 		budgets.POST("/copy", budgetHandler.CopyFromMonth)
+		budgets.GET("/rollover", budgetHandler.GetRolloverSetting)
+		budgets.PUT("/rollover", budgetHandler.UpdateRolloverSetting)
 		budgets.GET("/:id", budgetHandler.GetByID)
 		budgets.PUT("/:id", budgetHandler.Update)
 		budgets.DELETE("/:id", budgetHandler.Delete)
+		budgets.PUT("/:id/household", budgetHandler.Share)
 	}
 
 	creditCards := api.Group("/credit-cards")
-	creditCards.Use(middleware.AuthMiddleware())
+	creditCards.Use(requireAuth, middleware.RequireScope("credit-cards"), householdRoles)
 	{
 		creditCards.POST("", creditCardHandler.Create)
 		creditCards.GET("", creditCardHandler.GetAll)
 		creditCards.GET("/:id", creditCardHandler.GetByID)
 		creditCards.PUT("/:id", creditCardHandler.Update)
 		creditCards.DELETE("/:id", creditCardHandler.Delete)
+		creditCards.PUT("/:id/household", creditCardHandler.Share)
+		creditCards.GET("/:id/statements", creditCardHandler.GetStatements)
+		creditCards.GET("/:id/statements/:date", creditCardHandler.GetStatement)
+		creditCards.GET("/:id/projection", creditCardHandler.GetProjection)
+		creditCards.POST("/:id/payments", creditCardHandler.CreatePayment)
+		creditCards.GET("/:id/payments", creditCardHandler.GetPayments)
+		creditCards.DELETE("/:id/payments/:transactionId", creditCardHandler.DeletePayment)
+		creditCards.POST("/:id/installments", creditCardHandler.CreateInstallment)
+		creditCards.GET("/:id/installments", creditCardHandler.GetInstallments)
+		creditCards.GET("/:id/installments/:planId", creditCardHandler.GetInstallment)
+		creditCards.DELETE("/:id/installments/:planId", creditCardHandler.DeleteInstallment)
+		creditCards.GET("/:id/rewards", creditCardHandler.GetRewards)
+		creditCards.PUT("/:id/rewards/rules", creditCardHandler.UpsertRewardRule)
+		creditCards.DELETE("/:id/rewards/rules/:ruleId", creditCardHandler.DeleteRewardRule)
+		creditCards.GET("/:id/rewards/redemptions", creditCardHandler.GetRedemptions)
+		creditCards.POST("/:id/rewards/redemptions", creditCardHandler.CreateRedemption)
+		creditCards.DELETE("/:id/rewards/redemptions/:redemptionId", creditCardHandler.DeleteRedemption)
+	}
+
+	loans := api.Group("/loans")
+	loans.Use(requireAuth, middleware.RequireScope("loans"), householdRoles)
+	{
+		loans.POST("", loanHandler.Create)
+		loans.GET("", loanHandler.GetAll)
+		loans.GET("/payoff-plan", loanHandler.GetPayoffPlan)
+		loans.GET("/:id", loanHandler.GetByID)
+		loans.PUT("/:id", loanHandler.Update)
+		loans.DELETE("/:id", loanHandler.Delete)
+		loans.POST("/:id/payments", loanHandler.CreatePayment)
+		loans.GET("/:id/payments", loanHandler.GetPayments)
+		loans.DELETE("/:id/payments/:paymentId", loanHandler.DeletePayment)
+	}
+
+	contacts := api.Group("/contacts")
+	contacts.Use(requireAuth, middleware.RequireScope("contacts"))
+	{
+		contacts.POST("", contactHandler.Create)
+		contacts.GET("", contactHandler.GetAll)
+		contacts.GET("/:id", contactHandler.GetByID)
+		contacts.PUT("/:id", contactHandler.Update)
+		contacts.DELETE("/:id", contactHandler.Delete)
+		contacts.POST("/:id/share", verification.Require(middleware.FeatureShareLinks), contactHandler.CreateShareLink)
+		contacts.DELETE("/:id/share", contactHandler.DeleteShareLink)
+	}
+
+	ious := api.Group("/ious")
+	ious.Use(requireAuth, middleware.RequireScope("ious"), householdRoles)
+	{
+		ious.POST("", iouHandler.Create)
+		ious.GET("", iouHandler.GetAll)
+		ious.GET("/:id", iouHandler.GetByID)
+		ious.PUT("/:id", iouHandler.Update)
+		ious.DELETE("/:id", iouHandler.Delete)
+		ious.POST("/:id/settlements", iouHandler.CreateSettlement)
+		ious.DELETE("/:id/settlements/:settlementId", iouHandler.DeleteSettlement)
+	}
+
+	splitGroups := api.Group("/split-groups")
+	splitGroups.Use(requireAuth, middleware.RequireScope("split-groups"), householdRoles)
+	{
+		splitGroups.POST("", splitHandler.CreateGroup)
+		splitGroups.GET("", splitHandler.GetGroups)
+		splitGroups.GET("/invitations", splitHandler.GetMyInvitations)
+		splitGroups.POST("/invitations/:invitationId/accept", splitHandler.AcceptInvitation)
+		splitGroups.POST("/invitations/:invitationId/decline", splitHandler.DeclineInvitation)
+		splitGroups.GET("/:id", splitHandler.GetGroup)
+		splitGroups.DELETE("/:id", splitHandler.DeleteGroup)
+		splitGroups.POST("/:id/invitations", splitHandler.InviteMember)
+		splitGroups.DELETE("/:id/members/:userId", splitHandler.RemoveMember)
+		splitGroups.POST("/:id/expenses", splitHandler.CreateExpense)
+		splitGroups.GET("/:id/expenses", splitHandler.GetExpenses)
+		splitGroups.DELETE("/:id/expenses/:expenseId", splitHandler.DeleteExpense)
+		splitGroups.GET("/:id/balances", splitHandler.GetBalances)
+		splitGroups.POST("/:id/settlements", splitHandler.CreateSettlement)
+		splitGroups.GET("/:id/settlements", splitHandler.GetSettlements)
+		splitGroups.POST("/:id/settlements/:settlementId/confirm", splitHandler.ConfirmSettlement)
+		splitGroups.DELETE("/:id/settlements/:settlementId", splitHandler.DeleteSettlement)
+	}
+
+	households := api.Group("/households")
+	households.Use(requireAuth, middleware.RequireScope("households"), householdRoles)
+	{
+		households.POST("", householdHandler.Create)
+		households.GET("", householdHandler.GetAll)
+		households.GET("/invitations", householdHandler.GetMyInvitations)
+		households.POST("/invitations/:invitationId/accept", householdHandler.AcceptInvitation)
+		households.POST("/invitations/:invitationId/decline", householdHandler.DeclineInvitation)
+		households.GET("/:id", householdHandler.GetByID)
+		households.PUT("/:id", householdHandler.Update)
+		households.DELETE("/:id", householdHandler.Delete)
+		households.POST("/:id/invitations", verification.Require(middleware.FeatureHouseholdInvites), householdHandler.CreateInvitation)
+		households.DELETE("/:id/invitations/:invitationId", householdHandler.RevokeInvitation)
+		households.PUT("/:id/members/:userId", householdHandler.UpdateMember)
+		households.DELETE("/:id/members/:userId", householdHandler.RemoveMember)
+	}
+
+	forecasts := api.Group("/forecast")
+	forecasts.Use(requireAuth, middleware.RequireScope("forecast"))
+	{
+		forecasts.GET("", forecastHandler.GetForecast)
+	}
+
+	reports := api.Group("/reports")
+	reports.Use(requireAuth, middleware.RequireScope("reports"))
+	{
+		reports.GET("/monthly", reportHandler.GetMonthly)
+		reports.GET("/yearly", reportHandler.GetYearly)
+		reports.GET("/categories", reportHandler.GetCategories)
+		reports.GET("/compare", reportHandler.Compare)
+		reports.GET("/best-card", reportHandler.GetBestCard)
+	}
+
+	netWorth := api.Group("/net-worth")
+	netWorth.Use(requireAuth, middleware.RequireScope("net-worth"))
+	{
+		netWorth.GET("", netWorthHandler.GetCurrent)
+		netWorth.GET("/history", netWorthHandler.GetHistory)
+	}
+
+	notifications := api.Group("/notifications")
+	notifications.Use(requireAuth, middleware.RequireScope("notifications"))
+	{
+		notifications.GET("", notificationHandler.GetAll)
+		notifications.POST("/read-all", notificationHandler.MarkAllRead)
+		notifications.POST("/:id/read", notificationHandler.MarkRead)
 	}
 
 	goldProtected := api.Group("/gold")
-	goldProtected.Use(middleware.AuthMiddleware())
+	goldProtected.Use(requireAuth, middleware.RequireScope("gold"))
 	{
 		goldProtected.POST("/assets", goldHandler.CreateAsset)
 		goldProtected.GET("/assets", goldHandler.GetAllAssets)
 		goldProtected.GET("/assets/:id", goldHandler.GetAssetByID)
 		goldProtected.DELETE("/assets/:id", goldHandler.DeleteAsset)
 		goldProtected.GET("/summary", goldHandler.GetSummary)
-		// Admin: update today's price
-		goldProtected.POST("/price", goldHandler.UpdateTodayPrice)
+		// Admins and gold price editors set today's price
+		goldProtected.POST("/price", permissions.Require(models.PermGoldPricesWrite), goldHandler.UpdateTodayPrice)
 	}
 
 	// Start server
@@ -148,15 +450,47 @@ func main() {
 	fmt.Println("📊 API Documentation:")
 	fmt.Println("   POST   /api/auth/register")
 	fmt.Println("   POST   /api/auth/login")
+	fmt.Println("   POST   /api/auth/refresh (rotate refresh token)")
+	fmt.Println("   POST   /api/auth/forgot-password (+ /reset-password with the emailed token)")
+	fmt.Println("   POST   /api/auth/verify-email (+ /verify-email/resend when logged in)")
+	fmt.Println("   POST   /api/auth/oidc/:provider/{start,callback} (sign in with OIDC_PROVIDERS; GET /providers lists them)")
 	fmt.Println("   GET    /api/auth/me")
+	fmt.Println("   POST   /api/auth/change-password")
+	fmt.Println("   GET    /api/auth/2fa (+ /setup, /enable, /disable, /recovery-codes; /verify completes a login)")
+	fmt.Println("   POST   /api/auth/logout (+ /logout-all for every device)")
+	fmt.Println("   GET    /api/auth/sessions (+ DELETE /:id to log out a device)")
+	fmt.Println("   CRUD   /api/auth/tokens (personal access tokens with scopes, sent as Bearer ftk_...)")
 	fmt.Println("   CRUD   /api/accounts (with sub-accounts)")
+	fmt.Println("   PUT    /api/accounts/:id/paylater (paylater limit and billing cycle)")
+	fmt.Println("   POST   /api/accounts/:id/paylater/installments (split a paylater purchase)")
 	fmt.Println("   CRUD   /api/transactions")
 	fmt.Println("   CRUD   /api/budgets (month/year based)")
 	fmt.Println("   POST   /api/budgets/copy (copy from previous month)")
+	fmt.Println("   GET    /api/budgets/rollover (automatic monthly copy)")
 	fmt.Println("   CRUD   /api/credit-cards")
+	fmt.Println("   GET    /api/credit-cards/:id/statements (statement cycles)")
+	fmt.Println("   POST   /api/credit-cards/:id/payments (pay from an account)")
+	fmt.Println("   GET    /api/credit-cards/:id/projection?payment= (what if I pay X)")
+	fmt.Println("   CRUD   /api/credit-cards/:id/installments (installment plans)")
+	fmt.Println("   GET    /api/credit-cards/:id/rewards (+ /rules, /redemptions)")
+	fmt.Println("   CRUD   /api/loans (KPR, vehicle and family loans)")
+	fmt.Println("   POST   /api/loans/:id/payments (pay from an account)")
+	fmt.Println("   GET    /api/loans/payoff-plan?budget= (snowball vs avalanche)")
+	fmt.Println("   CRUD   /api/contacts (+ POST /:id/share for a public summary)")
+	fmt.Println("   CRUD   /api/ious (+ /:id/settlements)")
+	fmt.Println("   GET    /api/shared/ious/:token (public)")
+	fmt.Println("   CRUD   /api/split-groups (shared expenses, /:id/invitations, /:id/balances, /:id/settlements, /invitations/:id/{accept,decline})")
+	fmt.Println("   CRUD   /api/households (+ /:id/invitations, /:id/members, /invitations/:id/{accept,decline})")
+	fmt.Println("   PUT    /api/{accounts,budgets,credit-cards}/:id/household (share into a household)")
 	fmt.Println("   CRUD   /api/gold/assets")
 	fmt.Println("   GET    /api/gold/summary")
 	fmt.Println("   GET    /api/gold/price")
+	fmt.Println("   GET    /api/forecast (30/60/90 day cash-flow projection)")
+	fmt.Println("   GET    /api/reports/{monthly,yearly,categories,compare,best-card}")
+	fmt.Println("   GET    /api/net-worth (+ /history)")
+	fmt.Println("   GET    /api/notifications (card reminders and alerts)")
+	fmt.Println("   POST   /api/gold/price (needs the gold_prices:write permission)")
+	fmt.Println("   CRUD   /api/admin/users (+ PUT /:id/two-factor to require 2FA, PUT /:id/roles), /api/admin/roles, /api/admin/api-configs, /api/admin/stats")
 	fmt.Println()
 
 	if err := router.Run(":" + port); err != nil {
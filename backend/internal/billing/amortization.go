@@ -0,0 +1,69 @@
+package billing
+
+import (
+	"math"
+	"time"
+)
+
+// AmortizationMethod is how a loan's interest is computed
+type AmortizationMethod string
+
+const (
+	// AmortizationAnnuity charges interest on the remaining balance with a fixed
+	// monthly payment (anuitas), as banks do for mortgages (KPR)
+	AmortizationAnnuity AmortizationMethod = "annuity"
+	// AmortizationFlat charges interest on the original principal every month
+	// (bunga flat), as vehicle and multipurpose loans are usually quoted
+	AmortizationFlat AmortizationMethod = "flat"
+)
+
+// AmortizationRow is one monthly installment of a loan
+type AmortizationRow struct {
+	Number    int       `json:"number"`
+	DueDate   time.Time `json:"due_date"`
+	Payment   float64   `json:"payment"`
+	Principal float64   `json:"principal"`
+	Interest  float64   `json:"interest"`
+	Balance   float64   `json:"balance"`
+}
+
+// AmortizationSchedule splits a loan into tenor monthly installments at annualRate percent
+// per year. The first installment is due a month after start, on the same day of the month
+// (clamped in shorter months); rounding differences go to the last installment.
+func AmortizationSchedule(principal, annualRate float64, tenor int, method AmortizationMethod, start time.Time) []AmortizationRow {
+	if tenor < 1 {
+		return nil
+	}
+
+	rate := annualRate / 12 / 100
+	payment := roundCents(principal / float64(tenor))
+	flatInterest := roundCents(principal * rate)
+	if method == AmortizationAnnuity && rate > 0 {
+		payment = roundCents(principal * rate / (1 - math.Pow(1+rate, -float64(tenor))))
+	}
+
+	start = Day(start)
+	schedule := make([]AmortizationRow, 0, tenor)
+	balance := principal
+	for n := 1; n <= tenor; n++ {
+		due := firstOfMonth(start).AddDate(0, n, 0)
+		row := AmortizationRow{Number: n, DueDate: DayInMonth(due.Year(), due.Month(), start.Day())}
+
+		if method == AmortizationAnnuity {
+			row.Interest = roundCents(balance * rate)
+			row.Principal = roundCents(payment - row.Interest)
+		} else {
+			row.Interest = flatInterest
+			row.Principal = payment
+		}
+		if n == tenor {
+			row.Principal = roundCents(balance)
+		}
+		row.Payment = roundCents(row.Principal + row.Interest)
+		balance = roundCents(balance - row.Principal)
+		row.Balance = balance
+
+		schedule = append(schedule, row)
+	}
+	return schedule
+}
@@ -0,0 +1,103 @@
+package billing
+
+// Terms are a card's pricing: a monthly interest rate and late fee charged
+// when a statement isn't settled, and the minimum payment rule
+type Terms struct {
+	InterestRate   float64 // percent per month
+	LateFee        float64
+	MinimumPercent float64
+	MinimumFloor   float64
+}
+
+// MinimumPayment returns the minimum due on a statement balance under these terms
+func (t Terms) MinimumPayment(balance float64) float64 {
+	return MinimumPayment(balance, t.MinimumPercent, t.MinimumFloor)
+}
+
+// Charges are what a statement costs when it isn't paid in full by its due date
+type Charges struct {
+	Interest float64 `json:"interest"`
+	LateFee  float64 `json:"late_fee"`
+}
+
+// Total returns the sum of all charges
+func (c Charges) Total() float64 {
+	return roundCents(c.Interest + c.LateFee)
+}
+
+// ChargesFor computes the charges for a statement given what was paid by the due date.
+// Interest is a month of the interest rate on the unpaid remainder; the late fee
+// applies when not even the minimum payment was made.
+func (t Terms) ChargesFor(statementBalance, paidByDue float64) Charges {
+	var c Charges
+	unpaid := statementBalance - paidByDue
+	if unpaid <= 0 {
+		return c
+	}
+	c.Interest = roundCents(unpaid * t.InterestRate / 100)
+	if paidByDue < t.MinimumPayment(statementBalance) {
+		c.LateFee = t.LateFee
+	}
+	return c
+}
+
+// PayoffMonth is one month of a payoff projection
+type PayoffMonth struct {
+	Month        int     `json:"month"`
+	StartBalance float64 `json:"start_balance"`
+	Payment      float64 `json:"payment"`
+	Interest     float64 `json:"interest"`
+	LateFee      float64 `json:"late_fee"`
+	EndBalance   float64 `json:"end_balance"`
+}
+
+// Payoff projects paying a fixed amount every month with no new spending
+type Payoff struct {
+	Payment       float64       `json:"payment"`
+	PaidOff       bool          `json:"paid_off"`
+	Months        int           `json:"months"`
+	TotalPaid     float64       `json:"total_paid"`
+	TotalInterest float64       `json:"total_interest"`
+	TotalFees     float64       `json:"total_fees"`
+	Schedule      []PayoffMonth `json:"schedule"`
+}
+
+// ProjectPayoff simulates paying payment each month against balance for at most maxMonths.
+// PaidOff is false when the balance isn't cleared within maxMonths, or when the
+// payment doesn't cover the charges, in which case the projection stops right away.
+func (t Terms) ProjectPayoff(balance, payment float64, maxMonths int) Payoff {
+	p := Payoff{Payment: payment, Schedule: []PayoffMonth{}}
+	for month := 1; month <= maxMonths && balance > 0; month++ {
+		paid := payment
+		if paid > balance {
+			paid = balance
+		}
+		charges := t.ChargesFor(balance, paid)
+		end := roundCents(balance - paid + charges.Total())
+
+		p.Schedule = append(p.Schedule, PayoffMonth{
+			Month:        month,
+			StartBalance: balance,
+			Payment:      paid,
+			Interest:     charges.Interest,
+			LateFee:      charges.LateFee,
+			EndBalance:   end,
+		})
+		p.Months = month
+		p.TotalPaid += paid
+		p.TotalInterest += charges.Interest
+		p.TotalFees += charges.LateFee
+		// A payment that doesn't outpace the charges never clears the balance
+		if end >= balance {
+			balance = end
+			break
+		}
+		balance = end
+	}
+
+	p.PaidOff = balance <= 0
+	p.TotalPaid = roundCents(p.TotalPaid)
+	p.TotalInterest = roundCents(p.TotalInterest)
+	p.TotalFees = roundCents(p.TotalFees)
+	return p
+}
@@ -0,0 +1,94 @@
+// Package billing computes credit statement cycles from a billing (statement) day
+// and a payment due day, handling months with fewer days.
+package billing
+
+import "time"
+
+// Cycle is one statement period. Transactions dated from Start through Close
+// (both inclusive) belong to the statement issued on Close, payable by Due.
+type Cycle struct {
+	Start      time.Time `json:"period_start"`
+	Close      time.Time `json:"statement_date"`
+	Due        time.Time `json:"due_date"`
+	billingDay int
+	dueDay     int
+}
+
+// CycleFor returns the cycle that contains date
+func CycleFor(billingDay, dueDay int, date time.Time) Cycle {
+	date = Day(date)
+	closing := DayInMonth(date.Year(), date.Month(), billingDay)
+	if date.After(closing) {
+		next := firstOfMonth(date).AddDate(0, 1, 0)
+		closing = DayInMonth(next.Year(), next.Month(), billingDay)
+	}
+	return cycleClosingOn(billingDay, dueDay, closing)
+}
+
+// Previous returns the cycle right before c
+func (c Cycle) Previous() Cycle {
+	prev := firstOfMonth(c.Close).AddDate(0, -1, 0)
+	return cycleClosingOn(c.billingDay, c.dueDay, DayInMonth(prev.Year(), prev.Month(), c.billingDay))
+}
+
+// Next returns the cycle right after c
+func (c Cycle) Next() Cycle {
+	next := firstOfMonth(c.Close).AddDate(0, 1, 0)
+	return cycleClosingOn(c.billingDay, c.dueDay, DayInMonth(next.Year(), next.Month(), c.billingDay))
+}
+
+// End is the exclusive upper bound of the cycle, for half-open range queries
+func (c Cycle) End() time.Time {
+	return c.Close.AddDate(0, 0, 1)
+}
+
+// Contains reports whether date falls within the cycle
+func (c Cycle) Contains(date time.Time) bool {
+	date = Day(date)
+	return !date.Before(c.Start) && !date.After(c.Close)
+}
+
+func cycleClosingOn(billingDay, dueDay int, closing time.Time) Cycle {
+	prev := firstOfMonth(closing).AddDate(0, -1, 0)
+	prevClose := DayInMonth(prev.Year(), prev.Month(), billingDay)
+	return Cycle{
+		Start:      prevClose.AddDate(0, 0, 1),
+		Close:      closing,
+		Due:        DueDate(billingDay, dueDay, closing),
+		billingDay: billingDay,
+		dueDay:     dueDay,
+	}
+}
+
+// DueDate returns the payment due date of the statement issued on the closing date.
+// The due day falls in the statement month when it comes after the billing day,
+// otherwise in the following month.
+func DueDate(billingDay, dueDay int, closing time.Time) time.Time {
+	if dueDay > billingDay {
+		due := DayInMonth(closing.Year(), closing.Month(), dueDay)
+		if due.After(closing) {
+			return due
+		}
+	}
+	next := firstOfMonth(closing).AddDate(0, 1, 0)
+	return DayInMonth(next.Year(), next.Month(), dueDay)
+}
+
+// DayInMonth returns the given day of a month, clamped to the month's last day
+// (e.g. day 31 in February is February 28 or 29)
+func DayInMonth(year int, month time.Month, day int) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Day truncates t to midnight UTC of its calendar date
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func firstOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,97 @@
+package billing
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestDayInMonth(t *testing.T) {
+	tests := []struct {
+		year  int
+		month time.Month
+		day   int
+		want  string
+	}{
+		{2026, time.March, 15, "2026-03-15"},
+		{2026, time.February, 31, "2026-02-28"},
+		{2028, time.February, 31, "2028-02-29"},
+		{2026, time.April, 31, "2026-04-30"},
+		{2026, time.December, 31, "2026-12-31"},
+	}
+	for _, tt := range tests {
+		if got := DayInMonth(tt.year, tt.month, tt.day).Format("2006-01-02"); got != tt.want {
+			t.Errorf("DayInMonth(%d, %s, %d) = %s, want %s", tt.year, tt.month, tt.day, got, tt.want)
+		}
+	}
+}
+
+func TestDueDate(t *testing.T) {
+	tests := []struct {
+		name               string
+		billingDay, dueDay int
+		closing            string
+		want               string
+	}{
+		{"due day after billing day falls in the same month", 5, 20, "2026-03-05", "2026-03-20"},
+		{"due day before billing day falls in the next month", 25, 10, "2026-03-25", "2026-04-10"},
+		{"crosses the year boundary", 20, 5, "2026-12-20", "2027-01-05"},
+		{"clamped closing day isn't passed by a clamped due day", 28, 31, "2026-02-28", "2026-03-31"},
+		{"due day 31 in a 30-day month", 15, 31, "2026-04-15", "2026-04-30"},
+		{"due day 30 in February", 10, 30, "2026-02-10", "2026-02-28"},
+	}
+	for _, tt := range tests {
+		if got := DueDate(tt.billingDay, tt.dueDay, date(tt.closing)).Format("2006-01-02"); got != tt.want {
+			t.Errorf("%s: DueDate(%d, %d, %s) = %s, want %s", tt.name, tt.billingDay, tt.dueDay, tt.closing, got, tt.want)
+		}
+	}
+}
+
+func TestCycleFor(t *testing.T) {
+	tests := []struct {
+		name               string
+		billingDay, dueDay int
+		date               string
+		start, close, due  string
+	}{
+		{"before the billing day", 25, 10, "2026-03-10", "2026-02-26", "2026-03-25", "2026-04-10"},
+		{"on the billing day", 25, 10, "2026-03-25", "2026-02-26", "2026-03-25", "2026-04-10"},
+		{"after the billing day", 25, 10, "2026-03-26", "2026-03-26", "2026-04-25", "2026-05-10"},
+		{"billing day 31 in February", 31, 15, "2026-02-10", "2026-02-01", "2026-02-28", "2026-03-15"},
+		{"billing day 31 in a leap February", 31, 15, "2028-02-29", "2028-02-01", "2028-02-29", "2028-03-15"},
+		{"the cycle after a short month", 31, 15, "2026-03-01", "2026-03-01", "2026-03-31", "2026-04-15"},
+		{"closes in December, due in January", 20, 5, "2026-12-01", "2026-11-21", "2026-12-20", "2027-01-05"},
+		{"starts in December, closes in January", 20, 5, "2026-12-25", "2026-12-21", "2027-01-20", "2027-02-05"},
+	}
+	for _, tt := range tests {
+		c := CycleFor(tt.billingDay, tt.dueDay, date(tt.date))
+		got := [3]string{c.Start.Format("2006-01-02"), c.Close.Format("2006-01-02"), c.Due.Format("2006-01-02")}
+		if want := [3]string{tt.start, tt.close, tt.due}; got != want {
+			t.Errorf("%s: CycleFor(%d, %d, %s) = %v, want %v", tt.name, tt.billingDay, tt.dueDay, tt.date, got, want)
+		}
+		if !c.Contains(date(tt.date)) {
+			t.Errorf("%s: cycle %v doesn't contain %s", tt.name, got, tt.date)
+		}
+	}
+}
+
+func TestCyclesFollowEachOther(t *testing.T) {
+	c := CycleFor(31, 15, date("2026-01-15"))
+	for i := 0; i < 24; i++ {
+		next := c.Next()
+		if !next.Start.Equal(c.End()) {
+			t.Fatalf("cycle closing %s is followed by one starting %s", c.Close.Format("2006-01-02"), next.Start.Format("2006-01-02"))
+		}
+		if prev := next.Previous(); !prev.Close.Equal(c.Close) {
+			t.Fatalf("Previous of the cycle closing %s closes %s", next.Close.Format("2006-01-02"), prev.Close.Format("2006-01-02"))
+		}
+		c = next
+	}
+}
@@ -0,0 +1,53 @@
+package billing
+
+import (
+	"math"
+	"time"
+)
+
+// Installment is one monthly posting of an installment plan
+type Installment struct {
+	Number    int       `json:"number"`
+	Date      time.Time `json:"date"`
+	Principal float64   `json:"principal"`
+	Interest  float64   `json:"interest"`
+	Fee       float64   `json:"fee"`
+	Amount    float64   `json:"amount"`
+}
+
+// InstallmentSchedule splits a purchase into tenor monthly postings.
+// Interest is flat: monthlyRate percent of the original principal every month,
+// as card issuers quote it. The admin fee is charged with the first installment.
+// Each installment posts on the statement date of its cycle, starting with the
+// cycle containing the purchase; rounding differences go to the last installment.
+func InstallmentSchedule(principal float64, tenor int, monthlyRate, adminFee float64, first Cycle) []Installment {
+	if tenor < 1 {
+		return nil
+	}
+
+	monthlyPrincipal := roundCents(principal / float64(tenor))
+	interest := roundCents(principal * monthlyRate / 100)
+
+	schedule := make([]Installment, 0, tenor)
+	cycle := first
+	remaining := principal
+	for n := 1; n <= tenor; n++ {
+		inst := Installment{Number: n, Date: cycle.Close, Principal: monthlyPrincipal, Interest: interest}
+		if n == tenor {
+			inst.Principal = roundCents(remaining)
+		}
+		if n == 1 {
+			inst.Fee = adminFee
+		}
+		inst.Amount = roundCents(inst.Principal + inst.Interest + inst.Fee)
+		remaining -= inst.Principal
+
+		schedule = append(schedule, inst)
+		cycle = cycle.Next()
+	}
+	return schedule
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,19 @@
+package billing
+
+import "math"
+
+// Default minimum payment rule: 10% of the statement balance, at least Rp 50.000
+const (
+	DefaultMinimumPaymentPercent = 10
+	DefaultMinimumPaymentFloor   = 50000
+)
+
+// MinimumPayment returns the minimum due for a statement balance: percent of the
+// balance but not less than floor, and never more than the balance itself
+func MinimumPayment(balance, percent, floor float64) float64 {
+	if balance <= 0 {
+		return 0
+	}
+	minimum := math.Max(balance*percent/100, floor)
+	return roundCents(math.Min(minimum, balance))
+}
@@ -0,0 +1,139 @@
+package billing
+
+import "sort"
+
+// PayoffStrategy decides which debt gets the money left after minimum payments
+type PayoffStrategy string
+
+const (
+	// PayoffSnowball pays off the smallest balance first
+	PayoffSnowball PayoffStrategy = "snowball"
+	// PayoffAvalanche pays off the highest interest rate first
+	PayoffAvalanche PayoffStrategy = "avalanche"
+)
+
+// Debt is one balance in a payoff plan
+type Debt struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Kind           string  `json:"kind"`
+	Balance        float64 `json:"balance"`
+	AnnualRate     float64 `json:"annual_rate"`
+	MinimumPayment float64 `json:"minimum_payment"`
+}
+
+// DebtPayoff is when a debt is cleared under a plan and what it cost
+type DebtPayoff struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Kind          string  `json:"kind"`
+	PaidOffMonth  int     `json:"paid_off_month"`
+	TotalInterest float64 `json:"total_interest"`
+}
+
+// PayoffPlan is the result of paying a fixed monthly budget across debts with a strategy
+type PayoffPlan struct {
+	Strategy      PayoffStrategy `json:"strategy"`
+	MonthlyBudget float64        `json:"monthly_budget"`
+	PaidOff       bool           `json:"paid_off"`
+	Months        int            `json:"months"`
+	TotalPaid     float64        `json:"total_paid"`
+	TotalInterest float64        `json:"total_interest"`
+	Order         []DebtPayoff   `json:"order"`
+}
+
+// PlanPayoff simulates paying budget every month for at most maxMonths: interest accrues
+// monthly on each balance, every debt gets its minimum payment and whatever is left goes
+// to the debt the strategy puts first. Money freed by a cleared debt rolls over to the next.
+// The plan stops early when a month's payments don't outpace the interest.
+func PlanPayoff(debts []Debt, budget float64, strategy PayoffStrategy, maxMonths int) PayoffPlan {
+	plan := PayoffPlan{Strategy: strategy, MonthlyBudget: budget, Order: []DebtPayoff{}}
+
+	order := []Debt{}
+	for _, d := range debts {
+		if d.Balance > 0 {
+			order = append(order, d)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if strategy == PayoffAvalanche && order[i].AnnualRate != order[j].AnnualRate {
+			return order[i].AnnualRate > order[j].AnnualRate
+		}
+		return order[i].Balance < order[j].Balance
+	})
+
+	interest := make([]float64, len(order))
+	open := len(order)
+
+	for month := 1; month <= maxMonths && open > 0; month++ {
+		before, left := 0.0, budget
+		for i := range order {
+			if order[i].Balance <= 0 {
+				continue
+			}
+			before += order[i].Balance
+			charged := roundCents(order[i].Balance * order[i].AnnualRate / 12 / 100)
+			order[i].Balance = roundCents(order[i].Balance + charged)
+			interest[i] += charged
+			plan.TotalInterest += charged
+		}
+
+		pay := func(i int, amount float64) {
+			if amount > order[i].Balance {
+				amount = order[i].Balance
+			}
+			if amount > left {
+				amount = left
+			}
+			order[i].Balance = roundCents(order[i].Balance - amount)
+			left -= amount
+			plan.TotalPaid += amount
+		}
+		for i := range order {
+			if order[i].Balance > 0 {
+				pay(i, order[i].MinimumPayment)
+			}
+		}
+		for i := range order {
+			if order[i].Balance > 0 && left > 0 {
+				pay(i, left)
+			}
+		}
+
+		after := 0.0
+		for i := range order {
+			if order[i].Balance > 0 {
+				after += order[i].Balance
+				continue
+			}
+			if !cleared(plan.Order, order[i].ID) {
+				plan.Order = append(plan.Order, DebtPayoff{
+					ID:            order[i].ID,
+					Name:          order[i].Name,
+					Kind:          order[i].Kind,
+					PaidOffMonth:  month,
+					TotalInterest: roundCents(interest[i]),
+				})
+				open--
+			}
+		}
+		plan.Months = month
+		if after >= before {
+			break
+		}
+	}
+
+	plan.PaidOff = open == 0
+	plan.TotalPaid = roundCents(plan.TotalPaid)
+	plan.TotalInterest = roundCents(plan.TotalInterest)
+	return plan
+}
+
+func cleared(order []DebtPayoff, id string) bool {
+	for _, p := range order {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
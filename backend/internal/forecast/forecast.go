@@ -0,0 +1,393 @@
+// Package forecast projects daily account balances from historical
+// transactions, recurring patterns and scheduled payments.
+package forecast
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const dateLayout = "2006-01-02"
+
+// Flow is a historical cash movement on an account. Amount is signed:
+// positive for money in, negative for money out.
+type Flow struct {
+	AccountID   uuid.UUID
+	Date        time.Time
+	Amount      float64
+	Category    string
+	Description string
+}
+
+// ScheduledFlow is a known future movement, e.g. a credit card due amount.
+// AccountID is nil when it's unknown which account will pay it; such flows
+// only affect the total projection.
+type ScheduledFlow struct {
+	AccountID *uuid.UUID
+	Date      time.Time
+	Amount    float64
+	Label     string
+}
+
+// AccountBalance is the starting point of an account projection. OpenedAt is when the
+// account was created; the zero time means it's older than the history.
+type AccountBalance struct {
+	AccountID uuid.UUID
+	Name      string
+	Balance   float64
+	OpenedAt  time.Time
+}
+
+type Input struct {
+	Start       time.Time
+	Days        int
+	Accounts    []AccountBalance
+	History     []Flow
+	HistoryDays int
+	Scheduled   []ScheduledFlow
+}
+
+// RecurringPattern is a flow that repeats monthly around the same day
+type RecurringPattern struct {
+	AccountID   uuid.UUID `json:"account_id"`
+	Category    string    `json:"category"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	DayOfMonth  int       `json:"day_of_month"`
+	Occurrences int       `json:"occurrences"`
+	// months holds the months (2006-01) the pattern already posted in
+	months map[string]bool
+}
+
+type DailyBalance struct {
+	Date    string  `json:"date"`
+	Balance float64 `json:"balance"`
+	Inflow  float64 `json:"inflow"`
+	Outflow float64 `json:"outflow"`
+}
+
+type AccountProjection struct {
+	AccountID         uuid.UUID      `json:"account_id"`
+	Name              string         `json:"name"`
+	StartingBalance   float64        `json:"starting_balance"`
+	EndingBalance     float64        `json:"ending_balance"`
+	LowestBalance     float64        `json:"lowest_balance"`
+	LowestBalanceDate string         `json:"lowest_balance_date"`
+	AverageDailyNet   float64        `json:"average_daily_net"`
+	NegativeDates     []string       `json:"negative_dates"`
+	Daily             []DailyBalance `json:"daily"`
+}
+
+type TotalProjection struct {
+	StartingBalance   float64        `json:"starting_balance"`
+	EndingBalance     float64        `json:"ending_balance"`
+	LowestBalance     float64        `json:"lowest_balance"`
+	LowestBalanceDate string         `json:"lowest_balance_date"`
+	NegativeDates     []string       `json:"negative_dates"`
+	Daily             []DailyBalance `json:"daily"`
+}
+
+type Result struct {
+	StartDate string              `json:"start_date"`
+	EndDate   string              `json:"end_date"`
+	Days      int                 `json:"days"`
+	Accounts  []AccountProjection `json:"accounts"`
+	Total     TotalProjection     `json:"total"`
+	Recurring []RecurringPattern  `json:"recurring"`
+	// HasNegativeBalance is true when any account or the total goes below zero
+	HasNegativeBalance bool `json:"has_negative_balance"`
+}
+
+// Project runs the forecast. Balances start at the end of in.Start's previous day,
+// i.e. the first projected day is in.Start itself. A recurring flow isn't projected in a
+// month it has already posted in, e.g. a salary paid a day early.
+func Project(in Input) Result {
+	start := truncateDay(in.Start)
+	historyDays := in.HistoryDays
+	if historyDays <= 0 {
+		historyDays = 90
+	}
+
+	recurring, recurringFlows := DetectRecurring(in.History)
+
+	// Baseline: average daily net of everything that isn't a recurring pattern, over the
+	// part of the history each account has existed for
+	net := make(map[uuid.UUID]float64)
+	firstFlow := make(map[uuid.UUID]time.Time)
+	for i, f := range in.History {
+		if first, ok := firstFlow[f.AccountID]; !ok || f.Date.Before(first) {
+			firstFlow[f.AccountID] = f.Date
+		}
+		if recurringFlows[i] {
+			continue
+		}
+		net[f.AccountID] += f.Amount
+	}
+	baseline := make(map[uuid.UUID]float64)
+	for _, acc := range in.Accounts {
+		baseline[acc.AccountID] = net[acc.AccountID] / float64(historySpan(acc.OpenedAt, firstFlow[acc.AccountID], start, historyDays))
+	}
+
+	result := Result{
+		StartDate: start.Format(dateLayout),
+		EndDate:   start.AddDate(0, 0, in.Days-1).Format(dateLayout),
+		Days:      in.Days,
+		Accounts:  []AccountProjection{},
+		Recurring: recurring,
+	}
+
+	totalDaily := make([]DailyBalance, in.Days)
+	totalBalance := 0.0
+	for d := range totalDaily {
+		totalDaily[d].Date = start.AddDate(0, 0, d).Format(dateLayout)
+	}
+
+	for _, acc := range in.Accounts {
+		proj := AccountProjection{
+			AccountID:       acc.AccountID,
+			Name:            acc.Name,
+			StartingBalance: acc.Balance,
+			AverageDailyNet: round(baseline[acc.AccountID]),
+			NegativeDates:   []string{},
+			Daily:           make([]DailyBalance, in.Days),
+		}
+		totalBalance += acc.Balance
+
+		balance := acc.Balance
+		proj.LowestBalance = balance
+		proj.LowestBalanceDate = start.Format(dateLayout)
+		for d := 0; d < in.Days; d++ {
+			day := start.AddDate(0, 0, d)
+			inflow, outflow := splitFlow(baseline[acc.AccountID])
+			for _, p := range recurring {
+				if p.AccountID == acc.AccountID && occursOn(p.DayOfMonth, day) && !p.months[day.Format("2006-01")] {
+					credit, debit := splitFlow(p.Amount)
+					inflow += credit
+					outflow += debit
+				}
+			}
+			for _, s := range in.Scheduled {
+				if s.AccountID != nil && *s.AccountID == acc.AccountID && sameDay(s.Date, day) {
+					credit, debit := splitFlow(s.Amount)
+					inflow += credit
+					outflow += debit
+				}
+			}
+
+			balance += inflow - outflow
+			proj.Daily[d] = DailyBalance{Date: day.Format(dateLayout), Balance: round(balance), Inflow: round(inflow), Outflow: round(outflow)}
+			totalDaily[d].Inflow += inflow
+			totalDaily[d].Outflow += outflow
+
+			if balance < proj.LowestBalance {
+				proj.LowestBalance = balance
+				proj.LowestBalanceDate = day.Format(dateLayout)
+			}
+			if balance < 0 {
+				proj.NegativeDates = append(proj.NegativeDates, day.Format(dateLayout))
+			}
+		}
+		proj.EndingBalance = round(balance)
+		proj.LowestBalance = round(proj.LowestBalance)
+		if len(proj.NegativeDates) > 0 {
+			result.HasNegativeBalance = true
+		}
+		result.Accounts = append(result.Accounts, proj)
+	}
+
+	// Scheduled flows without an account only affect the total
+	for _, s := range in.Scheduled {
+		if s.AccountID != nil {
+			continue
+		}
+		for d := range totalDaily {
+			if sameDay(s.Date, start.AddDate(0, 0, d)) {
+				credit, debit := splitFlow(s.Amount)
+				totalDaily[d].Inflow += credit
+				totalDaily[d].Outflow += debit
+			}
+		}
+	}
+
+	total := TotalProjection{
+		StartingBalance:   round(totalBalance),
+		LowestBalance:     totalBalance,
+		LowestBalanceDate: start.Format(dateLayout),
+		NegativeDates:     []string{},
+		Daily:             totalDaily,
+	}
+	balance := totalBalance
+	for d := range totalDaily {
+		balance += totalDaily[d].Inflow - totalDaily[d].Outflow
+		totalDaily[d].Balance = round(balance)
+		totalDaily[d].Inflow = round(totalDaily[d].Inflow)
+		totalDaily[d].Outflow = round(totalDaily[d].Outflow)
+		if balance < total.LowestBalance {
+			total.LowestBalance = balance
+			total.LowestBalanceDate = totalDaily[d].Date
+		}
+		if balance < 0 {
+			total.NegativeDates = append(total.NegativeDates, totalDaily[d].Date)
+		}
+	}
+	total.EndingBalance = round(balance)
+	total.LowestBalance = round(total.LowestBalance)
+	if len(total.NegativeDates) > 0 {
+		result.HasNegativeBalance = true
+	}
+	result.Total = total
+
+	return result
+}
+
+// DetectRecurring finds flows that repeat in at least two different months with
+// a similar amount (same account, category, description and direction).
+// The second return value marks which history entries belong to a pattern.
+func DetectRecurring(history []Flow) ([]RecurringPattern, map[int]bool) {
+	type groupKey struct {
+		accountID   uuid.UUID
+		category    string
+		description string
+		income      bool
+	}
+
+	groups := make(map[groupKey][]int)
+	for i, f := range history {
+		key := groupKey{
+			accountID:   f.AccountID,
+			category:    strings.ToLower(strings.TrimSpace(f.Category)),
+			description: strings.ToLower(strings.TrimSpace(f.Description)),
+			income:      f.Amount > 0,
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	patterns := []RecurringPattern{}
+	members := make(map[int]bool)
+	for _, idx := range groups {
+		months := make(map[string]bool)
+		amounts := make([]float64, 0, len(idx))
+		days := make([]int, 0, len(idx))
+		for _, i := range idx {
+			months[history[i].Date.Format("2006-01")] = true
+			amounts = append(amounts, history[i].Amount)
+			days = append(days, history[i].Date.Day())
+		}
+		// Exactly one flow per month keeps daily spending (e.g. coffee) out of the patterns
+		if len(months) < 2 || len(months) != len(idx) {
+			continue
+		}
+
+		med := median(amounts)
+		similar := true
+		for _, a := range amounts {
+			if math.Abs(a-med) > math.Abs(med)*0.2 {
+				similar = false
+				break
+			}
+		}
+		if !similar {
+			continue
+		}
+
+		sum := 0.0
+		for _, a := range amounts {
+			sum += a
+		}
+		first := history[idx[0]]
+		patterns = append(patterns, RecurringPattern{
+			AccountID:   first.AccountID,
+			Category:    first.Category,
+			Description: first.Description,
+			Amount:      round(sum / float64(len(amounts))),
+			DayOfMonth:  int(medianInt(days)),
+			Occurrences: len(idx),
+			months:      months,
+		})
+		for _, i := range idx {
+			members[i] = true
+		}
+	}
+
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].DayOfMonth != patterns[j].DayOfMonth {
+			return patterns[i].DayOfMonth < patterns[j].DayOfMonth
+		}
+		return patterns[i].Category < patterns[j].Category
+	})
+	return patterns, members
+}
+
+// historySpan is how many days of history before start an account has: historyDays, or
+// fewer when it was opened since. Transactions dated before the account was created,
+// e.g. entered after the fact, move its opening back to the first of them.
+func historySpan(openedAt, firstFlow, start time.Time, historyDays int) int {
+	if openedAt.IsZero() {
+		return historyDays
+	}
+	opened := openedAt
+	if !firstFlow.IsZero() && firstFlow.Before(opened) {
+		opened = firstFlow
+	}
+	days := int(start.Sub(truncateDay(opened)).Hours() / 24)
+	if days < 1 {
+		return 1
+	}
+	if days > historyDays {
+		return historyDays
+	}
+	return days
+}
+
+func occursOn(dayOfMonth int, date time.Time) bool {
+	return clampDay(date.Year(), date.Month(), dayOfMonth, date.Location()).Day() == date.Day()
+}
+
+func clampDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+func splitFlow(amount float64) (inflow, outflow float64) {
+	if amount >= 0 {
+		return amount, 0
+	}
+	return 0, -amount
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func medianInt(values []int) float64 {
+	floats := make([]float64, len(values))
+	for i, v := range values {
+		floats[i] = float64(v)
+	}
+	return math.Round(median(floats))
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
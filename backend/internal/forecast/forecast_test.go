@@ -0,0 +1,189 @@
+package forecast
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func dailyOn(daily []DailyBalance, day string) DailyBalance {
+	for _, d := range daily {
+		if d.Date == day {
+			return d
+		}
+	}
+	return DailyBalance{}
+}
+
+func TestDetectRecurring(t *testing.T) {
+	account := uuid.New()
+	history := []Flow{
+		{AccountID: account, Date: date("2026-07-25"), Amount: 10000000, Category: "Salary", Description: "Payroll"},
+		{AccountID: account, Date: date("2026-08-26"), Amount: 10500000, Category: "salary", Description: "payroll "},
+		{AccountID: account, Date: date("2026-09-25"), Amount: 9500000, Category: "Salary", Description: "Payroll"},
+		// Several a month: everyday spending, not a pattern
+		{AccountID: account, Date: date("2026-08-03"), Amount: -30000, Category: "Coffee"},
+		{AccountID: account, Date: date("2026-08-04"), Amount: -30000, Category: "Coffee"},
+		{AccountID: account, Date: date("2026-09-03"), Amount: -30000, Category: "Coffee"},
+		// Monthly, but the amounts are too far apart
+		{AccountID: account, Date: date("2026-08-10"), Amount: -200000, Category: "Electricity"},
+		{AccountID: account, Date: date("2026-09-10"), Amount: -500000, Category: "Electricity"},
+	}
+
+	patterns, members := DetectRecurring(history)
+	if len(patterns) != 1 {
+		t.Fatalf("expected 1 pattern, got %+v", patterns)
+	}
+	p := patterns[0]
+	if p.AccountID != account || p.DayOfMonth != 25 || p.Amount != 10000000 || p.Occurrences != 3 {
+		t.Errorf("unexpected pattern %+v", p)
+	}
+	if !reflect.DeepEqual(members, map[int]bool{0: true, 1: true, 2: true}) {
+		t.Errorf("unexpected members %v", members)
+	}
+}
+
+func TestProjectClampsDayToShortMonths(t *testing.T) {
+	account := uuid.New()
+	history := []Flow{
+		{AccountID: account, Date: date("2026-07-31"), Amount: -1000000, Category: "Rent"},
+		{AccountID: account, Date: date("2026-08-31"), Amount: -1000000, Category: "Rent"},
+	}
+
+	result := Project(Input{
+		Start:    date("2026-09-01"),
+		Days:     61,
+		Accounts: []AccountBalance{{AccountID: account, Balance: 5000000}},
+		History:  history,
+	})
+	if len(result.Recurring) != 1 || result.Recurring[0].DayOfMonth != 31 {
+		t.Fatalf("expected rent on day 31, got %+v", result.Recurring)
+	}
+
+	daily := result.Accounts[0].Daily
+	for _, day := range []string{"2026-09-30", "2026-10-31"} {
+		if got := dailyOn(daily, day).Outflow; got != 1000000 {
+			t.Errorf("%s: expected rent of 1000000, got %v", day, got)
+		}
+	}
+	for _, day := range []string{"2026-09-29", "2026-10-01", "2026-10-30"} {
+		if got := dailyOn(daily, day).Outflow; got != 0 {
+			t.Errorf("%s: expected no rent, got %v", day, got)
+		}
+	}
+	if result.Accounts[0].EndingBalance != 3000000 {
+		t.Errorf("expected 3000000 after two rents, got %v", result.Accounts[0].EndingBalance)
+	}
+
+	february := Project(Input{
+		Start:    date("2027-02-01"),
+		Days:     28,
+		Accounts: []AccountBalance{{AccountID: account, Balance: 5000000}},
+		History:  history,
+	})
+	if got := dailyOn(february.Accounts[0].Daily, "2027-02-28").Outflow; got != 1000000 {
+		t.Errorf("expected rent on 28 February, got %v", got)
+	}
+}
+
+func TestProjectFlagsNegativeDates(t *testing.T) {
+	payer, other := uuid.New(), uuid.New()
+	result := Project(Input{
+		Start: date("2026-10-01"),
+		Days:  10,
+		Accounts: []AccountBalance{
+			{AccountID: payer, Balance: 1000000},
+			{AccountID: other, Balance: 500000},
+		},
+		Scheduled: []ScheduledFlow{
+			// A card due paid from a chosen account
+			{AccountID: &payer, Date: date("2026-10-05"), Amount: -1200000, Label: "Card"},
+			// Unassigned dues only reach the total
+			{Date: date("2026-10-08"), Amount: -400000, Label: "Other card"},
+		},
+	})
+
+	paying := result.Accounts[0]
+	if !reflect.DeepEqual(paying.NegativeDates, []string{"2026-10-05", "2026-10-06", "2026-10-07", "2026-10-08", "2026-10-09", "2026-10-10"}) {
+		t.Errorf("unexpected negative dates %v", paying.NegativeDates)
+	}
+	if paying.LowestBalance != -200000 || paying.LowestBalanceDate != "2026-10-05" {
+		t.Errorf("unexpected lowest balance %v on %s", paying.LowestBalance, paying.LowestBalanceDate)
+	}
+	if len(result.Accounts[1].NegativeDates) != 0 {
+		t.Errorf("the other account should stay positive, got %v", result.Accounts[1].NegativeDates)
+	}
+
+	if !reflect.DeepEqual(result.Total.NegativeDates, []string{"2026-10-08", "2026-10-09", "2026-10-10"}) {
+		t.Errorf("unexpected total negative dates %v", result.Total.NegativeDates)
+	}
+	if result.Total.EndingBalance != -100000 || !result.HasNegativeBalance {
+		t.Errorf("unexpected total %+v", result.Total)
+	}
+}
+
+func TestProjectBaselineUsesAccountAge(t *testing.T) {
+	start := date("2026-10-01")
+	older, newer := uuid.New(), uuid.New()
+	result := Project(Input{
+		Start: start,
+		Days:  1,
+		Accounts: []AccountBalance{
+			{AccountID: older, Balance: 0},
+			{AccountID: newer, Balance: 0, OpenedAt: start.AddDate(0, 0, -9).Add(15 * time.Hour)},
+		},
+		History: []Flow{
+			{AccountID: older, Date: date("2026-09-20"), Amount: -900000, Category: "Shopping"},
+			{AccountID: newer, Date: date("2026-09-25"), Amount: -900000, Category: "Shopping"},
+		},
+		HistoryDays: 90,
+	})
+
+	if got := result.Accounts[0].AverageDailyNet; got != -10000 {
+		t.Errorf("older account: expected -10000 a day over 90 days, got %v", got)
+	}
+	if got := result.Accounts[1].AverageDailyNet; got != -100000 {
+		t.Errorf("newer account: expected -100000 a day over its 9 days, got %v", got)
+	}
+}
+
+func TestProjectSkipsRecurringAlreadyPosted(t *testing.T) {
+	account := uuid.New()
+	history := []Flow{
+		{AccountID: account, Date: date("2026-08-25"), Amount: 10000000, Category: "Salary"},
+		{AccountID: account, Date: date("2026-09-25"), Amount: 10000000, Category: "Salary"},
+		// October's salary came a day early and is already in the balance
+		{AccountID: account, Date: date("2026-10-24"), Amount: 10000000, Category: "Salary"},
+	}
+
+	result := Project(Input{
+		Start:    date("2026-10-25"),
+		Days:     32,
+		Accounts: []AccountBalance{{AccountID: account, Balance: 10000000}},
+		History:  history,
+	})
+	if len(result.Recurring) != 1 || result.Recurring[0].DayOfMonth != 25 {
+		t.Fatalf("expected salary on day 25, got %+v", result.Recurring)
+	}
+
+	daily := result.Accounts[0].Daily
+	if got := dailyOn(daily, "2026-10-25").Inflow; got != 0 {
+		t.Errorf("October's salary was counted again: %v", got)
+	}
+	if got := dailyOn(daily, "2026-11-25").Inflow; got != 10000000 {
+		t.Errorf("expected November's salary, got %v", got)
+	}
+	if result.Accounts[0].EndingBalance != 20000000 {
+		t.Errorf("expected 20000000 after one more salary, got %v", result.Accounts[0].EndingBalance)
+	}
+}
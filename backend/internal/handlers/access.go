@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Access to accounts, budgets and cards: the owner can do anything, members of the
+// household a record is shared into can view it, and owners and editors can change it.
+// Roles come from middleware.HouseholdRoles.
+
+// householdRole returns the current user's role in the household, if they belong to it
+func householdRole(c *gin.Context, householdID *uuid.UUID) (models.HouseholdRole, bool) {
+	if householdID == nil {
+		return "", false
+	}
+	value, _ := c.Get("household_roles")
+	roles, _ := value.(map[uuid.UUID]models.HouseholdRole)
+	role, ok := roles[*householdID]
+	return role, ok
+}
+
+func isOwner(c *gin.Context, ownerID uuid.UUID) bool {
+	userID, _ := c.Get("user_id")
+	return ownerID == userID.(uuid.UUID)
+}
+
+func canView(c *gin.Context, ownerID uuid.UUID, householdID *uuid.UUID) bool {
+	if isOwner(c, ownerID) {
+		return true
+	}
+	_, ok := householdRole(c, householdID)
+	return ok
+}
+
+func canEdit(c *gin.Context, ownerID uuid.UUID, householdID *uuid.UUID) bool {
+	if isOwner(c, ownerID) {
+		return true
+	}
+	role, ok := householdRole(c, householdID)
+	return ok && role.CanEdit()
+}
+
+// canManage reports whether the current user may delete a record or take it out of its
+// household: its owner, or an owner of the household
+func canManage(c *gin.Context, ownerID uuid.UUID, householdID *uuid.UUID) bool {
+	if isOwner(c, ownerID) {
+		return true
+	}
+	role, ok := householdRole(c, householdID)
+	return ok && role == models.HouseholdRoleOwner
+}
+
+// canAccess checks view access for reads and edit access for everything else, for
+// helpers loading the parent record of a nested route
+func canAccess(c *gin.Context, ownerID uuid.UUID, householdID *uuid.UUID) bool {
+	if c.Request.Method == http.MethodGet {
+		return canView(c, ownerID, householdID)
+	}
+	return canEdit(c, ownerID, householdID)
+}
+
+// checkShare validates a request to move a record into a household (or back out of it
+// when req.HouseholdID is nil) and writes the error response itself. Only the owner
+// can share a record, into a household where they can edit; taking it out is also
+// open to owners of its current household.
+func checkShare(c *gin.Context, ownerID uuid.UUID, current *uuid.UUID, req models.ShareRequest) bool {
+	if !canView(c, ownerID, current) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return false
+	}
+
+	if req.HouseholdID == nil {
+		if !canManage(c, ownerID, current) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return false
+		}
+		return true
+	}
+
+	if !isOwner(c, ownerID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can share this"})
+		return false
+	}
+	role, ok := householdRole(c, req.HouseholdID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Household not found"})
+		return false
+	}
+	if !role.CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Viewers cannot share into the household"})
+		return false
+	}
+	return true
+}
@@ -47,6 +47,20 @@ func (h *AccountHandler) Create(c *gin.Context) {
 		ParentAccountID: req.ParentAccountID,
 	}
 
+	// Pockets live in their parent account's household
+	if req.ParentAccountID != nil {
+		parent, err := h.accountRepo.GetByID(*req.ParentAccountID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Parent account not found"})
+			return
+		}
+		if !canEdit(c, parent.UserID, parent.HouseholdID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		account.HouseholdID = parent.HouseholdID
+	}
+
 	if account.Currency == "" {
 		account.Currency = "IDR"
 	}
@@ -89,9 +103,8 @@ func (h *AccountHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	// Check ownership
-	userID, _ := c.Get("user_id")
-	if account.UserID != userID.(uuid.UUID) {
+	// Check ownership or household membership
+	if !canView(c, account.UserID, account.HouseholdID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -113,8 +126,7 @@ func (h *AccountHandler) Update(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	if account.UserID != userID.(uuid.UUID) {
+	if !canEdit(c, account.UserID, account.HouseholdID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -167,8 +179,7 @@ func (h *AccountHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	if account.UserID != userID.(uuid.UUID) {
+	if !canManage(c, account.UserID, account.HouseholdID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -180,3 +191,39 @@ func (h *AccountHandler) Delete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
 }
+
+// Share moves the account and its pockets into a household, or back to personal when household_id is null
+func (h *AccountHandler) Share(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	account, err := h.accountRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	var req models.ShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !checkShare(c, account.UserID, account.HouseholdID, req) {
+		return
+	}
+	if account.ParentAccountID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pockets are shared together with their parent account"})
+		return
+	}
+
+	if err := h.accountRepo.SetHousehold(account, req.HouseholdID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/financial-tracker/backend/internal/models"
@@ -9,17 +10,26 @@ import (
 	"github.com/google/uuid"
 )
 
+// PermissionInvalidator drops the cached permissions of a user whose roles changed
+type PermissionInvalidator interface {
+	Invalidate(userID uuid.UUID)
+}
+
 type AdminHandler struct {
 	userRepo      *repository.UserRepository
 	apiConfigRepo *repository.APIConfigRepository
 	goldRepo      *repository.GoldRepository
+	roleRepo      *repository.RoleRepository
+	permissions   PermissionInvalidator
 }
 
-func NewAdminHandler(userRepo *repository.UserRepository, apiConfigRepo *repository.APIConfigRepository, goldRepo *repository.GoldRepository) *AdminHandler {
+func NewAdminHandler(userRepo *repository.UserRepository, apiConfigRepo *repository.APIConfigRepository, goldRepo *repository.GoldRepository, roleRepo *repository.RoleRepository, permissions PermissionInvalidator) *AdminHandler {
 	return &AdminHandler{
 		userRepo:      userRepo,
 		apiConfigRepo: apiConfigRepo,
 		goldRepo:      goldRepo,
+		roleRepo:      roleRepo,
+		permissions:   permissions,
 	}
 }
 
@@ -75,7 +85,21 @@ func (h *AdminHandler) UpdateUser(c *gin.Context) {
 	if req.FullName != "" {
 		user.FullName = req.FullName
 	}
-	if req.IsAdmin != nil {
+	// is_admin grants or takes away the admin role, which takes permission to assign roles
+	if req.IsAdmin != nil && *req.IsAdmin != user.IsAdmin {
+		permissions, _ := c.Get("permissions")
+		if granted, _ := permissions.(map[string]bool); !granted[models.PermRolesWrite] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to do this", "code": "permission_denied", "permission": models.PermRolesWrite})
+			return
+		}
+		if !*req.IsAdmin && !h.canRemoveAdmin(c, user.ID) {
+			return
+		}
+		if err := h.roleRepo.SetRole(user.ID, models.RoleAdmin, *req.IsAdmin); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update roles"})
+			return
+		}
+		h.permissions.Invalidate(user.ID)
 		user.IsAdmin = *req.IsAdmin
 	}
 
@@ -109,6 +133,122 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
+// SetTwoFactorRequired makes the user set up 2FA before they can use the app, or lifts
+// that. It takes effect when their access token is next refreshed.
+func (h *AdminHandler) SetTwoFactorRequired(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.SetTwoFactorRequiredRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.userRepo.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.userRepo.SetTwoFactorRequired(id, *req.Required); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+
+	user, _ := h.userRepo.GetByID(id)
+	c.JSON(http.StatusOK, user)
+}
+
+// Roles
+
+// GetRoles lists the roles users can be given, with their permissions
+func (h *AdminHandler) GetRoles(c *gin.Context) {
+	roles, err := h.roleRepo.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get roles"})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+func (h *AdminHandler) GetUserRoles(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if _, err := h.userRepo.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	roles, err := h.roleRepo.GetRolesByUser(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get roles"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// SetUserRoles replaces the user's roles. The change applies on the user's next request.
+func (h *AdminHandler) SetUserRoles(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.SetUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.userRepo.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	keepsAdmin := false
+	for _, role := range req.Roles {
+		if role == models.RoleAdmin {
+			keepsAdmin = true
+		}
+	}
+	if !keepsAdmin && !h.canRemoveAdmin(c, id) {
+		return
+	}
+
+	err = h.roleRepo.SetUserRoles(id, req.Roles)
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update roles"})
+		return
+	}
+	h.permissions.Invalidate(id)
+
+	roles, _ := h.roleRepo.GetRolesByUser(id)
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// canRemoveAdmin stops admins taking the admin role from themselves, which could leave
+// nobody able to administer the app. It writes the error response itself.
+func (h *AdminHandler) canRemoveAdmin(c *gin.Context, userID uuid.UUID) bool {
+	currentUserID, _ := c.Get("user_id")
+	if userID == currentUserID.(uuid.UUID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot remove your own admin role"})
+		return false
+	}
+	return true
+}
+
 // API Configuration Management
 
 func (h *AdminHandler) GetAllAPIConfigs(c *gin.Context) {
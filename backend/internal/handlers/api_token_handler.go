@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxAPITokensPerUser caps how many active personal access tokens a user can have
+const maxAPITokensPerUser = 25
+
+type APITokenHandler struct {
+	apiTokenRepo *repository.APITokenRepository
+	userRepo     *repository.UserRepository
+}
+
+func NewAPITokenHandler(apiTokenRepo *repository.APITokenRepository, userRepo *repository.UserRepository) *APITokenHandler {
+	return &APITokenHandler{apiTokenRepo: apiTokenRepo, userRepo: userRepo}
+}
+
+// GetAll lists the user's active personal access tokens, without the tokens themselves
+func (h *APITokenHandler) GetAll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	tokens, err := h.apiTokenRepo.GetActiveByUser(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Create makes a personal access token. The token is in the response only this once.
+func (h *APITokenHandler) Create(c *gin.Context) {
+	var req models.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scopes := []string{}
+	seen := map[string]bool{}
+	for _, scope := range req.Scopes {
+		if !models.IsValidAPITokenScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + scope, "valid_scopes": models.APITokenScopes})
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	userID, _ := c.Get("user_id")
+	user, err := h.userRepo.GetByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactorRequired && user.TwoFactorEnabledAt == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Set up two-factor authentication to continue", "code": "two_factor_setup_required"})
+		return
+	}
+
+	count, err := h.apiTokenRepo.CountActiveByUser(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API tokens"})
+		return
+	}
+	if count >= maxAPITokensPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many API tokens, revoke one first"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays != nil {
+		t := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	apiToken, token, err := h.apiTokenRepo.Create(user.ID, req.Name, scopes, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateAPITokenResponse{APIToken: *apiToken, Token: token})
+}
+
+// Revoke stops one of the user's tokens working
+func (h *APITokenHandler) Revoke(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API token ID"})
+		return
+	}
+
+	apiToken, err := h.apiTokenRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if apiToken.UserID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	if err := h.apiTokenRepo.Revoke(apiToken.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API token revoked successfully"})
+}
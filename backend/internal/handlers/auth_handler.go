@@ -3,12 +3,18 @@ package handlers
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/financial-tracker/backend/internal/mailer"
 	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/oidc"
+	"github.com/financial-tracker/backend/internal/ratelimit"
 	"github.com/financial-tracker/backend/internal/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -17,11 +23,28 @@ import (
 )
 
 type AuthHandler struct {
-	userRepo *repository.UserRepository
+	userRepo      *repository.UserRepository
+	sessionRepo   *repository.SessionRepository
+	resetRepo     *repository.PasswordResetRepository
+	twoFactorRepo *repository.TwoFactorRepository
+	identityRepo  *repository.IdentityRepository
+	mailer        mailer.Mailer
+	loginGuard    *ratelimit.LoginGuard
+	// OpenID Connect providers users can sign in with, in the order the login page shows them
+	oidcProviders []*oidc.Provider
 }
 
-func NewAuthHandler(userRepo *repository.UserRepository) *AuthHandler {
-	return &AuthHandler{userRepo: userRepo}
+func NewAuthHandler(userRepo *repository.UserRepository, sessionRepo *repository.SessionRepository, resetRepo *repository.PasswordResetRepository, twoFactorRepo *repository.TwoFactorRepository, identityRepo *repository.IdentityRepository, mail mailer.Mailer, loginGuard *ratelimit.LoginGuard, oidcProviders []*oidc.Provider) *AuthHandler {
+	return &AuthHandler{
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		resetRepo:     resetRepo,
+		twoFactorRepo: twoFactorRepo,
+		identityRepo:  identityRepo,
+		mailer:        mail,
+		loginGuard:    loginGuard,
+		oidcProviders: oidcProviders,
+	}
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -80,6 +103,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// The account works right away; verifying the address unlocks the restricted features
+	if err := h.sendVerificationEmail(user); err != nil {
+		log.Printf("Error sending verification email: %v", err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully", "user": user})
 }
 
@@ -93,27 +121,116 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Get user by email or username
 	user, err := h.userRepo.GetByEmailOrUsername(req.UsernameOrEmail)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		user = nil
+	}
+	account := loginGuardAccount(user, req.UsernameOrEmail)
+
+	// Accounts with recent failures have to wait, so passwords can't be guessed quickly.
+	// Unknown usernames are throttled the same way so they can't be told apart.
+	if !h.checkLoginGuard(c, account) {
+		return
+	}
+
+	if user == nil {
+		h.loginFailed(c, account)
 		return
 	}
 
 	// Compare password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		h.loginFailed(c, account)
 		return
 	}
 
-	// Generate JWT token
-	token, err := generateToken(user)
+	h.completeLogin(c, user)
+}
+
+// completeLogin answers a successful first factor, a password or a provider sign-in.
+// With 2FA it only earns a challenge, which VerifyTwoFactor exchanges for the tokens;
+// failed codes count against the account like failed passwords, so the count is only
+// cleared once the whole login succeeds and no challenge is issued while it is locked.
+func (h *AuthHandler) completeLogin(c *gin.Context, user *models.User) {
+	account := userLoginGuardAccount(user.ID)
+	if user.TwoFactorEnabledAt != nil {
+		if !h.checkLoginGuard(c, account) {
+			return
+		}
+		token, expiresAt, err := h.twoFactorRepo.CreateChallenge(user.ID, twoFactorChallengeTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor login"})
+			return
+		}
+		c.JSON(http.StatusOK, models.TwoFactorChallengeResponse{TwoFactorRequired: true, ChallengeToken: token, ExpiresAt: expiresAt})
+		return
+	}
+
+	if err := h.loginGuard.Success(account); err != nil {
+		log.Printf("Error clearing login attempts: %v", err)
+	}
+
+	// Start a session: short-lived access token plus a refresh token to renew it
+	session, refreshToken, err := h.sessionRepo.Create(user.ID, refreshTokenTTL(), sessionClient(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.LoginResponse{
-		Token: token,
-		User:  *user,
-	})
+	h.respondWithTokens(c, user, session, refreshToken)
+}
+
+// Refresh trades a refresh token for a new access token and refresh token. Refresh
+// tokens are single use; replaying one revokes its whole session.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, refreshToken, err := h.sessionRepo.Rotate(req.RefreshToken, refreshTokenTTL(), sessionClient(c))
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		log.Printf("Refresh token reuse detected, session revoked")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token was already used; the session has been revoked, please log in again"})
+		return
+	}
+	if errors.Is(err, repository.ErrRefreshTokenInvalid) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(session.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	h.respondWithTokens(c, user, session, refreshToken)
+}
+
+// Logout ends the current session
+func (h *AuthHandler) Logout(c *gin.Context) {
+	sessionID, _ := c.Get("session_id")
+	if err := h.sessionRepo.Revoke(sessionID.(uuid.UUID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll ends every session of the user, on all devices
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	if err := h.sessionRepo.RevokeAll(userID.(uuid.UUID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all devices successfully"})
 }
 
 func (h *AuthHandler) GetMe(c *gin.Context) {
@@ -127,13 +244,406 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
-func generateToken(user *models.User) (string, error) {
+// GetSessions lists where the user is logged in, marking the session making the request
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	sessionID, _ := c.Get("session_id")
+	sessions, err := h.sessionRepo.GetActiveByUser(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Device = models.DescribeUserAgent(sessions[i].UserAgent)
+		sessions[i].Current = sessions[i].ID == sessionID.(uuid.UUID)
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession logs out one of the user's sessions, e.g. a lost phone
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	session, err := h.sessionRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if session.UserID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	if err := h.sessionRepo.Revoke(session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// ChangePassword sets a new password after checking the current one. Every session is
+// logged out, and the caller gets a fresh session so they stay logged in here.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	user, err := h.userRepo.GetByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	if err := h.userRepo.ChangePassword(user.ID, string(hashedPassword)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	// Reload for the new token version
+	user, err = h.userRepo.GetByID(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+	session, refreshToken, err := h.sessionRepo.Create(user.ID, refreshTokenTTL(), sessionClient(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	h.respondWithTokens(c, user, session, refreshToken)
+}
+
+// ForgotPassword emails a reset link. It answers the same whether or not the address is
+// registered, so it can't be used to find out who has an account.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"message": "If that email is registered, a reset link has been sent"}
+
+	user, err := h.userRepo.GetByEmail(strings.TrimSpace(req.Email))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error looking up user for password reset: %v", err)
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// Create the token and send it in the background so the response time doesn't reveal
+	// whether the user exists
+	go func() {
+		ttl := durationFromEnv("PASSWORD_RESET_TTL", time.Hour)
+		token, err := h.resetRepo.Create(user.ID, ttl)
+		if err != nil {
+			log.Printf("Failed to create password reset token: %v", err)
+			return
+		}
+
+		msg := mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password for your Financial Tracker account. "+
+				"Open this link within %s to choose a new one:\n\n%s\n\nIf it wasn't you, you can ignore this email.\n",
+				user.FullName, ttl, appURL("/reset-password", url.Values{"token": {token}})),
+		}
+		if err := h.mailer.Send(msg); err != nil {
+			log.Printf("Failed to send password reset email: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword. The token works
+// once, and every session of the user is logged out.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	if _, err := h.resetRepo.Consume(req.Token, string(hashedPassword)); err != nil {
+		if errors.Is(err, repository.ErrResetTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+// VerifyEmail marks the user's address verified with the token from their verification email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, email, err := parseVerificationToken(req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	// The link is only good for the address it was sent to
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil || !strings.EqualFold(user.Email, email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Email already verified"})
+		return
+	}
+
+	if err := h.userRepo.MarkEmailVerified(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification sends a fresh verification email, at most once per
+// VERIFICATION_RESEND_COOLDOWN and maxVerificationSendsPerDay times a day
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	user, err := h.userRepo.GetByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email already verified"})
+		return
+	}
+
+	now := time.Now()
+	sentToday, lastSent, err := h.userRepo.GetVerificationSends(user.ID, now.Add(-24*time.Hour))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check verification emails"})
+		return
+	}
+
+	var retryAfter time.Duration
+	if lastSent != nil {
+		retryAfter = lastSent.Add(durationFromEnv("VERIFICATION_RESEND_COOLDOWN", time.Minute)).Sub(now)
+	}
+	if sentToday >= maxVerificationSendsPerDay {
+		retryAfter = 24 * time.Hour
+	}
+	if retryAfter > 0 {
+		ratelimit.TooManyRequests(c, retryAfter, "Verification email sent recently, please try again later")
+		return
+	}
+
+	if err := h.sendVerificationEmail(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// maxVerificationSendsPerDay caps verification emails per user, including the one sent on registration
+const maxVerificationSendsPerDay = 5
+
+// sendVerificationEmail records the send and mails a signed verification link in the background
+func (h *AuthHandler) sendVerificationEmail(user *models.User) error {
+	ttl := durationFromEnv("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	token, err := generateVerificationToken(user, time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+	if err := h.userRepo.RecordVerificationSend(user.ID); err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm this is your email address by opening this link within %s:\n\n%s\n\n"+
+			"If you didn't create a Financial Tracker account, you can ignore this email.\n",
+			user.FullName, ttl, appURL("/verify-email", url.Values{"token": {token}})),
+	}
+	go func() {
+		if err := h.mailer.Send(msg); err != nil {
+			log.Printf("Failed to send verification email: %v", err)
+		}
+	}()
+	return nil
+}
+
+// loginGuardAccount is the key failed logins are counted under: the user's ID, so the
+// username and the email address share one count, or the identifier given for an
+// unknown account
+func loginGuardAccount(user *models.User, identifier string) string {
+	if user != nil {
+		return userLoginGuardAccount(user.ID)
+	}
+	return "name:" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+func userLoginGuardAccount(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// checkLoginGuard answers 429 and returns false while the account has to wait after
+// failed attempts
+func (h *AuthHandler) checkLoginGuard(c *gin.Context, account string) bool {
+	wait, err := h.loginGuard.Wait(account)
+	if err != nil {
+		log.Printf("Error checking login attempts: %v", err)
+		return true
+	}
+	if wait > 0 {
+		ratelimit.TooManyRequests(c, wait, "Too many failed login attempts, please try again later")
+		return false
+	}
+	return true
+}
+
+// loginFailed counts a failed login against the account and answers 401
+func (h *AuthHandler) loginFailed(c *gin.Context, account string) {
+	if err := h.loginGuard.Failure(account, c.ClientIP()); err != nil {
+		log.Printf("Error recording failed login: %v", err)
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+}
+
+func (h *AuthHandler) respondWithTokens(c *gin.Context, user *models.User, session *models.Session, refreshToken string) {
+	token, expiresAt, err := generateToken(user, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LoginResponse{
+		Token:        token,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+		User:         *user,
+	})
+}
+
+// generateToken issues an access token for the session. It is only checked against
+// the session and the user's token version, so keep it short-lived.
+func generateToken(user *models.User, sessionID uuid.UUID) (string, time.Time, error) {
+	expiresAt := time.Now().Add(durationFromEnv("ACCESS_TOKEN_TTL", 15*time.Minute))
 	claims := jwt.MapClaims{
+		"user_id":   user.ID.String(),
+		"email":     user.Email,
+		"sid":       sessionID.String(),
+		"ver":       user.TokenVersion,
+		"tfa_setup": user.TwoFactorRequired && user.TwoFactorEnabledAt == nil,
+		"exp":       expiresAt.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return signed, expiresAt, err
+}
+
+// generateVerificationToken signs a link token for the user's current email address.
+// Its purpose claim keeps it from being accepted anywhere else.
+func generateVerificationToken(user *models.User, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"purpose": "email_verification",
 		"user_id": user.ID.String(),
 		"email":   user.Email,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 days
+		"exp":     expiresAt.Unix(),
 	}
-
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
 }
+
+// parseVerificationToken checks a token from generateVerificationToken and returns the
+// user and address it was issued for
+func parseVerificationToken(tokenString string) (uuid.UUID, string, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	if purpose, _ := claims["purpose"].(string); purpose != "email_verification" {
+		return uuid.Nil, "", errors.New("not an email verification token")
+	}
+	rawID, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	email, _ := claims["email"].(string)
+	return userID, email, nil
+}
+
+// sessionClient describes the device making the request, for the session list
+func sessionClient(c *gin.Context) models.SessionClient {
+	return models.SessionClient{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
+// appURL builds a link into the frontend, which lives at APP_URL
+func appURL(path string, query url.Values) string {
+	base := strings.TrimRight(os.Getenv("APP_URL"), "/")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return base + path + "?" + query.Encode()
+}
+
+// refreshTokenTTL is how long a session stays alive without being refreshed
+func refreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+// durationFromEnv reads a Go duration such as "15m" or "720h" from the environment
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/financial-tracker/backend/internal/models"
 	"github.com/financial-tracker/backend/internal/repository"
@@ -11,25 +14,43 @@ import (
 )
 
 type BudgetHandler struct {
-	budgetRepo *repository.BudgetRepository
+	budgetRepo     *repository.BudgetRepository
+	accountRepo    *repository.AccountRepository
+	creditCardRepo *repository.CreditCardRepository
 }
 
-func NewBudgetHandler(budgetRepo *repository.BudgetRepository) *BudgetHandler {
-	return &BudgetHandler{budgetRepo: budgetRepo}
+func NewBudgetHandler(budgetRepo *repository.BudgetRepository, accountRepo *repository.AccountRepository, creditCardRepo *repository.CreditCardRepository) *BudgetHandler {
+	return &BudgetHandler{
+		budgetRepo:     budgetRepo,
+		accountRepo:    accountRepo,
+		creditCardRepo: creditCardRepo,
+	}
 }
 
 type CreateBudgetRequest struct {
-	Category    string  `json:"category" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	BudgetMonth int     `json:"budget_month" binding:"required,min=1,max=12"`
-	BudgetYear  int     `json:"budget_year" binding:"required,min=2020"`
+	Category     string  `json:"category" binding:"required"`
+	Amount       float64 `json:"amount" binding:"required,gt=0"`
+	BudgetMonth  int     `json:"budget_month" binding:"required,min=1,max=12"`
+	BudgetYear   int     `json:"budget_year" binding:"required,min=2020"`
+	AccountID    string  `json:"account_id"`
+	CreditCardID string  `json:"credit_card_id"`
 }
 
 type CopyBudgetRequest struct {
-	FromMonth int `json:"from_month" binding:"required,min=1,max=12"`
-	FromYear  int `json:"from_year" binding:"required,min=2020"`
-	ToMonth   int `json:"to_month" binding:"required,min=1,max=12"`
-	ToYear    int `json:"to_year" binding:"required,min=2020"`
+	FromMonth     int     `json:"from_month" binding:"required,min=1,max=12"`
+	FromYear      int     `json:"from_year" binding:"required,min=2020"`
+	ToMonth       int     `json:"to_month" binding:"required,min=1,max=12"`
+	ToYear        int     `json:"to_year" binding:"required,min=2020"`
+	Mode          string  `json:"mode" binding:"omitempty,oneof=skip overwrite"`
+	AdjustPercent float64 `json:"adjust_percent" binding:"gte=-100"`
+	AmountSource  string  `json:"amount_source" binding:"omitempty,oneof=budget last_month_actual three_month_average"`
+}
+
+type UpdateRolloverSettingRequest struct {
+	Enabled       bool    `json:"enabled"`
+	Mode          string  `json:"mode" binding:"omitempty,oneof=skip overwrite"`
+	AdjustPercent float64 `json:"adjust_percent" binding:"gte=-100"`
+	AmountSource  string  `json:"amount_source" binding:"omitempty,oneof=budget last_month_actual three_month_average"`
 }
 
 func (h *BudgetHandler) Create(c *gin.Context) {
@@ -41,16 +62,23 @@ func (h *BudgetHandler) Create(c *gin.Context) {
 
 	userID, _ := c.Get("user_id")
 
+	accountID, creditCardID, ok := h.parseScope(c, req)
+	if !ok {
+		return
+	}
+
 	budget := &models.Budget{
-		UserID:      userID.(uuid.UUID),
-		Category:    req.Category,
-		Amount:      req.Amount,
-		BudgetMonth: req.BudgetMonth,
-		BudgetYear:  req.BudgetYear,
+		UserID:       userID.(uuid.UUID),
+		Category:     req.Category,
+		Amount:       req.Amount,
+		BudgetMonth:  req.BudgetMonth,
+		BudgetYear:   req.BudgetYear,
+		AccountID:    accountID,
+		CreditCardID: creditCardID,
 	}
 
 	if err := h.budgetRepo.Create(budget); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create budget. Category might already exist for this month."})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create budget. Category might already exist for this month and scope."})
 		return
 	}
 
@@ -87,6 +115,32 @@ func (h *BudgetHandler) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, budgets)
 }
 
+// GetProgress returns budgets of a month with spending of matching transactions
+func (h *BudgetHandler) GetProgress(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	now := time.Now()
+	month, year := int(now.Month()), now.Year()
+	if m, err := strconv.Atoi(c.Query("month")); err == nil {
+		month = m
+	}
+	if y, err := strconv.Atoi(c.Query("year")); err == nil {
+		year = y
+	}
+	if month < 1 || month > 12 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month"})
+		return
+	}
+
+	progress, err := h.budgetRepo.GetProgressByMonthYear(userID.(uuid.UUID), month, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get budget progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
 func (h *BudgetHandler) GetByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -100,8 +154,7 @@ func (h *BudgetHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	if budget.UserID != userID.(uuid.UUID) {
+	if !canView(c, budget.UserID, budget.HouseholdID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -122,8 +175,7 @@ func (h *BudgetHandler) Update(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	if budget.UserID != userID.(uuid.UUID) {
+	if !canEdit(c, budget.UserID, budget.HouseholdID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -134,13 +186,20 @@ func (h *BudgetHandler) Update(c *gin.Context) {
 		return
 	}
 
+	accountID, creditCardID, ok := h.parseScope(c, req)
+	if !ok {
+		return
+	}
+
 	budget.Category = req.Category
 	budget.Amount = req.Amount
 	budget.BudgetMonth = req.BudgetMonth
 	budget.BudgetYear = req.BudgetYear
+	budget.AccountID = accountID
+	budget.CreditCardID = creditCardID
 
 	if err := h.budgetRepo.Update(budget); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update budget. Category might already exist for this month."})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update budget. Category might already exist for this month and scope."})
 		return
 	}
 
@@ -160,8 +219,7 @@ func (h *BudgetHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	if budget.UserID != userID.(uuid.UUID) {
+	if !canManage(c, budget.UserID, budget.HouseholdID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -174,6 +232,39 @@ func (h *BudgetHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Budget deleted successfully"})
 }
 
+// Share moves the budget into a household, where it counts every member's spending,
+// or back to personal when household_id is null
+func (h *BudgetHandler) Share(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid budget ID"})
+		return
+	}
+
+	budget, err := h.budgetRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+
+	var req models.ShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !checkShare(c, budget.UserID, budget.HouseholdID, req) {
+		return
+	}
+
+	if err := h.budgetRepo.SetHousehold(budget, req.HouseholdID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share budget"})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
 // CopyFromMonth copies budgets from one month to another
 func (h *BudgetHandler) CopyFromMonth(c *gin.Context) {
 	var req CopyBudgetRequest
@@ -182,16 +273,126 @@ func (h *BudgetHandler) CopyFromMonth(c *gin.Context) {
 		return
 	}
 
+	if req.FromMonth == req.ToMonth && req.FromYear == req.ToYear {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source and target month must be different"})
+		return
+	}
+
 	userID, _ := c.Get("user_id")
 
-	count, err := h.budgetRepo.CopyFromMonth(userID.(uuid.UUID), req.FromMonth, req.FromYear, req.ToMonth, req.ToYear)
+	result, err := h.budgetRepo.CopyFromMonth(userID.(uuid.UUID), models.BudgetCopyOptions{
+		FromMonth:     req.FromMonth,
+		FromYear:      req.FromYear,
+		ToMonth:       req.ToMonth,
+		ToYear:        req.ToYear,
+		Mode:          models.BudgetCopyMode(req.Mode),
+		AdjustPercent: req.AdjustPercent,
+		AmountSource:  models.BudgetAmountSource(req.AmountSource),
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy budgets"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Budgets copied successfully",
-		"copied":  count,
+		"message":     "Budgets copied successfully",
+		"copied":      len(result.Created) + len(result.Overwritten),
+		"created":     result.Created,
+		"overwritten": result.Overwritten,
+		"skipped":     result.Skipped,
+		"failed":      result.Failed,
 	})
 }
+
+// GetRolloverSetting returns the automatic monthly rollover setting
+func (h *BudgetHandler) GetRolloverSetting(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	setting, err := h.budgetRepo.GetRolloverSetting(userID.(uuid.UUID))
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusOK, models.BudgetRolloverSetting{
+			UserID:       userID.(uuid.UUID),
+			Mode:         models.BudgetCopyModeSkip,
+			AmountSource: models.BudgetAmountSourceBudget,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rollover setting"})
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+// UpdateRolloverSetting enables or configures the automatic monthly rollover
+func (h *BudgetHandler) UpdateRolloverSetting(c *gin.Context) {
+	var req UpdateRolloverSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	setting := &models.BudgetRolloverSetting{
+		UserID:        userID.(uuid.UUID),
+		Enabled:       req.Enabled,
+		Mode:          models.BudgetCopyMode(req.Mode),
+		AdjustPercent: req.AdjustPercent,
+		AmountSource:  models.BudgetAmountSource(req.AmountSource),
+	}
+	if setting.Mode == "" {
+		setting.Mode = models.BudgetCopyModeSkip
+	}
+	if setting.AmountSource == "" {
+		setting.AmountSource = models.BudgetAmountSourceBudget
+	}
+
+	if err := h.budgetRepo.UpsertRolloverSetting(setting); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rollover setting"})
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+// parseScope validates the optional account or credit card a budget is limited to,
+// which may be one shared into the user's households.
+// It writes the error response itself and returns ok=false on failure.
+func (h *BudgetHandler) parseScope(c *gin.Context, req CreateBudgetRequest) (accountID, creditCardID *uuid.UUID, ok bool) {
+	if req.AccountID != "" && req.CreditCardID != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot scope a budget to both an account and a credit card"})
+		return nil, nil, false
+	}
+
+	if req.AccountID != "" {
+		id, err := uuid.Parse(req.AccountID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+			return nil, nil, false
+		}
+		account, err := h.accountRepo.GetByID(id)
+		if err != nil || !canView(c, account.UserID, account.HouseholdID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found"})
+			return nil, nil, false
+		}
+		accountID = &id
+	}
+
+	if req.CreditCardID != "" {
+		id, err := uuid.Parse(req.CreditCardID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credit card ID"})
+			return nil, nil, false
+		}
+		card, err := h.creditCardRepo.GetByID(id)
+		if err != nil || !canView(c, card.UserID, card.HouseholdID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Credit card not found"})
+			return nil, nil, false
+		}
+		creditCardID = &id
+	}
+
+	return accountID, creditCardID, true
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ContactHandler struct {
+	contactRepo *repository.ContactRepository
+	iouRepo     *repository.IOURepository
+	userRepo    *repository.UserRepository
+}
+
+func NewContactHandler(contactRepo *repository.ContactRepository, iouRepo *repository.IOURepository, userRepo *repository.UserRepository) *ContactHandler {
+	return &ContactHandler{
+		contactRepo: contactRepo,
+		iouRepo:     iouRepo,
+		userRepo:    userRepo,
+	}
+}
+
+func (h *ContactHandler) Create(c *gin.Context) {
+	var req models.CreateContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	contact := &models.Contact{
+		UserID: userID.(uuid.UUID),
+		Name:   req.Name,
+		Phone:  req.Phone,
+		Email:  req.Email,
+		Notes:  req.Notes,
+	}
+	if err := h.contactRepo.Create(contact); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create contact"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contact)
+}
+
+// GetAll returns the user's contacts with what each owes or is owed
+func (h *ContactHandler) GetAll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	contacts, err := h.contactRepo.GetByUserID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get contacts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, contacts)
+}
+
+// GetByID returns a contact with their IOUs
+func (h *ContactHandler) GetByID(c *gin.Context) {
+	contact, ok := h.loadContact(c)
+	if !ok {
+		return
+	}
+
+	ious, err := h.iouRepo.GetByUserID(contact.UserID, repository.IOUFilter{ContactID: &contact.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get IOUs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contact": contact,
+		"ious":    ious,
+	})
+}
+
+func (h *ContactHandler) Update(c *gin.Context) {
+	contact, ok := h.loadContact(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != "" {
+		contact.Name = req.Name
+	}
+	if req.Phone != nil {
+		contact.Phone = *req.Phone
+	}
+	if req.Email != nil {
+		contact.Email = *req.Email
+	}
+	if req.Notes != nil {
+		contact.Notes = *req.Notes
+	}
+
+	if err := h.contactRepo.Update(contact); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update contact"})
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+func (h *ContactHandler) Delete(c *gin.Context) {
+	contact, ok := h.loadContact(c)
+	if !ok {
+		return
+	}
+
+	if err := h.contactRepo.Delete(contact.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contact"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contact deleted successfully"})
+}
+
+// CreateShareLink issues a new share token for the contact's summary, revoking any previous link
+func (h *ContactHandler) CreateShareLink(c *gin.Context) {
+	contact, ok := h.loadContact(c)
+	if !ok {
+		return
+	}
+
+	if err := h.contactRepo.SetShareToken(contact); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"share_token": *contact.ShareToken,
+		"share_path":  "/api/shared/ious/" + *contact.ShareToken,
+	})
+}
+
+func (h *ContactHandler) DeleteShareLink(c *gin.Context) {
+	contact, ok := h.loadContact(c)
+	if !ok {
+		return
+	}
+
+	if err := h.contactRepo.ClearShareToken(contact); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked successfully"})
+}
+
+// GetSharedSummary is the public view behind a share link: the open IOUs between
+// the owner and the contact, seen from the contact's side
+func (h *ContactHandler) GetSharedSummary(c *gin.Context) {
+	contact, err := h.contactRepo.GetByShareToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return
+	}
+
+	owner, err := h.userRepo.GetByID(contact.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get summary"})
+		return
+	}
+	ious, err := h.iouRepo.GetByUserID(contact.UserID, repository.IOUFilter{ContactID: &contact.ID, Status: models.IOUStatusOpen})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get summary"})
+		return
+	}
+
+	summary := models.SharedIOUSummary{
+		OwnerName:   owner.FullName,
+		ContactName: contact.Name,
+		YouOwe:      contact.OwedToMe,
+		OwedToYou:   contact.IOwe,
+		Balance:     contact.Balance,
+		OpenItems:   []models.SharedIOU{},
+		GeneratedAt: time.Now(),
+	}
+	for _, iou := range ious {
+		direction := "you_owe"
+		if iou.Direction == models.IOUDirectionIOwe {
+			direction = "owed_to_you"
+		}
+		summary.OpenItems = append(summary.OpenItems, models.SharedIOU{
+			Direction:   direction,
+			Amount:      iou.Amount,
+			Outstanding: iou.Outstanding,
+			Description: iou.Description,
+			IOUDate:     iou.IOUDate,
+			DueDate:     iou.DueDate,
+		})
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// loadContact fetches the contact in :id and checks it belongs to the current user.
+// It writes the error response itself and returns ok=false on failure.
+func (h *ContactHandler) loadContact(c *gin.Context) (*models.Contact, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return nil, false
+	}
+
+	contact, err := h.contactRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+		return nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+	if contact.UserID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return contact, true
+}
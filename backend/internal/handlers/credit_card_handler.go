@@ -2,7 +2,11 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/financial-tracker/backend/internal/billing"
 	"github.com/financial-tracker/backend/internal/models"
 	"github.com/financial-tracker/backend/internal/repository"
 	"github.com/gin-gonic/gin"
@@ -10,28 +14,85 @@ import (
 )
 
 type CreditCardHandler struct {
-	cardRepo *repository.CreditCardRepository
+	cardRepo        *repository.CreditCardRepository
+	accountRepo     *repository.AccountRepository
+	transactionRepo *repository.TransactionRepository
+	installmentRepo *repository.InstallmentRepository
+	rewardRepo      *repository.RewardRepository
 }
 
-func NewCreditCardHandler(cardRepo *repository.CreditCardRepository) *CreditCardHandler {
-	return &CreditCardHandler{cardRepo: cardRepo}
+func NewCreditCardHandler(cardRepo *repository.CreditCardRepository, accountRepo *repository.AccountRepository, transactionRepo *repository.TransactionRepository, installmentRepo *repository.InstallmentRepository, rewardRepo *repository.RewardRepository) *CreditCardHandler {
+	return &CreditCardHandler{
+		cardRepo:        cardRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		installmentRepo: installmentRepo,
+		rewardRepo:      rewardRepo,
+	}
 }
 
 type CreateCreditCardRequest struct {
-	CardName        string  `json:"card_name" binding:"required"`
-	LastFourDigits  string  `json:"last_four_digits" binding:"required,len=4"`
-	CreditLimit     float64 `json:"credit_limit" binding:"required,gt=0"`
-	BillingDate     int     `json:"billing_date" binding:"required,gte=1,lte=31"`
-	PaymentDueDate  int     `json:"payment_due_date" binding:"required,gte=1,lte=31"`
+	CardName       string  `json:"card_name" binding:"required"`
+	LastFourDigits string  `json:"last_four_digits" binding:"required,len=4"`
+	CreditLimit    float64 `json:"credit_limit" binding:"required,gt=0"`
+	BillingDate    int     `json:"billing_date" binding:"required,gte=1,lte=31"`
+	PaymentDueDate int     `json:"payment_due_date" binding:"required,gte=1,lte=31"`
+	CreditCardSettings
 }
 
 type UpdateCreditCardRequest struct {
-	CardName        string  `json:"card_name" binding:"required"`
-	CreditLimit     float64 `json:"credit_limit" binding:"required,gt=0"`
-	BillingDate     int     `json:"billing_date" binding:"required,gte=1,lte=31"`
-	PaymentDueDate  int     `json:"payment_due_date" binding:"required,gte=1,lte=31"`
+	CardName       string  `json:"card_name" binding:"required"`
+	CreditLimit    float64 `json:"credit_limit" binding:"required,gt=0"`
+	BillingDate    int     `json:"billing_date" binding:"required,gte=1,lte=31"`
+	PaymentDueDate int     `json:"payment_due_date" binding:"required,gte=1,lte=31"`
+	CreditCardSettings
+}
+
+// CreditCardSettings are the optional reminder and pricing settings of a card.
+// Omitted fields keep the card's current value (or the default on create).
+type CreditCardSettings struct {
+	ReminderDaysBefore      *int     `json:"reminder_days_before" binding:"omitempty,gte=0,lte=31"`
+	UtilizationAlertPercent *float64 `json:"utilization_alert_percent" binding:"omitempty,gte=0,lte=100"`
+	InterestRate            *float64 `json:"interest_rate" binding:"omitempty,gte=0,lte=100"`
+	LateFee                 *float64 `json:"late_fee" binding:"omitempty,gte=0"`
+	AnnualFee               *float64 `json:"annual_fee" binding:"omitempty,gte=0"`
+	AnnualFeeMonth          *int     `json:"annual_fee_month" binding:"omitempty,gte=0,lte=12"`
+	MinPaymentPercent       *float64 `json:"min_payment_percent" binding:"omitempty,gte=0,lte=100"`
+	MinPaymentFloor         *float64 `json:"min_payment_floor" binding:"omitempty,gte=0"`
 }
 
+func (s CreditCardSettings) applyTo(card *models.CreditCard) {
+	if s.ReminderDaysBefore != nil {
+		card.ReminderDaysBefore = *s.ReminderDaysBefore
+	}
+	if s.UtilizationAlertPercent != nil {
+		card.UtilizationAlertPercent = *s.UtilizationAlertPercent
+	}
+	if s.InterestRate != nil {
+		card.InterestRate = *s.InterestRate
+	}
+	if s.LateFee != nil {
+		card.LateFee = *s.LateFee
+	}
+	if s.AnnualFee != nil {
+		card.AnnualFee = *s.AnnualFee
+	}
+	if s.AnnualFeeMonth != nil {
+		card.AnnualFeeMonth = *s.AnnualFeeMonth
+	}
+	if s.MinPaymentPercent != nil {
+		card.MinPaymentPercent = *s.MinPaymentPercent
+	}
+	if s.MinPaymentFloor != nil {
+		card.MinPaymentFloor = *s.MinPaymentFloor
+	}
+}
+
+const (
+	defaultReminderDaysBefore      = 3
+	defaultUtilizationAlertPercent = 80
+)
+
 func (h *CreditCardHandler) Create(c *gin.Context) {
 	var req CreateCreditCardRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -49,7 +110,13 @@ func (h *CreditCardHandler) Create(c *gin.Context) {
 		CurrentBalance: 0, // Balance will be calculated from transactions
 		BillingDate:    req.BillingDate,
 		PaymentDueDate: req.PaymentDueDate,
+
+		ReminderDaysBefore:      defaultReminderDaysBefore,
+		UtilizationAlertPercent: defaultUtilizationAlertPercent,
+		MinPaymentPercent:       billing.DefaultMinimumPaymentPercent,
+		MinPaymentFloor:         billing.DefaultMinimumPaymentFloor,
 	}
+	req.CreditCardSettings.applyTo(card)
 
 	if err := h.cardRepo.Create(card); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create credit card"})
@@ -67,6 +134,13 @@ func (h *CreditCardHandler) GetAll(c *gin.Context) {
 		return
 	}
 
+	for i := range cards {
+		if err := h.applyLimitUsage(&cards[i]); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get credit cards"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, cards)
 }
 
@@ -83,12 +157,16 @@ func (h *CreditCardHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	if card.UserID != userID.(uuid.UUID) {
+	if !canView(c, card.UserID, card.HouseholdID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
 
+	if err := h.applyLimitUsage(card); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get credit card"})
+		return
+	}
+
 	c.JSON(http.StatusOK, card)
 }
 
@@ -105,8 +183,7 @@ func (h *CreditCardHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	if card.UserID != userID.(uuid.UUID) {
+	if !canManage(c, card.UserID, card.HouseholdID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -126,15 +203,14 @@ func (h *CreditCardHandler) Update(c *gin.Context) {
 		return
 	}
 
-	// Check if card exists and belongs to user
+	// Check if card exists and the user can edit it
 	card, err := h.cardRepo.GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Credit card not found"})
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	if card.UserID != userID.(uuid.UUID) {
+	if !canEdit(c, card.UserID, card.HouseholdID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -151,6 +227,7 @@ func (h *CreditCardHandler) Update(c *gin.Context) {
 	card.CreditLimit = req.CreditLimit
 	card.BillingDate = req.BillingDate
 	card.PaymentDueDate = req.PaymentDueDate
+	req.CreditCardSettings.applyTo(card)
 
 	if err := h.cardRepo.Update(card); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update credit card"})
@@ -159,3 +236,677 @@ func (h *CreditCardHandler) Update(c *gin.Context) {
 
 	c.JSON(http.StatusOK, card)
 }
+
+// Statements
+
+// GetStatements lists the latest closed statements plus the current (unbilled) cycle.
+// Query: count (default 6, max 24)
+func (h *CreditCardHandler) GetStatements(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", "6"))
+	if err != nil || count < 1 || count > 24 {
+		count = 6
+	}
+
+	current := billing.CycleFor(card.BillingDate, card.PaymentDueDate, time.Now())
+	currentStmt, err := h.cardRepo.GetStatement(card, current, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get statements"})
+		return
+	}
+
+	statements := []models.CreditCardStatement{}
+	cycle := current.Previous()
+	for i := 0; i < count; i++ {
+		stmt, err := h.cardRepo.GetStatement(card, cycle, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get statements"})
+			return
+		}
+		statements = append(statements, *stmt)
+		cycle = cycle.Previous()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"credit_card_id":   card.ID,
+		"current_balance":  card.CurrentBalance,
+		"credit_limit":     card.CreditLimit,
+		"unbilled_charges": currentStmt.Charges,
+		"unbilled_credits": currentStmt.Credits,
+		"current_cycle":    currentStmt,
+		"statements":       statements,
+	})
+}
+
+// GetStatement returns the statement whose cycle contains :date (YYYY-MM-DD or "current"), with its transactions
+func (h *CreditCardHandler) GetStatement(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	date := time.Now()
+	if s := c.Param("date"); s != "current" {
+		parsed, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD or current"})
+			return
+		}
+		date = parsed
+	}
+
+	cycle := billing.CycleFor(card.BillingDate, card.PaymentDueDate, date)
+	stmt, err := h.cardRepo.GetStatement(card, cycle, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get statement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stmt)
+}
+
+// CreatePayment pays the card from one of the user's accounts.
+// The account is debited and the card credited as a linked pair of transactions.
+func (h *CreditCardHandler) CreatePayment(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateCardPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountID, err := uuid.Parse(req.AccountID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+	account, err := h.accountRepo.GetByID(accountID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	if !canEdit(c, account.UserID, account.HouseholdID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+	if account.Type == models.AccountTypePaylater {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A credit card cannot be paid from a paylater account"})
+		return
+	}
+
+	paymentDate := time.Now()
+	if req.PaymentDate != "" {
+		paymentDate, err = time.Parse("2006-01-02", req.PaymentDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment_date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	// Full and minimum amounts refer to the last closed statement as of the payment date
+	var amount float64
+	switch req.PaymentType {
+	case models.CardPaymentTypeCustom:
+		if req.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount is required for a custom payment"})
+			return
+		}
+		amount = req.Amount
+	default:
+		last := billing.CycleFor(card.BillingDate, card.PaymentDueDate, paymentDate).Previous()
+		stmt, err := h.cardRepo.GetStatement(card, last, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get statement"})
+			return
+		}
+		amount = stmt.Outstanding
+		if req.PaymentType == models.CardPaymentTypeMinimum {
+			amount = stmt.MinimumPayment - stmt.PaidAfterClose
+			if amount > stmt.Outstanding {
+				amount = stmt.Outstanding
+			}
+		}
+		if amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing is due on the last statement"})
+			return
+		}
+	}
+
+	description := req.Description
+	if description == "" {
+		description = "Payment for " + card.CardName + " (" + card.LastFourDigits + ")"
+	}
+
+	// Both halves are recorded as the paying user's, who may be a household member
+	userID, _ := c.Get("user_id")
+	debit := &models.Transaction{
+		UserID:          userID.(uuid.UUID),
+		AccountID:       &account.ID,
+		Type:            models.TransactionTypeExpense,
+		Category:        models.CategoryCreditCardPayment,
+		Amount:          amount,
+		Description:     description,
+		TransactionDate: paymentDate,
+	}
+	credit := &models.Transaction{
+		UserID:          userID.(uuid.UUID),
+		CreditCardID:    &card.ID,
+		Type:            models.TransactionTypeIncome,
+		Category:        models.CategoryCreditCardPayment,
+		Amount:          amount,
+		Description:     description,
+		TransactionDate: paymentDate,
+	}
+
+	if err := h.transactionRepo.CreateLinkedPair(debit, credit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CardPayment{CardTransaction: *credit, AccountTransaction: *debit})
+}
+
+// GetPayments lists payments made to the card from accounts
+func (h *CreditCardHandler) GetPayments(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	payments, err := h.transactionRepo.GetLinkedCardPayments(card.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+// DeletePayment reverses a card payment: both transactions are removed and both balances restored.
+// :transactionId may be either half of the pair.
+func (h *CreditCardHandler) DeletePayment(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("transactionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	transaction, err := h.transactionRepo.GetByID(id)
+	if err != nil || transaction.LinkedTransactionID == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+	partner, err := h.transactionRepo.GetByID(*transaction.LinkedTransactionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+	// Make sure the pair actually belongs to this card
+	cardLeg, accountLeg := transaction, partner
+	if transaction.CreditCardID == nil {
+		cardLeg, accountLeg = partner, transaction
+	}
+	if cardLeg.CreditCardID == nil || *cardLeg.CreditCardID != card.ID || accountLeg.AccountID == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+
+	// Reversing the payment changes the paying account's balance as well
+	account, err := h.accountRepo.GetByID(*accountLeg.AccountID)
+	if err != nil || !canEdit(c, account.UserID, account.HouseholdID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	if err := h.transactionRepo.DeleteLinkedPair(transaction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete payment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payment reversed successfully"})
+}
+
+// CreateInstallment records a card purchase as an installment plan and posts
+// the installments whose statement date has already passed
+func (h *CreditCardHandler) CreateInstallment(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateInstallmentPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	purchaseDate := time.Now()
+	if req.PurchaseDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.PurchaseDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase_date format. Use YYYY-MM-DD"})
+			return
+		}
+		purchaseDate = parsed
+	}
+
+	plan := &models.InstallmentPlan{
+		UserID:       card.UserID,
+		CreditCardID: &card.ID,
+		Description:  req.Description,
+		Category:     req.Category,
+		Subcategory:  req.Subcategory,
+		Principal:    req.Principal,
+		TenorMonths:  req.TenorMonths,
+		InterestRate: req.InterestRate,
+		AdminFee:     req.AdminFee,
+		PurchaseDate: billing.Day(purchaseDate),
+	}
+
+	if err := h.installmentRepo.Create(plan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create installment plan"})
+		return
+	}
+
+	plan, err := h.installmentRepo.GetByID(card.InstallmentTarget(), plan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get installment plan"})
+		return
+	}
+	if _, err := h.installmentRepo.PostDue(plan, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post installments"})
+		return
+	}
+
+	plan, err = h.installmentRepo.GetByID(card.InstallmentTarget(), plan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get installment plan"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+func (h *CreditCardHandler) GetInstallments(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	plans, err := h.installmentRepo.GetByTarget(card.InstallmentTarget())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get installment plans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+func (h *CreditCardHandler) GetInstallment(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	plan, ok := h.loadInstallment(c, card)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// DeleteInstallment removes a plan together with the installments already posted
+func (h *CreditCardHandler) DeleteInstallment(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	plan, ok := h.loadInstallment(c, card)
+	if !ok {
+		return
+	}
+
+	if err := h.installmentRepo.Delete(plan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete installment plan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Installment plan deleted successfully"})
+}
+
+func (h *CreditCardHandler) loadInstallment(c *gin.Context, card *models.CreditCard) (*models.InstallmentPlan, bool) {
+	id, err := uuid.Parse(c.Param("planId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid installment plan ID"})
+		return nil, false
+	}
+
+	plan, err := h.installmentRepo.GetByID(card.InstallmentTarget(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Installment plan not found"})
+		return nil, false
+	}
+
+	return plan, true
+}
+
+// applyLimitUsage fills the card's limit usage: the billed balance plus installment
+// principal that will be billed in later statements
+func (h *CreditCardHandler) applyLimitUsage(card *models.CreditCard) error {
+	outstanding, err := h.installmentRepo.GetOutstandingPrincipal(card.InstallmentTarget())
+	if err != nil {
+		return err
+	}
+	card.InstallmentOutstanding = outstanding
+	card.AvailableLimit = card.CreditLimit - card.CurrentBalance - outstanding
+	return nil
+}
+
+// GetProjection answers "what if I pay X": the charges the last statement would incur
+// if X is paid by its due date, and how long paying X every month clears the balance.
+// Without ?payment the statement's minimum payment is used.
+func (h *CreditCardHandler) GetProjection(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	current := billing.CycleFor(card.BillingDate, card.PaymentDueDate, now)
+	last, err := h.cardRepo.GetStatement(card, current.Previous(), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get statement"})
+		return
+	}
+	unbilled, err := h.cardRepo.GetStatement(card, current, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get statement"})
+		return
+	}
+
+	payment := last.MinimumPayment
+	if s := c.Query("payment"); s != "" {
+		payment, err = strconv.ParseFloat(s, 64)
+		if err != nil || payment < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment amount"})
+			return
+		}
+	}
+
+	terms := card.Terms()
+	// Payments already made since the statement count towards what's paid by the due date
+	paidByDue := last.PaidAfterClose + payment
+	charges := terms.ChargesFor(last.StatementBalance, paidByDue)
+	remaining := last.StatementBalance - paidByDue
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"credit_card_id":          card.ID,
+		"payment":                 payment,
+		"statement":               last,
+		"remaining":               remaining,
+		"charges":                 charges,
+		"paid_in_full":            remaining == 0,
+		"meets_minimum":           paidByDue >= last.MinimumPayment,
+		"next_statement_estimate": remaining + charges.Total() + unbilled.Charges - unbilled.Credits,
+		"payoff":                  terms.ProjectPayoff(card.CurrentBalance, payment, 360),
+	})
+}
+
+// GetRewards returns the card's reward rules, balances per reward type and the earnings of each purchase
+func (h *CreditCardHandler) GetRewards(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.rewardRepo.GetRules(card.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reward rules"})
+		return
+	}
+	earnings, err := h.rewardRepo.GetEarnings(card.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rewards"})
+		return
+	}
+	balances, err := h.rewardRepo.GetBalances(card.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rewards"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"credit_card_id": card.ID,
+		"rules":          rules,
+		"balances":       balances,
+		"earnings":       earnings,
+	})
+}
+
+// UpsertRewardRule sets the card's reward for a category; an empty category sets the base rate
+func (h *CreditCardHandler) UpsertRewardRule(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpsertRewardRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := &models.RewardRule{
+		UserID:       card.UserID,
+		CreditCardID: card.ID,
+		Category:     strings.TrimSpace(req.Category),
+		RewardType:   req.RewardType,
+		Rate:         req.Rate,
+		PerAmount:    1,
+		PointValue:   req.PointValue,
+	}
+	if req.PerAmount > 0 {
+		rule.PerAmount = req.PerAmount
+	}
+
+	if err := h.rewardRepo.UpsertRule(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reward rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *CreditCardHandler) DeleteRewardRule(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("ruleId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reward rule ID"})
+		return
+	}
+
+	found, err := h.rewardRepo.DeleteRule(id, card.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reward rule"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reward rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reward rule deleted successfully"})
+}
+
+func (h *CreditCardHandler) GetRedemptions(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	redemptions, err := h.rewardRepo.GetRedemptions(card.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get redemptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, redemptions)
+}
+
+// CreateRedemption records cashback or points taken out of the card's rewards balance
+func (h *CreditCardHandler) CreateRedemption(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateRewardRedemptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	redeemedAt := time.Now()
+	if req.RedeemedAt != "" {
+		parsed, err := time.Parse("2006-01-02", req.RedeemedAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid redeemed_at format. Use YYYY-MM-DD"})
+			return
+		}
+		redeemedAt = parsed
+	}
+
+	balances, err := h.rewardRepo.GetBalances(card.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rewards"})
+		return
+	}
+
+	var available float64
+	for _, b := range balances {
+		if b.RewardType == req.RewardType {
+			available = b.Balance
+		}
+	}
+	if req.Amount > available {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Redemption exceeds the rewards balance", "balance": available})
+		return
+	}
+
+	redemption := &models.RewardRedemption{
+		UserID:       card.UserID,
+		CreditCardID: card.ID,
+		RewardType:   req.RewardType,
+		Amount:       req.Amount,
+		Description:  req.Description,
+		RedeemedAt:   billing.Day(redeemedAt),
+	}
+	if err := h.rewardRepo.CreateRedemption(redemption); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create redemption"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, redemption)
+}
+
+func (h *CreditCardHandler) DeleteRedemption(c *gin.Context) {
+	card, ok := h.loadCard(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("redemptionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid redemption ID"})
+		return
+	}
+
+	found, err := h.rewardRepo.DeleteRedemption(id, card.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete redemption"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Redemption not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Redemption deleted successfully"})
+}
+
+// Share moves the card into a household, or back to personal when household_id is null
+func (h *CreditCardHandler) Share(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credit card ID"})
+		return
+	}
+
+	card, err := h.cardRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credit card not found"})
+		return
+	}
+
+	var req models.ShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !checkShare(c, card.UserID, card.HouseholdID, req) {
+		return
+	}
+
+	if err := h.cardRepo.SetHousehold(card, req.HouseholdID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share credit card"})
+		return
+	}
+
+	c.JSON(http.StatusOK, card)
+}
+
+// loadCard fetches the card in :id and checks the current user can view it, or edit it
+// for anything but a read. It writes the error response itself and returns ok=false on failure.
+func (h *CreditCardHandler) loadCard(c *gin.Context) (*models.CreditCard, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credit card ID"})
+		return nil, false
+	}
+
+	card, err := h.cardRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credit card not found"})
+		return nil, false
+	}
+
+	if !canAccess(c, card.UserID, card.HouseholdID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return card, true
+}
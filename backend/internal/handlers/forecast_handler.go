@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/billing"
+	"github.com/financial-tracker/backend/internal/forecast"
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// forecastHistoryDays is how much history is used to learn spending patterns
+const forecastHistoryDays = 90
+
+type ForecastHandler struct {
+	transactionRepo *repository.TransactionRepository
+	accountRepo     *repository.AccountRepository
+	creditCardRepo  *repository.CreditCardRepository
+}
+
+func NewForecastHandler(transactionRepo *repository.TransactionRepository, accountRepo *repository.AccountRepository, creditCardRepo *repository.CreditCardRepository) *ForecastHandler {
+	return &ForecastHandler{
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+		creditCardRepo:  creditCardRepo,
+	}
+}
+
+type upcomingCardPayment struct {
+	CreditCardID uuid.UUID  `json:"credit_card_id"`
+	CardName     string     `json:"card_name"`
+	DueDate      string     `json:"due_date"`
+	Amount       float64    `json:"amount"`
+	AccountID    *uuid.UUID `json:"account_id,omitempty"`
+}
+
+// GetForecast projects daily account balances for the next 30, 60 or 90 days.
+// Optional pay_cards_from=<account_id> assigns upcoming credit card dues to that account;
+// otherwise they only affect the total projection.
+func (h *ForecastHandler) GetForecast(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	uid := userID.(uuid.UUID)
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || (days != 30 && days != 60 && days != 90) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be 30, 60 or 90"})
+		return
+	}
+
+	var payFrom *uuid.UUID
+	if s := c.Query("pay_cards_from"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+			return
+		}
+		payFrom = &id
+	}
+
+	accounts, err := h.accountRepo.GetByUserID(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get accounts"})
+		return
+	}
+
+	// Paylater balances are credit, not cash, so they're left out of the projection
+	var balances []forecast.AccountBalance
+	for _, acc := range flattenAccounts(accounts) {
+		if acc.Type == models.AccountTypePaylater {
+			continue
+		}
+		balances = append(balances, forecast.AccountBalance{AccountID: acc.ID, Name: acc.Name, Balance: acc.Balance, OpenedAt: acc.CreatedAt})
+	}
+
+	if payFrom != nil && !containsAccount(balances, *payFrom) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found"})
+		return
+	}
+
+	// Balances already include what posted today, so the projection starts tomorrow
+	today := time.Now()
+	start := time.Date(today.Year(), today.Month(), today.Day()+1, 0, 0, 0, 0, time.UTC)
+	transactions, err := h.transactionRepo.GetAccountTransactionsBetween(uid, start.AddDate(0, 0, -forecastHistoryDays), start)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get transactions"})
+		return
+	}
+
+	history := make([]forecast.Flow, 0, len(transactions))
+	for _, t := range transactions {
+		amount := t.Amount
+		if t.Type == models.TransactionTypeExpense {
+			amount = -amount
+		}
+		history = append(history, forecast.Flow{
+			AccountID:   *t.AccountID,
+			Date:        t.TransactionDate,
+			Amount:      amount,
+			Category:    t.Category,
+			Description: t.Description,
+		})
+	}
+
+	cards, err := h.creditCardRepo.GetByUserID(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get credit cards"})
+		return
+	}
+
+	// Each card owes the outstanding part of its last statement on that statement's due date,
+	// and the rest of its balance on the due date of the current cycle
+	var scheduled []forecast.ScheduledFlow
+	cardPayments := []upcomingCardPayment{}
+	end := start.AddDate(0, 0, days-1)
+	for i := range cards {
+		card := &cards[i]
+		current := billing.CycleFor(card.BillingDate, card.PaymentDueDate, start)
+		last, err := h.creditCardRepo.GetStatement(card, current.Previous(), false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get credit card statements"})
+			return
+		}
+
+		dues := []struct {
+			date   time.Time
+			amount float64
+		}{
+			{last.DueDate, last.Outstanding},
+			{current.Due, card.CurrentBalance - last.Outstanding},
+		}
+		for _, due := range dues {
+			if due.amount <= 0 || due.date.Before(start) || due.date.After(end) {
+				continue
+			}
+			scheduled = append(scheduled, forecast.ScheduledFlow{AccountID: payFrom, Date: due.date, Amount: -due.amount, Label: card.CardName})
+			cardPayments = append(cardPayments, upcomingCardPayment{
+				CreditCardID: card.ID,
+				CardName:     card.CardName,
+				DueDate:      due.date.Format("2006-01-02"),
+				Amount:       due.amount,
+				AccountID:    payFrom,
+			})
+		}
+	}
+
+	result := forecast.Project(forecast.Input{
+		Start:       start,
+		Days:        days,
+		Accounts:    balances,
+		History:     history,
+		HistoryDays: forecastHistoryDays,
+		Scheduled:   scheduled,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"forecast":      result,
+		"card_payments": cardPayments,
+	})
+}
+
+// flattenAccounts returns main accounts followed by their pockets
+func flattenAccounts(accounts []models.Account) []models.Account {
+	var flat []models.Account
+	for _, acc := range accounts {
+		flat = append(flat, acc)
+		flat = append(flat, acc.SubAccounts...)
+	}
+	return flat
+}
+
+func containsAccount(balances []forecast.AccountBalance, id uuid.UUID) bool {
+	for _, b := range balances {
+		if b.AccountID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type HouseholdHandler struct {
+	householdRepo *repository.HouseholdRepository
+	userRepo      *repository.UserRepository
+}
+
+func NewHouseholdHandler(householdRepo *repository.HouseholdRepository, userRepo *repository.UserRepository) *HouseholdHandler {
+	return &HouseholdHandler{
+		householdRepo: householdRepo,
+		userRepo:      userRepo,
+	}
+}
+
+func (h *HouseholdHandler) Create(c *gin.Context) {
+	var req models.CreateHouseholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	household := &models.Household{
+		Name:      req.Name,
+		CreatedBy: userID.(uuid.UUID),
+	}
+	if err := h.householdRepo.Create(household); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create household"})
+		return
+	}
+
+	members, err := h.householdRepo.GetMembers(household.ID)
+	if err == nil {
+		household.Members = members
+	}
+
+	c.JSON(http.StatusCreated, household)
+}
+
+// GetAll returns the households the user belongs to with their role in each
+func (h *HouseholdHandler) GetAll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	households, err := h.householdRepo.GetByUserID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get households"})
+		return
+	}
+
+	c.JSON(http.StatusOK, households)
+}
+
+// GetByID returns a household with its members and open invitations
+func (h *HouseholdHandler) GetByID(c *gin.Context) {
+	household, ok := h.loadHousehold(c, false)
+	if !ok {
+		return
+	}
+
+	members, err := h.householdRepo.GetMembers(household.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get household members"})
+		return
+	}
+	household.Members = members
+
+	invitations, err := h.householdRepo.GetPendingInvitations(household.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invitations"})
+		return
+	}
+	household.Invitations = invitations
+
+	c.JSON(http.StatusOK, household)
+}
+
+func (h *HouseholdHandler) Update(c *gin.Context) {
+	household, ok := h.loadHousehold(c, true)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateHouseholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	household.Name = req.Name
+	if err := h.householdRepo.Update(household); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update household"})
+		return
+	}
+
+	c.JSON(http.StatusOK, household)
+}
+
+// Delete removes the household; everything shared into it goes back to its owners
+func (h *HouseholdHandler) Delete(c *gin.Context) {
+	household, ok := h.loadHousehold(c, true)
+	if !ok {
+		return
+	}
+
+	if err := h.householdRepo.Delete(household.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete household"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Household deleted successfully"})
+}
+
+// UpdateMember changes a member's role. A household always keeps at least one owner.
+func (h *HouseholdHandler) UpdateMember(c *gin.Context) {
+	household, ok := h.loadHousehold(c, true)
+	if !ok {
+		return
+	}
+
+	member, ok := h.loadMember(c, household.ID)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateHouseholdMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if member.Role == models.HouseholdRoleOwner && req.Role != models.HouseholdRoleOwner {
+		if !h.hasOtherOwner(c, household.ID) {
+			return
+		}
+	}
+
+	if err := h.householdRepo.UpdateMemberRole(household.ID, member.UserID, req.Role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+	member.Role = req.Role
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember takes a member out of the household. Owners can remove anyone and
+// members can remove themselves; whatever the member shared becomes personal again.
+func (h *HouseholdHandler) RemoveMember(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	memberID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	household, ok := h.loadHousehold(c, memberID != userID.(uuid.UUID))
+	if !ok {
+		return
+	}
+
+	member, ok := h.loadMember(c, household.ID)
+	if !ok {
+		return
+	}
+
+	if member.Role == models.HouseholdRoleOwner && !h.hasOtherOwner(c, household.ID) {
+		return
+	}
+
+	if err := h.householdRepo.RemoveMember(household.ID, member.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// Invitations
+
+// CreateInvitation invites an email address to the household as an editor or viewer
+func (h *HouseholdHandler) CreateInvitation(c *gin.Context) {
+	household, ok := h.loadHousehold(c, true)
+	if !ok {
+		return
+	}
+
+	var req models.CreateHouseholdInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	email := strings.TrimSpace(req.Email)
+
+	if user, err := h.userRepo.GetByEmail(email); err == nil {
+		if _, err := h.householdRepo.GetMember(household.ID, user.ID); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "User is already a member of the household"})
+			return
+		}
+	}
+
+	pending, err := h.householdRepo.HasPendingInvitation(household.ID, email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		return
+	}
+	if pending {
+		c.JSON(http.StatusConflict, gin.H{"error": "This address already has a pending invitation"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	invitation := &models.HouseholdInvitation{
+		HouseholdID:   household.ID,
+		Email:         email,
+		Role:          req.Role,
+		InvitedBy:     userID.(uuid.UUID),
+		HouseholdName: household.Name,
+	}
+	if err := h.householdRepo.CreateInvitation(invitation); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// RevokeInvitation withdraws an invitation that hasn't been answered yet
+func (h *HouseholdHandler) RevokeInvitation(c *gin.Context) {
+	household, ok := h.loadHousehold(c, true)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	invitation, err := h.householdRepo.GetInvitation(id)
+	if err != nil || invitation.HouseholdID != household.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return
+	}
+
+	if err := h.householdRepo.CloseInvitation(invitation, models.HouseholdInvitationRevoked); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Invitation is no longer pending"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked successfully"})
+}
+
+// GetMyInvitations returns the pending invitations addressed to the current user's email
+func (h *HouseholdHandler) GetMyInvitations(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.householdRepo.GetPendingInvitationsByEmail(user.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// AcceptInvitation joins the household with the invited role
+func (h *HouseholdHandler) AcceptInvitation(c *gin.Context) {
+	invitation, user, ok := h.loadMyInvitation(c)
+	if !ok {
+		return
+	}
+
+	if err := h.householdRepo.AcceptInvitation(invitation, user.ID); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Invitation is no longer pending"})
+		return
+	}
+
+	household, err := h.householdRepo.GetByID(invitation.HouseholdID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get household"})
+		return
+	}
+	if member, err := h.householdRepo.GetMember(household.ID, user.ID); err == nil {
+		household.MyRole = member.Role
+	}
+
+	c.JSON(http.StatusOK, household)
+}
+
+func (h *HouseholdHandler) DeclineInvitation(c *gin.Context) {
+	invitation, _, ok := h.loadMyInvitation(c)
+	if !ok {
+		return
+	}
+
+	if err := h.householdRepo.CloseInvitation(invitation, models.HouseholdInvitationDeclined); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Invitation is no longer pending"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation declined successfully"})
+}
+
+// loadHousehold fetches the household in :id and checks the current user is a member,
+// and its owner when ownerOnly is set. It writes the error response itself and returns
+// ok=false on failure.
+func (h *HouseholdHandler) loadHousehold(c *gin.Context, ownerOnly bool) (*models.Household, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid household ID"})
+		return nil, false
+	}
+
+	household, err := h.householdRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Household not found"})
+		return nil, false
+	}
+
+	role, member := householdRole(c, &household.ID)
+	if !member {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+	if ownerOnly && role != models.HouseholdRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only household owners can do this"})
+		return nil, false
+	}
+
+	household.MyRole = role
+	return household, true
+}
+
+// loadMember fetches the member in :userId of the household
+func (h *HouseholdHandler) loadMember(c *gin.Context, householdID uuid.UUID) (*models.HouseholdMember, bool) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return nil, false
+	}
+
+	member, err := h.householdRepo.GetMember(householdID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return nil, false
+	}
+	return member, true
+}
+
+// hasOtherOwner checks an owner can step down or leave without orphaning the household
+func (h *HouseholdHandler) hasOtherOwner(c *gin.Context, householdID uuid.UUID) bool {
+	owners, err := h.householdRepo.CountOwners(householdID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check household owners"})
+		return false
+	}
+	if owners <= 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A household needs at least one owner; make someone else owner or delete the household"})
+		return false
+	}
+	return true
+}
+
+func (h *HouseholdHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	userID, _ := c.Get("user_id")
+	user, err := h.userRepo.GetByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return user, true
+}
+
+// loadMyInvitation fetches the invitation in :invitationId and checks it is pending and
+// addressed to the current user
+func (h *HouseholdHandler) loadMyInvitation(c *gin.Context) (*models.HouseholdInvitation, *models.User, bool) {
+	id, err := uuid.Parse(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return nil, nil, false
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	invitation, err := h.householdRepo.GetInvitation(id)
+	if err != nil || !strings.EqualFold(invitation.Email, user.Email) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return nil, nil, false
+	}
+	if invitation.Status != models.HouseholdInvitationPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Invitation is no longer pending"})
+		return nil, nil, false
+	}
+
+	return invitation, user, true
+}
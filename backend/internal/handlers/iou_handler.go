@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type IOUHandler struct {
+	iouRepo         *repository.IOURepository
+	contactRepo     *repository.ContactRepository
+	accountRepo     *repository.AccountRepository
+	transactionRepo *repository.TransactionRepository
+}
+
+func NewIOUHandler(iouRepo *repository.IOURepository, contactRepo *repository.ContactRepository, accountRepo *repository.AccountRepository, transactionRepo *repository.TransactionRepository) *IOUHandler {
+	return &IOUHandler{
+		iouRepo:         iouRepo,
+		contactRepo:     contactRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+func (h *IOUHandler) Create(c *gin.Context) {
+	var req models.CreateIOURequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	contactID, err := uuid.Parse(req.ContactID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+	contact, err := h.contactRepo.GetByID(contactID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+		return
+	}
+	if contact.UserID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	iouDate := time.Now()
+	if req.IOUDate != "" {
+		iouDate, err = time.Parse("2006-01-02", req.IOUDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid iou_date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	var dueDate *time.Time
+	if req.DueDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_date format. Use YYYY-MM-DD"})
+			return
+		}
+		dueDate = &parsed
+	}
+
+	iou := &models.IOU{
+		UserID:      userID.(uuid.UUID),
+		ContactID:   contact.ID,
+		ContactName: contact.Name,
+		Direction:   req.Direction,
+		Amount:      req.Amount,
+		Description: req.Description,
+		IOUDate:     iouDate,
+		DueDate:     dueDate,
+	}
+
+	// An IOU can point at the transaction it came from, e.g. a bill the user paid for everyone
+	if req.TransactionID != "" {
+		transactionID, err := uuid.Parse(req.TransactionID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+			return
+		}
+		transaction, err := h.transactionRepo.GetByID(transactionID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+			return
+		}
+		if transaction.UserID != iou.UserID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		iou.TransactionID = &transaction.ID
+		if iou.Description == "" {
+			iou.Description = transaction.Description
+		}
+	}
+
+	if err := h.iouRepo.Create(iou); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create IOU"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, iou)
+}
+
+// GetAll lists the user's IOUs, optionally for one contact (?contact_id=) or status (?status=open|settled)
+func (h *IOUHandler) GetAll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var filter repository.IOUFilter
+	if s := c.Query("contact_id"); s != "" {
+		contactID, err := uuid.Parse(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+			return
+		}
+		filter.ContactID = &contactID
+	}
+	switch status := models.IOUStatus(c.Query("status")); status {
+	case "", models.IOUStatusOpen, models.IOUStatusSettled:
+		filter.Status = status
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open or settled"})
+		return
+	}
+
+	ious, err := h.iouRepo.GetByUserID(userID.(uuid.UUID), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get IOUs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ious)
+}
+
+// GetByID returns an IOU with its settlements
+func (h *IOUHandler) GetByID(c *gin.Context) {
+	iou, ok := h.loadIOU(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, iou)
+}
+
+func (h *IOUHandler) Update(c *gin.Context) {
+	iou, ok := h.loadIOU(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateIOURequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Amount > 0 {
+		if req.Amount < iou.Settled {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount cannot be less than what has already been settled", "settled": iou.Settled})
+			return
+		}
+		iou.Amount = req.Amount
+	}
+	if req.Description != nil {
+		iou.Description = *req.Description
+	}
+	if req.DueDate != nil {
+		iou.DueDate = nil
+		if *req.DueDate != "" {
+			parsed, err := time.Parse("2006-01-02", *req.DueDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_date format. Use YYYY-MM-DD"})
+				return
+			}
+			iou.DueDate = &parsed
+		}
+	}
+
+	if err := h.iouRepo.Update(iou); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IOU"})
+		return
+	}
+
+	updated, err := h.iouRepo.GetByID(iou.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get IOU"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *IOUHandler) Delete(c *gin.Context) {
+	iou, ok := h.loadIOU(c)
+	if !ok {
+		return
+	}
+
+	if err := h.iouRepo.Delete(iou.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete IOU"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "IOU deleted successfully"})
+}
+
+// CreateSettlement records a full or partial repayment. With an account_id the money
+// is also posted to that account: income when the contact pays the user back,
+// an expense when the user pays the contact.
+func (h *IOUHandler) CreateSettlement(c *gin.Context) {
+	iou, ok := h.loadIOU(c)
+	if !ok {
+		return
+	}
+	if iou.Outstanding <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "IOU is already settled"})
+		return
+	}
+
+	var req models.CreateIOUSettlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = iou.Outstanding
+	}
+	if math.Round((amount-iou.Outstanding)*100) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Settlement is more than the outstanding amount", "outstanding": iou.Outstanding})
+		return
+	}
+
+	settlementDate := time.Now()
+	if req.SettlementDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.SettlementDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid settlement_date format. Use YYYY-MM-DD"})
+			return
+		}
+		settlementDate = parsed
+	}
+
+	var transaction *models.Transaction
+	if req.AccountID != "" {
+		accountID, err := uuid.Parse(req.AccountID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+			return
+		}
+		account, err := h.accountRepo.GetByID(accountID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+			return
+		}
+		if !canEdit(c, account.UserID, account.HouseholdID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+
+		transaction = &models.Transaction{
+			UserID:          iou.UserID,
+			AccountID:       &account.ID,
+			Type:            models.TransactionTypeIncome,
+			Category:        models.CategoryIOUSettlement,
+			Amount:          amount,
+			Description:     "Repayment from " + iou.ContactName,
+			TransactionDate: settlementDate,
+		}
+		if iou.Direction == models.IOUDirectionIOwe {
+			transaction.Type = models.TransactionTypeExpense
+			transaction.Description = "Repayment to " + iou.ContactName
+		}
+		if iou.Description != "" {
+			transaction.Description += " - " + iou.Description
+		}
+	}
+
+	settlement := &models.IOUSettlement{
+		Amount:         amount,
+		SettlementDate: settlementDate,
+		Note:           req.Note,
+	}
+	if err := h.iouRepo.CreateSettlement(iou, settlement, transaction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create settlement"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, settlement)
+}
+
+// DeleteSettlement removes a settlement and reverses the transaction it posted
+func (h *IOUHandler) DeleteSettlement(c *gin.Context) {
+	iou, ok := h.loadIOU(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("settlementId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid settlement ID"})
+		return
+	}
+	var settlement *models.IOUSettlement
+	for i := range iou.Settlements {
+		if iou.Settlements[i].ID == id {
+			settlement = &iou.Settlements[i]
+		}
+	}
+	if settlement == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Settlement not found"})
+		return
+	}
+
+	if err := h.iouRepo.DeleteSettlement(settlement); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete settlement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Settlement deleted successfully"})
+}
+
+// loadIOU fetches the IOU in :id with its settlements and checks it belongs to the current user.
+// It writes the error response itself and returns ok=false on failure.
+func (h *IOUHandler) loadIOU(c *gin.Context) (*models.IOU, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IOU ID"})
+		return nil, false
+	}
+
+	iou, err := h.iouRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "IOU not found"})
+		return nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+	if iou.UserID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return iou, true
+}
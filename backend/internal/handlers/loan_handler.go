@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/billing"
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxPayoffMonths bounds payoff plans to the longest loan tenor
+const maxPayoffMonths = 360
+
+type LoanHandler struct {
+	loanRepo    *repository.LoanRepository
+	accountRepo *repository.AccountRepository
+	cardRepo    *repository.CreditCardRepository
+}
+
+func NewLoanHandler(loanRepo *repository.LoanRepository, accountRepo *repository.AccountRepository, cardRepo *repository.CreditCardRepository) *LoanHandler {
+	return &LoanHandler{
+		loanRepo:    loanRepo,
+		accountRepo: accountRepo,
+		cardRepo:    cardRepo,
+	}
+}
+
+func (h *LoanHandler) Create(c *gin.Context) {
+	var req models.CreateLoanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startDate := time.Now()
+	if req.StartDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+			return
+		}
+		startDate = parsed
+	}
+
+	userID, _ := c.Get("user_id")
+	loan := &models.Loan{
+		UserID:       userID.(uuid.UUID),
+		Name:         req.Name,
+		Kind:         req.Kind,
+		Direction:    req.Direction,
+		Counterparty: req.Counterparty,
+		Principal:    req.Principal,
+		InterestRate: req.InterestRate,
+		Method:       req.Method,
+		TenorMonths:  req.TenorMonths,
+		StartDate:    billing.Day(startDate),
+		Notes:        req.Notes,
+	}
+	if loan.Kind == "" {
+		loan.Kind = models.LoanKindOther
+	}
+	if loan.Method == "" {
+		loan.Method = billing.AmortizationAnnuity
+	}
+
+	if err := h.loanRepo.Create(loan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create loan"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, loan)
+}
+
+func (h *LoanHandler) GetAll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	loans, err := h.loanRepo.GetByUserID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get loans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, loans)
+}
+
+// GetByID returns a loan with its amortization schedule
+func (h *LoanHandler) GetByID(c *gin.Context) {
+	loan, ok := h.loadLoan(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+func (h *LoanHandler) Update(c *gin.Context) {
+	loan, ok := h.loadLoan(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateLoanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != "" {
+		loan.Name = req.Name
+	}
+	if req.Kind != "" {
+		loan.Kind = req.Kind
+	}
+	if req.Counterparty != nil {
+		loan.Counterparty = *req.Counterparty
+	}
+	if req.Notes != nil {
+		loan.Notes = *req.Notes
+	}
+
+	if err := h.loanRepo.Update(loan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update loan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+func (h *LoanHandler) Delete(c *gin.Context) {
+	loan, ok := h.loadLoan(c)
+	if !ok {
+		return
+	}
+
+	if err := h.loanRepo.Delete(loan.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete loan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Loan deleted successfully"})
+}
+
+// CreatePayment records a payment on the loan from an account, or a repayment received
+// into an account for money lent. Interest due for the period is paid first.
+func (h *LoanHandler) CreatePayment(c *gin.Context) {
+	loan, ok := h.loadLoan(c)
+	if !ok {
+		return
+	}
+	if loan.Outstanding <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Loan is already paid off"})
+		return
+	}
+
+	var req models.CreateLoanPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountID, err := uuid.Parse(req.AccountID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+	account, err := h.accountRepo.GetByID(accountID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	if !canEdit(c, account.UserID, account.HouseholdID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	paymentDate := time.Now()
+	if req.PaymentDate != "" {
+		paymentDate, err = time.Parse("2006-01-02", req.PaymentDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment_date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	paymentDate = billing.Day(paymentDate)
+
+	start, end := loan.PeriodFor(paymentDate)
+	interestPaid, err := h.loanRepo.GetInterestPaidBetween(loan.ID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get loan payments"})
+		return
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		// The scheduled installment, or whatever is left when that's less
+		amount = loan.MonthlyPayment
+		if owed := loan.Outstanding + math.Max(loan.PeriodInterest()-interestPaid, 0); amount > owed {
+			amount = math.Round(owed*100) / 100
+		}
+	}
+	principal, interest := loan.Allocate(amount, interestPaid)
+	if principal > loan.Outstanding {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":       "Payment is more than what is owed on the loan",
+			"outstanding": loan.Outstanding,
+			"interest":    interest,
+		})
+		return
+	}
+
+	t := &models.Transaction{
+		UserID:          loan.UserID,
+		AccountID:       &account.ID,
+		Type:            models.TransactionTypeExpense,
+		Category:        models.CategoryLoanPayment,
+		Amount:          amount,
+		Description:     req.Description,
+		TransactionDate: paymentDate,
+	}
+	if loan.Direction == models.LoanDirectionLent {
+		t.Type = models.TransactionTypeIncome
+		t.Category = models.CategoryLoanReceived
+	}
+	if t.Description == "" {
+		t.Description = t.Category + " - " + loan.Name
+	}
+
+	payment := &models.LoanPayment{
+		Amount:      amount,
+		Principal:   principal,
+		Interest:    interest,
+		PaymentDate: paymentDate,
+	}
+	if err := h.loanRepo.CreatePayment(loan, t, payment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create loan payment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+func (h *LoanHandler) GetPayments(c *gin.Context) {
+	loan, ok := h.loadLoan(c)
+	if !ok {
+		return
+	}
+
+	payments, err := h.loanRepo.GetPayments(loan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get loan payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+// DeletePayment removes a payment and its account transaction
+func (h *LoanHandler) DeletePayment(c *gin.Context) {
+	loan, ok := h.loadLoan(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("paymentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+	payment, err := h.loanRepo.GetPaymentByID(loan.ID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Loan payment not found"})
+		return
+	}
+
+	if err := h.loanRepo.DeletePayment(payment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete loan payment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Loan payment deleted successfully"})
+}
+
+// GetPayoffPlan compares the snowball and avalanche strategies for paying off every
+// borrowed loan and credit card with a monthly budget (defaults to the minimum payments).
+// Flat-rate loans are modeled with their rate on the remaining balance.
+func (h *LoanHandler) GetPayoffPlan(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	loans, err := h.loanRepo.GetByUserID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get loans"})
+		return
+	}
+	cards, err := h.cardRepo.GetByUserID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get credit cards"})
+		return
+	}
+
+	debts := []billing.Debt{}
+	for _, loan := range loans {
+		if loan.Direction != models.LoanDirectionBorrowed || loan.Outstanding <= 0 {
+			continue
+		}
+		debts = append(debts, billing.Debt{
+			ID:             loan.ID.String(),
+			Name:           loan.Name,
+			Kind:           string(loan.Kind),
+			Balance:        loan.Outstanding,
+			AnnualRate:     loan.InterestRate,
+			MinimumPayment: math.Min(loan.MonthlyPayment, loan.Outstanding),
+		})
+	}
+	for _, card := range cards {
+		if card.CurrentBalance <= 0 {
+			continue
+		}
+		debts = append(debts, billing.Debt{
+			ID:             card.ID.String(),
+			Name:           card.CardName,
+			Kind:           "credit_card",
+			Balance:        card.CurrentBalance,
+			AnnualRate:     card.InterestRate * 12,
+			MinimumPayment: card.Terms().MinimumPayment(card.CurrentBalance),
+		})
+	}
+
+	var minimum float64
+	for _, d := range debts {
+		minimum += d.MinimumPayment
+	}
+	minimum = math.Round(minimum*100) / 100
+
+	budget := minimum
+	if s := c.Query("budget"); s != "" {
+		budget, err = strconv.ParseFloat(s, 64)
+		if err != nil || budget < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid budget amount"})
+			return
+		}
+	}
+	if budget < minimum {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Budget doesn't cover the minimum payments", "minimum_payment": minimum})
+		return
+	}
+
+	plan := models.DebtPayoffPlan{
+		Debts:          debts,
+		MinimumPayment: minimum,
+		Snowball:       billing.PlanPayoff(debts, budget, billing.PayoffSnowball, maxPayoffMonths),
+		Avalanche:      billing.PlanPayoff(debts, budget, billing.PayoffAvalanche, maxPayoffMonths),
+	}
+	plan.InterestSaved = math.Round((plan.Snowball.TotalInterest-plan.Avalanche.TotalInterest)*100) / 100
+	// Avalanche never costs more interest; snowball wins ties for its quicker early payoffs
+	plan.Recommended = string(billing.PayoffSnowball)
+	if plan.InterestSaved > 0 {
+		plan.Recommended = string(billing.PayoffAvalanche)
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// loadLoan fetches the loan in :id and checks it belongs to the current user.
+// It writes the error response itself and returns ok=false on failure.
+func (h *LoanHandler) loadLoan(c *gin.Context) (*models.Loan, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan ID"})
+		return nil, false
+	}
+
+	loan, err := h.loanRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Loan not found"})
+		return nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+	if loan.UserID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return loan, true
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxNetWorthPoints bounds how many dates a history request can reconstruct
+const maxNetWorthPoints = 366
+
+type NetWorthHandler struct {
+	netWorthRepo *repository.NetWorthRepository
+}
+
+func NewNetWorthHandler(netWorthRepo *repository.NetWorthRepository) *NetWorthHandler {
+	return &NetWorthHandler{netWorthRepo: netWorthRepo}
+}
+
+// GetCurrent returns today's net worth
+func (h *NetWorthHandler) GetCurrent(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	nw, err := h.netWorthRepo.Calculate(userID.(uuid.UUID), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate net worth"})
+		return
+	}
+	nw.Source = models.NetWorthSourceLive
+
+	c.JSON(http.StatusOK, nw)
+}
+
+// GetHistory returns net worth over time.
+// Query: from, to (YYYY-MM-DD, default last 90 days), interval=day|week|month (default day).
+// Stored snapshots are used where they exist; other dates are reconstructed from transactions and gold prices.
+func (h *NetWorthHandler) GetHistory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -90)
+
+	var err error
+	if s := c.Query("from"); s != "" {
+		if from, err = time.Parse("2006-01-02", s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if s := c.Query("to"); s != "" {
+		if to, err = time.Parse("2006-01-02", s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	var step func(time.Time) time.Time
+	switch c.DefaultQuery("interval", "day") {
+	case "day":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case "week":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	case "month":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be day, week or month"})
+		return
+	}
+
+	var dates []time.Time
+	for d := from; !d.After(to); d = step(d) {
+		dates = append(dates, d)
+		if len(dates) > maxNetWorthPoints {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Too many points. Use a shorter range or a larger interval"})
+			return
+		}
+	}
+
+	snapshots, err := h.netWorthRepo.GetSnapshots(userID.(uuid.UUID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get net worth history"})
+		return
+	}
+	byDate := make(map[string]models.NetWorth, len(snapshots))
+	for _, s := range snapshots {
+		byDate[s.Date.Format("2006-01-02")] = s
+	}
+
+	var missing []time.Time
+	for _, d := range dates {
+		if _, ok := byDate[d.Format("2006-01-02")]; !ok {
+			missing = append(missing, d)
+		}
+	}
+	if len(missing) > 0 {
+		reconstructed, err := h.netWorthRepo.Reconstruct(userID.(uuid.UUID), missing)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconstruct net worth history"})
+			return
+		}
+		for _, nw := range reconstructed {
+			byDate[nw.Date.Format("2006-01-02")] = nw
+		}
+	}
+
+	history := make([]models.NetWorth, 0, len(dates))
+	for _, d := range dates {
+		history = append(history, byDate[d.Format("2006-01-02")])
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/oidc"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Sign-in with OpenID Connect providers. StartOIDCLogin sends the user to the provider
+// with a PKCE challenge; the frontend page the provider redirects back to passes the
+// code to OIDCCallback, which logs in the user linked to the provider account. Unlinked
+// accounts are linked to the user with the same verified email, or get a new user.
+
+// oidcLoginTTL is how long a user has to finish signing in at the provider
+const oidcLoginTTL = 10 * time.Minute
+
+var usernameUnsafe = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// GetOIDCProviders lists the providers the login page can offer
+func (h *AuthHandler) GetOIDCProviders(c *gin.Context) {
+	providers := []models.OIDCProvider{}
+	for _, p := range h.oidcProviders {
+		providers = append(providers, models.OIDCProvider{Name: p.Name, DisplayName: p.DisplayName})
+	}
+
+	c.JSON(http.StatusOK, providers)
+}
+
+// StartOIDCLogin returns the provider URL to send the user to
+func (h *AuthHandler) StartOIDCLogin(c *gin.Context) {
+	provider := h.oidcProvider(c.Param("provider"))
+	if provider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sign-in provider not found"})
+		return
+	}
+
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return
+	}
+	nonce, err := oidc.NewNonce()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return
+	}
+	state, err := h.identityRepo.CreateLoginState(provider.Name, nonce, verifier, oidcLoginTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, challenge)
+	if err != nil {
+		log.Printf("OIDC %s: %v", provider.Name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Sign-in with " + provider.DisplayName + " is unavailable"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OIDCStartResponse{AuthorizationURL: authURL, State: state})
+}
+
+// OIDCCallback finishes a sign-in with the code the provider redirected back with and
+// answers like Login
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	provider := h.oidcProvider(c.Param("provider"))
+	if provider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sign-in provider not found"})
+		return
+	}
+
+	var req models.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loginState, err := h.identityRepo.ConsumeLoginState(provider.Name, req.State)
+	if errors.Is(err, repository.ErrLoginStateInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired sign-in, please try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sign-in"})
+		return
+	}
+
+	rawIDToken, err := provider.Exchange(c.Request.Context(), req.Code, loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC %s: %v", provider.Name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Sign-in with " + provider.DisplayName + " failed"})
+		return
+	}
+	identity, err := provider.Verify(c.Request.Context(), rawIDToken, loginState.Nonce)
+	if err != nil {
+		log.Printf("OIDC %s: %v", provider.Name, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign-in with " + provider.DisplayName + " failed"})
+		return
+	}
+
+	var user *models.User
+	userID, err := h.identityRepo.RecordLogin(provider.Name, identity.Subject, identity.Email)
+	switch {
+	case err == nil:
+		user, err = h.userRepo.GetByID(userID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		var ok bool
+		if user, ok = h.linkOIDCIdentity(c, provider, identity); !ok {
+			return
+		}
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get linked account"})
+		return
+	}
+
+	h.completeLogin(c, user)
+}
+
+// GetIdentities lists the provider accounts the user can sign in with
+func (h *AuthHandler) GetIdentities(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	identities, err := h.identityRepo.GetByUser(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get linked accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+// linkOIDCIdentity links a provider account seen for the first time to the user with
+// its email, or creates a user for it. It writes the error response itself.
+func (h *AuthHandler) linkOIDCIdentity(c *gin.Context, provider *oidc.Provider, identity *oidc.Identity) (*models.User, bool) {
+	// Only an address the provider checked proves the account belongs to the email's owner
+	if identity.Email == "" || !identity.EmailVerified {
+		c.JSON(http.StatusForbidden, gin.H{"error": provider.DisplayName + " has not verified your email address"})
+		return nil, false
+	}
+
+	user, err := h.userRepo.GetByEmail(identity.Email)
+	if err == nil {
+		// An unverified account may have been registered by someone else who could then
+		// share it with the address's owner, so its owner has to verify it first
+		if user.EmailVerifiedAt == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this email exists; log in with your password and verify your email first"})
+			return nil, false
+		}
+		if err := h.identityRepo.Link(user.ID, provider.Name, identity.Subject, identity.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link account"})
+			return nil, false
+		}
+		return user, true
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error while checking email"})
+		return nil, false
+	}
+
+	username, err := h.availableUsername(identity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error while checking username"})
+		return nil, false
+	}
+	// The user signs in through the provider; a password can be set with a reset email
+	password, err := randomPassword()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return nil, false
+	}
+	fullName := identity.Name
+	if fullName == "" {
+		fullName = username
+	}
+	user = &models.User{
+		Email:        identity.Email,
+		Username:     &username,
+		PasswordHash: password,
+		FullName:     fullName,
+	}
+	if err := h.identityRepo.CreateUser(user, provider.Name, identity.Subject); err != nil {
+		log.Printf("Error creating user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return nil, false
+	}
+	return user, true
+}
+
+// availableUsername picks a username from the provider's preferred username or the
+// email, adding a random suffix while it is taken
+func (h *AuthHandler) availableUsername(identity *oidc.Identity) (string, error) {
+	base := identity.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(identity.Email, "@")
+	}
+	base = usernameUnsafe.ReplaceAllString(strings.ToLower(base), "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	candidate := base
+	for {
+		_, err := h.userRepo.GetByUsername(candidate)
+		if errors.Is(err, sql.ErrNoRows) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		candidate = base + "_" + hex.EncodeToString(suffix)
+	}
+}
+
+func (h *AuthHandler) oidcProvider(name string) *oidc.Provider {
+	for _, p := range h.oidcProviders {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// randomPassword returns the hash of a password nobody knows
+func randomPassword() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(base64.RawURLEncoding.EncodeToString(buf)), bcrypt.DefaultCost)
+	return string(hash), err
+}
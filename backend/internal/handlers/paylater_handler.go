@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/billing"
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PaylaterHandler manages the credit terms and installment purchases of paylater accounts
+type PaylaterHandler struct {
+	accountRepo     *repository.AccountRepository
+	paylaterRepo    *repository.PaylaterRepository
+	installmentRepo *repository.InstallmentRepository
+}
+
+func NewPaylaterHandler(accountRepo *repository.AccountRepository, paylaterRepo *repository.PaylaterRepository, installmentRepo *repository.InstallmentRepository) *PaylaterHandler {
+	return &PaylaterHandler{
+		accountRepo:     accountRepo,
+		paylaterRepo:    paylaterRepo,
+		installmentRepo: installmentRepo,
+	}
+}
+
+// GetProfile returns the account's paylater terms with its debt, limit usage and installment plans
+func (h *PaylaterHandler) GetProfile(c *gin.Context) {
+	account, ok := h.loadAccount(c)
+	if !ok {
+		return
+	}
+	profile, ok := h.loadProfile(c, account)
+	if !ok {
+		return
+	}
+
+	plans, err := h.installmentRepo.GetByTarget(profile.InstallmentTarget())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get installment plans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"profile":      profile,
+		"installments": plans,
+	})
+}
+
+// UpsertProfile sets the credit limit, billing cycle and fees of a paylater account
+func (h *PaylaterHandler) UpsertProfile(c *gin.Context) {
+	account, ok := h.loadAccount(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpsertPaylaterProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := &models.PaylaterProfile{
+		AccountID:    account.ID,
+		Provider:     req.Provider,
+		CreditLimit:  req.CreditLimit,
+		BillingDay:   req.BillingDay,
+		DueDay:       req.DueDay,
+		InterestRate: req.InterestRate,
+		AdminFee:     req.AdminFee,
+	}
+	if err := h.paylaterRepo.Upsert(profile); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save paylater profile"})
+		return
+	}
+	if err := h.applyUsage(account, profile); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get paylater usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// CreatePurchase records a paylater purchase split over a tenor. Installments post
+// on the billing day like card installments; ones already due are posted right away.
+func (h *PaylaterHandler) CreatePurchase(c *gin.Context) {
+	account, ok := h.loadAccount(c)
+	if !ok {
+		return
+	}
+	profile, ok := h.loadProfile(c, account)
+	if !ok {
+		return
+	}
+
+	var req models.CreatePaylaterPurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	purchaseDate := time.Now()
+	if req.PurchaseDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.PurchaseDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase_date format. Use YYYY-MM-DD"})
+			return
+		}
+		purchaseDate = parsed
+	}
+
+	interestRate, adminFee := profile.InterestRate, profile.AdminFee
+	if req.TenorMonths == 1 {
+		interestRate = 0
+	}
+	if req.InterestRate != nil {
+		interestRate = *req.InterestRate
+	}
+	if req.AdminFee != nil {
+		adminFee = *req.AdminFee
+	}
+
+	plan := &models.InstallmentPlan{
+		UserID:       account.UserID,
+		AccountID:    &account.ID,
+		Description:  req.Description,
+		Category:     req.Category,
+		Subcategory:  req.Subcategory,
+		Principal:    req.Amount,
+		TenorMonths:  req.TenorMonths,
+		InterestRate: interestRate,
+		AdminFee:     adminFee,
+		PurchaseDate: billing.Day(purchaseDate),
+	}
+	if err := h.installmentRepo.Create(plan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create paylater purchase"})
+		return
+	}
+
+	target := profile.InstallmentTarget()
+	plan, err := h.installmentRepo.GetByID(target, plan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get installment plan"})
+		return
+	}
+	if _, err := h.installmentRepo.PostDue(plan, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post installments"})
+		return
+	}
+	plan, err = h.installmentRepo.GetByID(target, plan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get installment plan"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// DeletePurchase removes an installment purchase and takes its posted installments off the debt
+func (h *PaylaterHandler) DeletePurchase(c *gin.Context) {
+	account, ok := h.loadAccount(c)
+	if !ok {
+		return
+	}
+	profile, ok := h.loadProfile(c, account)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("planId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid installment plan ID"})
+		return
+	}
+	plan, err := h.installmentRepo.GetByID(profile.InstallmentTarget(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Installment plan not found"})
+		return
+	}
+
+	if err := h.installmentRepo.Delete(plan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete paylater purchase"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Paylater purchase deleted successfully"})
+}
+
+// loadAccount fetches the paylater account in :id and checks the current user can view it,
+// or edit it for anything but a read.
+// It writes the error response itself and returns ok=false on failure.
+func (h *PaylaterHandler) loadAccount(c *gin.Context) (*models.Account, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return nil, false
+	}
+
+	account, err := h.accountRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return nil, false
+	}
+
+	if !canAccess(c, account.UserID, account.HouseholdID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	if account.Type != models.AccountTypePaylater {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account is not a paylater account"})
+		return nil, false
+	}
+
+	return account, true
+}
+
+// loadProfile fetches the account's paylater profile with its usage filled in
+func (h *PaylaterHandler) loadProfile(c *gin.Context, account *models.Account) (*models.PaylaterProfile, bool) {
+	profile, err := h.paylaterRepo.GetByAccountID(account.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Paylater profile not set up for this account"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get paylater profile"})
+		return nil, false
+	}
+
+	if err := h.applyUsage(account, profile); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get paylater usage"})
+		return nil, false
+	}
+	return profile, true
+}
+
+// applyUsage fills the debt, limit usage and current billing cycle of a profile
+func (h *PaylaterHandler) applyUsage(account *models.Account, profile *models.PaylaterProfile) error {
+	outstanding, err := h.installmentRepo.GetOutstandingPrincipal(profile.InstallmentTarget())
+	if err != nil {
+		return err
+	}
+
+	cycle := billing.CycleFor(profile.BillingDay, profile.DueDay, time.Now())
+	profile.Balance = account.Balance
+	profile.InstallmentOutstanding = outstanding
+	profile.AvailableLimit = profile.CreditLimit - account.Balance - outstanding
+	profile.CurrentCycle = &cycle
+	return nil
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReportHandler struct {
+	reportRepo *repository.ReportRepository
+	cardRepo   *repository.CreditCardRepository
+	rewardRepo *repository.RewardRepository
+}
+
+func NewReportHandler(reportRepo *repository.ReportRepository, cardRepo *repository.CreditCardRepository, rewardRepo *repository.RewardRepository) *ReportHandler {
+	return &ReportHandler{reportRepo: reportRepo, cardRepo: cardRepo, rewardRepo: rewardRepo}
+}
+
+// GetMonthly returns income and expense per month. Query: from=YYYY-MM&to=YYYY-MM (default: last 12 months)
+func (h *ReportHandler) GetMonthly(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, -11, 0)
+
+	var err error
+	if s := c.Query("from"); s != "" {
+		if from, err = time.Parse("2006-01", s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from month. Use YYYY-MM"})
+			return
+		}
+	}
+	if s := c.Query("to"); s != "" {
+		if to, err = time.Parse("2006-01", s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to month. Use YYYY-MM"})
+			return
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+	if from.AddDate(5, 0, 0).Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Range cannot exceed 5 years"})
+		return
+	}
+
+	months, err := h.reportRepo.GetMonthlyTotals(userID.(uuid.UUID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get monthly report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, months)
+}
+
+// GetYearly returns the 12 months of a year plus the year's category breakdown. Query: year=YYYY
+func (h *ReportHandler) GetYearly(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	year := time.Now().Year()
+	if s := c.Query("year"); s != "" {
+		parsed, err := strconv.Atoi(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		year = parsed
+	}
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+
+	months, err := h.reportRepo.GetMonthlyTotals(userID.(uuid.UUID), start, end.AddDate(0, -1, 0))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get yearly report"})
+		return
+	}
+
+	period, err := h.reportRepo.GetPeriodReport(userID.(uuid.UUID), start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get yearly report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"year":       year,
+		"income":     period.Income,
+		"expense":    period.Expense,
+		"net":        period.Net,
+		"months":     months,
+		"categories": period.Categories,
+	})
+}
+
+// GetCategories returns expense by category and subcategory.
+// Query: start_date=YYYY-MM-DD&end_date=YYYY-MM-DD (inclusive, default: current month)
+func (h *ReportHandler) GetCategories(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	start, end, ok := parsePeriod(c, "start_date", "end_date")
+	if !ok {
+		return
+	}
+
+	report, err := h.reportRepo.GetPeriodReport(userID.(uuid.UUID), start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get category report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Compare returns a period next to a previous period with deltas and percentages.
+// Query: start_date, end_date, and optionally compare_start_date, compare_end_date
+// (default: the period of equal length right before start_date)
+func (h *ReportHandler) Compare(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	start, end, ok := parsePeriod(c, "start_date", "end_date")
+	if !ok {
+		return
+	}
+
+	prevStart, prevEnd := start.Add(-end.Sub(start)), start
+	if c.Query("compare_start_date") != "" || c.Query("compare_end_date") != "" {
+		if prevStart, prevEnd, ok = parsePeriod(c, "compare_start_date", "compare_end_date"); !ok {
+			return
+		}
+	} else if start.Day() == 1 && end.Day() == 1 {
+		// Whole months compare with the same number of previous calendar months
+		months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+		prevStart = start.AddDate(0, -months, 0)
+	}
+
+	current, err := h.reportRepo.GetPeriodReport(userID.(uuid.UUID), start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get comparison report"})
+		return
+	}
+	previous, err := h.reportRepo.GetPeriodReport(userID.(uuid.UUID), prevStart, prevEnd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get comparison report"})
+		return
+	}
+
+	comparison := models.PeriodComparison{
+		Current:    *current,
+		Previous:   *previous,
+		Income:     reportDelta(current.Income, previous.Income),
+		Expense:    reportDelta(current.Expense, previous.Expense),
+		Net:        reportDelta(current.Net, previous.Net),
+		Categories: []models.CategoryComparison{},
+	}
+
+	totals := make(map[string][2]float64)
+	for _, cat := range current.Categories {
+		t := totals[cat.Category]
+		t[0] = cat.Total
+		totals[cat.Category] = t
+	}
+	for _, cat := range previous.Categories {
+		t := totals[cat.Category]
+		t[1] = cat.Total
+		totals[cat.Category] = t
+	}
+	for category, t := range totals {
+		comparison.Categories = append(comparison.Categories, models.CategoryComparison{
+			Category:    category,
+			ReportDelta: reportDelta(t[0], t[1]),
+		})
+	}
+	sort.Slice(comparison.Categories, func(i, j int) bool {
+		return comparison.Categories[i].Current > comparison.Categories[j].Current ||
+			(comparison.Categories[i].Current == comparison.Categories[j].Current && comparison.Categories[i].Category < comparison.Categories[j].Category)
+	})
+
+	c.JSON(http.StatusOK, comparison)
+}
+
+// GetBestCard shows, for each category of past spending, which card's reward rules
+// would have earned the most on it. Points are compared at their point value.
+// Query: start_date, end_date (inclusive, default: the last 12 months)
+func (h *ReportHandler) GetBestCard(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	start, end, ok := parsePeriod(c, "start_date", "end_date")
+	if !ok {
+		return
+	}
+	if c.Query("start_date") == "" {
+		start = end.AddDate(-1, 0, 0)
+	}
+
+	report, err := h.reportRepo.GetPeriodReport(userID.(uuid.UUID), start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get spending"})
+		return
+	}
+	cards, err := h.cardRepo.GetByUserID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get credit cards"})
+		return
+	}
+	rules, err := h.rewardRepo.GetRulesByUser(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reward rules"})
+		return
+	}
+
+	categories := []models.BestCardCategory{}
+	for _, cat := range report.Categories {
+		// Card interest and fees aren't spending a card can earn on
+		if cat.Category == models.CategoryCreditCardCharges {
+			continue
+		}
+
+		entry := models.BestCardCategory{Category: cat.Category, Spent: cat.Total, Cards: []models.CardRewardValue{}}
+		for _, card := range cards {
+			rule := models.MatchRewardRule(rules[card.ID], cat.Category)
+			if rule == nil {
+				continue
+			}
+			entry.Cards = append(entry.Cards, models.CardRewardValue{
+				CreditCardID: card.ID,
+				CardName:     card.CardName,
+				RewardType:   rule.RewardType,
+				Earned:       rule.Earn(cat.Total),
+				Value:        rule.Value(cat.Total),
+			})
+		}
+		sort.SliceStable(entry.Cards, func(i, j int) bool { return entry.Cards[i].Value > entry.Cards[j].Value })
+		if len(entry.Cards) > 0 && entry.Cards[0].Value > 0 {
+			entry.BestCard = &entry.Cards[0]
+		}
+		categories = append(categories, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date": start.Format("2006-01-02"),
+		"end_date":   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		"categories": categories,
+	})
+}
+
+// parsePeriod reads an inclusive date range from the query and returns it as [start, end).
+// Missing dates default to the current month. It writes the error response itself.
+func parsePeriod(c *gin.Context, startKey, endKey string) (start, end time.Time, ok bool) {
+	now := time.Now()
+	start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end = start.AddDate(0, 1, 0)
+
+	var err error
+	if s := c.Query(startKey); s != "" {
+		if start, err = time.Parse("2006-01-02", s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + startKey + ". Use YYYY-MM-DD"})
+			return start, end, false
+		}
+	}
+	if s := c.Query(endKey); s != "" {
+		inclusiveEnd, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + endKey + ". Use YYYY-MM-DD"})
+			return start, end, false
+		}
+		end = inclusiveEnd.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": endKey + " must not be before " + startKey})
+		return start, end, false
+	}
+
+	return start, end, true
+}
+
+func reportDelta(current, previous float64) models.ReportDelta {
+	delta := models.ReportDelta{Current: current, Previous: previous, Delta: current - previous}
+	if previous != 0 {
+		percent := (current - previous) / previous * 100
+		delta.Percent = &percent
+	}
+	return delta
+}
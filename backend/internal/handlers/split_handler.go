@@ -0,0 +1,675 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/financial-tracker/backend/internal/split"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SplitHandler manages groups of users sharing expenses and the settlements between them
+type SplitHandler struct {
+	splitRepo   *repository.SplitRepository
+	userRepo    *repository.UserRepository
+	accountRepo *repository.AccountRepository
+}
+
+func NewSplitHandler(splitRepo *repository.SplitRepository, userRepo *repository.UserRepository, accountRepo *repository.AccountRepository) *SplitHandler {
+	return &SplitHandler{
+		splitRepo:   splitRepo,
+		userRepo:    userRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+// CreateGroup creates a group with the current user as its first member and invites the
+// other users. Unknown names are skipped so the response doesn't reveal who is registered.
+func (h *SplitHandler) CreateGroup(c *gin.Context) {
+	var req models.CreateSplitGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	inviteeIDs := []uuid.UUID{}
+	for _, name := range req.Members {
+		user, err := h.userRepo.GetByEmailOrUsername(name)
+		if err != nil || user.ID == userID.(uuid.UUID) {
+			continue
+		}
+		inviteeIDs = append(inviteeIDs, user.ID)
+	}
+
+	group := &models.SplitGroup{Name: req.Name, CreatedBy: userID.(uuid.UUID)}
+	if err := h.splitRepo.CreateGroup(group, inviteeIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		return
+	}
+
+	group, err := h.splitRepo.GetGroup(group.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get group"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// GetGroups returns the current user's groups with their balance in each
+func (h *SplitHandler) GetGroups(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	groups, err := h.splitRepo.GetGroupsByUser(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// GetGroup returns a group with its members' balances
+func (h *SplitHandler) GetGroup(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteGroup can only be done by the group's creator
+func (h *SplitHandler) DeleteGroup(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if group.CreatedBy != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the group creator can delete the group"})
+		return
+	}
+
+	if err := h.splitRepo.DeleteGroup(group.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group deleted successfully"})
+}
+
+// InviteMember invites a user to the group; they join once they accept. The response is the
+// same whether or not the user exists or already belongs to the group.
+func (h *SplitHandler) InviteMember(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+
+	var req models.InviteSplitMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if user, err := h.userRepo.GetByEmailOrUsername(req.UsernameOrEmail); err == nil {
+		if err := h.splitRepo.InviteMember(group.ID, user.ID, userID.(uuid.UUID)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite member"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the user exists, they have been invited to the group"})
+}
+
+// GetMyInvitations returns the group invitations waiting for the current user's answer
+func (h *SplitHandler) GetMyInvitations(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	invitations, err := h.splitRepo.GetInvitationsByUser(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// AcceptInvitation joins the group
+func (h *SplitHandler) AcceptInvitation(c *gin.Context) {
+	invitation, ok := h.loadMyInvitation(c)
+	if !ok {
+		return
+	}
+
+	if err := h.splitRepo.AcceptInvitation(invitation); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Invitation is no longer pending"})
+		return
+	}
+
+	group, err := h.splitRepo.GetGroup(invitation.GroupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+func (h *SplitHandler) DeclineInvitation(c *gin.Context) {
+	invitation, ok := h.loadMyInvitation(c)
+	if !ok {
+		return
+	}
+
+	if err := h.splitRepo.DeleteInvitation(invitation.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decline invitation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation declined successfully"})
+}
+
+// RemoveMember lets members leave and the creator remove members, once they are settled up
+func (h *SplitHandler) RemoveMember(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if memberID != userID.(uuid.UUID) && group.CreatedBy != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the group creator can remove other members"})
+		return
+	}
+	if memberID == group.CreatedBy {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The group creator cannot leave; delete the group instead"})
+		return
+	}
+
+	member := findSplitMember(group.Members, memberID)
+	if member == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+	if math.Round(member.Balance*100) != 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Member still has an open balance in the group", "balance": member.Balance})
+		return
+	}
+
+	if err := h.splitRepo.RemoveMember(group.ID, memberID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// CreateExpense records a bill paid by one member and divides it between participants
+// equally, by shares or by exact amounts
+func (h *SplitHandler) CreateExpense(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateSplitExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	payerID := userID.(uuid.UUID)
+	if req.PayerID != "" {
+		parsed, err := uuid.Parse(req.PayerID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payer ID"})
+			return
+		}
+		payerID = parsed
+	}
+	if findSplitMember(group.Members, payerID) == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payer is not a member of the group"})
+		return
+	}
+
+	expenseDate := time.Now()
+	if req.ExpenseDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.ExpenseDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense_date format. Use YYYY-MM-DD"})
+			return
+		}
+		expenseDate = parsed
+	}
+
+	participants := []split.Participant{}
+	if len(req.Participants) == 0 {
+		if req.SplitMode != split.ModeEqual {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "participants are required for shares and exact splits"})
+			return
+		}
+		for _, m := range group.Members {
+			participants = append(participants, split.Participant{UserID: m.UserID})
+		}
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, p := range req.Participants {
+		participantID, err := uuid.Parse(p.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant user ID"})
+			return
+		}
+		if findSplitMember(group.Members, participantID) == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Participant is not a member of the group"})
+			return
+		}
+		if seen[participantID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Participants must be unique"})
+			return
+		}
+		seen[participantID] = true
+		participants = append(participants, split.Participant{UserID: participantID, Shares: p.Shares, Amount: p.Amount})
+	}
+
+	amounts, err := split.Allocate(req.Amount, req.SplitMode, participants)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expense := &models.SplitExpense{
+		GroupID:     group.ID,
+		PayerID:     payerID,
+		CreatedBy:   userID.(uuid.UUID),
+		Description: req.Description,
+		Category:    req.Category,
+		Amount:      req.Amount,
+		SplitMode:   req.SplitMode,
+		ExpenseDate: expenseDate,
+	}
+	for i, p := range participants {
+		shares := 1.0
+		if req.SplitMode == split.ModeShares {
+			shares = p.Shares
+		}
+		expense.Shares = append(expense.Shares, models.SplitExpenseShare{UserID: p.UserID, Shares: shares, Amount: amounts[i]})
+	}
+
+	// Only the payer can post the bill to their own account
+	var transaction *models.Transaction
+	if req.AccountID != "" {
+		if payerID != userID.(uuid.UUID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only the payer can post the expense to an account"})
+			return
+		}
+		account, ok := h.loadOwnAccount(c, req.AccountID)
+		if !ok {
+			return
+		}
+		transaction = &models.Transaction{
+			UserID:          payerID,
+			AccountID:       &account.ID,
+			Type:            models.TransactionTypeExpense,
+			Category:        req.Category,
+			Amount:          req.Amount,
+			Description:     req.Description + " (" + group.Name + ")",
+			TransactionDate: expenseDate,
+		}
+	}
+
+	if err := h.splitRepo.CreateExpense(expense, transaction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create expense"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, expense)
+}
+
+func (h *SplitHandler) GetExpenses(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+
+	expenses, err := h.splitRepo.GetExpenses(group.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get expenses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, expenses)
+}
+
+// DeleteExpense can be done by whoever recorded or paid the expense
+func (h *SplitHandler) DeleteExpense(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("expenseId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+	expense, err := h.splitRepo.GetExpense(group.ID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if expense.CreatedBy != userID.(uuid.UUID) && expense.PayerID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the payer or whoever recorded the expense can delete it"})
+		return
+	}
+
+	if err := h.splitRepo.DeleteExpense(expense); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete expense"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Expense deleted successfully"})
+}
+
+// GetBalances returns each member's net balance and the fewest transfers that settle the group
+func (h *SplitHandler) GetBalances(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+
+	pending, err := h.splitRepo.GetSettlements(group.ID, models.SplitSettlementPending)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get settlements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SplitBalances{
+		Members:            group.Members,
+		SimplifiedDebts:    simplifySplitGroup(group),
+		PendingSettlements: pending,
+	})
+}
+
+// CreateSettlement records the current user paying another member from one of their accounts.
+// The payment is posted right away on the payer's side and counts once the receiver confirms it.
+func (h *SplitHandler) CreateSettlement(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateSplitSettlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	toUserID, err := uuid.Parse(req.ToUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	receiver := findSplitMember(group.Members, toUserID)
+	if receiver == nil || toUserID == userID.(uuid.UUID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Receiver must be another member of the group"})
+		return
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		for _, t := range simplifySplitGroup(group) {
+			if t.FromUserID == userID.(uuid.UUID) && t.ToUserID == toUserID {
+				amount = t.Amount
+			}
+		}
+		if amount == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You don't owe this member anything; pass an amount to pay anyway"})
+			return
+		}
+	}
+
+	settlementDate := time.Now()
+	if req.SettlementDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.SettlementDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid settlement_date format. Use YYYY-MM-DD"})
+			return
+		}
+		settlementDate = parsed
+	}
+
+	account, ok := h.loadOwnAccount(c, req.AccountID)
+	if !ok {
+		return
+	}
+
+	settlement := &models.SplitSettlement{
+		GroupID:        group.ID,
+		FromUserID:     userID.(uuid.UUID),
+		ToUserID:       toUserID,
+		Amount:         amount,
+		SettlementDate: settlementDate,
+	}
+	transaction := &models.Transaction{
+		UserID:          userID.(uuid.UUID),
+		AccountID:       &account.ID,
+		Type:            models.TransactionTypeExpense,
+		Category:        models.CategorySplitSettlement,
+		Amount:          amount,
+		Description:     "Paid " + receiver.FullName + " (" + group.Name + ")",
+		TransactionDate: settlementDate,
+	}
+	if err := h.splitRepo.CreateSettlement(settlement, transaction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create settlement"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, settlement)
+}
+
+func (h *SplitHandler) GetSettlements(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+
+	settlements, err := h.splitRepo.GetSettlements(group.ID, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get settlements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settlements)
+}
+
+// ConfirmSettlement is the receiver acknowledging a payment into one of their accounts
+func (h *SplitHandler) ConfirmSettlement(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+	settlement, ok := h.loadSettlement(c, group)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if settlement.ToUserID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the receiver can confirm a settlement"})
+		return
+	}
+	if settlement.Status != models.SplitSettlementPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Settlement is already confirmed"})
+		return
+	}
+
+	var req models.ConfirmSplitSettlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	account, ok := h.loadOwnAccount(c, req.AccountID)
+	if !ok {
+		return
+	}
+
+	description := "Received (" + group.Name + ")"
+	if payer := findSplitMember(group.Members, settlement.FromUserID); payer != nil {
+		description = "Received from " + payer.FullName + " (" + group.Name + ")"
+	}
+	transaction := &models.Transaction{
+		UserID:          userID.(uuid.UUID),
+		AccountID:       &account.ID,
+		Type:            models.TransactionTypeIncome,
+		Category:        models.CategorySplitSettlement,
+		Amount:          settlement.Amount,
+		Description:     description,
+		TransactionDate: settlement.SettlementDate,
+	}
+	if err := h.splitRepo.ConfirmSettlement(settlement, transaction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm settlement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settlement)
+}
+
+// DeleteSettlement lets the payer cancel a pending settlement, and either side remove a
+// confirmed one. The postings on both sides are reversed.
+func (h *SplitHandler) DeleteSettlement(c *gin.Context) {
+	group, ok := h.loadGroup(c)
+	if !ok {
+		return
+	}
+	settlement, ok := h.loadSettlement(c, group)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	allowed := settlement.FromUserID == userID.(uuid.UUID) ||
+		(settlement.Status == models.SplitSettlementConfirmed && settlement.ToUserID == userID.(uuid.UUID))
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	if err := h.splitRepo.DeleteSettlement(settlement); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete settlement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Settlement deleted successfully"})
+}
+
+// loadGroup fetches the group in :id with its members and checks the current user is one of them.
+// It writes the error response itself and returns ok=false on failure.
+func (h *SplitHandler) loadGroup(c *gin.Context) (*models.SplitGroup, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return nil, false
+	}
+
+	group, err := h.splitRepo.GetGroup(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+	if member := findSplitMember(group.Members, userID.(uuid.UUID)); member == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return group, true
+}
+
+// loadMyInvitation fetches the invitation in :invitationId if it is addressed to the current user
+func (h *SplitHandler) loadMyInvitation(c *gin.Context) (*models.SplitGroupInvitation, bool) {
+	id, err := uuid.Parse(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return nil, false
+	}
+
+	invitation, err := h.splitRepo.GetInvitation(id)
+	userID, _ := c.Get("user_id")
+	if err != nil || invitation.UserID != userID.(uuid.UUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return nil, false
+	}
+	return invitation, true
+}
+
+func (h *SplitHandler) loadSettlement(c *gin.Context, group *models.SplitGroup) (*models.SplitSettlement, bool) {
+	id, err := uuid.Parse(c.Param("settlementId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid settlement ID"})
+		return nil, false
+	}
+
+	settlement, err := h.splitRepo.GetSettlement(group.ID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Settlement not found"})
+		return nil, false
+	}
+	return settlement, true
+}
+
+// loadOwnAccount fetches an account the current user can post to: their own or one shared into their household
+func (h *SplitHandler) loadOwnAccount(c *gin.Context, accountID string) (*models.Account, bool) {
+	id, err := uuid.Parse(accountID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return nil, false
+	}
+
+	account, err := h.accountRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return nil, false
+	}
+
+	if !canEdit(c, account.UserID, account.HouseholdID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return account, true
+}
+
+func findSplitMember(members []models.SplitMember, userID uuid.UUID) *models.SplitMember {
+	for i := range members {
+		if members[i].UserID == userID {
+			return &members[i]
+		}
+	}
+	return nil
+}
+
+func simplifySplitGroup(group *models.SplitGroup) []split.Transfer {
+	balances := map[uuid.UUID]float64{}
+	for _, m := range group.Members {
+		balances[m.UserID] = m.Balance
+	}
+	return split.Simplify(balances)
+}
@@ -65,6 +65,11 @@ func (h *TransactionHandler) Create(c *gin.Context) {
 		creditCardID = &parsedCreditCardID
 	}
 
+	if !h.canPostTo(c, accountID, creditCardID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
 	// Parse transaction date
 	var transactionDate time.Time
 	if req.TransactionDate != "" {
@@ -83,6 +88,7 @@ func (h *TransactionHandler) Create(c *gin.Context) {
 		CreditCardID:    creditCardID,
 		Type:            req.Type,
 		Category:        req.Category,
+		Subcategory:     req.Subcategory,
 		Amount:          req.Amount,
 		Description:     req.Description,
 		TransactionDate: transactionDate,
@@ -97,11 +103,7 @@ func (h *TransactionHandler) Create(c *gin.Context) {
 	if req.AccountID != "" && accountID != nil {
 		account, err := h.accountRepo.GetByID(*accountID)
 		if err == nil {
-			if req.Type == models.TransactionTypeIncome {
-				account.Balance += req.Amount
-			} else if req.Type == models.TransactionTypeExpense {
-				account.Balance -= req.Amount
-			}
+			account.Balance += account.BalanceEffect(req.Type, req.Amount)
 			h.accountRepo.Update(account)
 		}
 	} else if req.CreditCardID != "" && creditCardID != nil {
@@ -164,9 +166,8 @@ func (h *TransactionHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	// Check ownership
-	userID, _ := c.Get("user_id")
-	if transaction.UserID != userID.(uuid.UUID) {
+	// Check ownership or household access to its account or card
+	if !h.canAccess(c, transaction) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -188,12 +189,37 @@ func (h *TransactionHandler) Update(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	if transaction.UserID != userID.(uuid.UUID) {
+	if !h.canAccess(c, transaction) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
 
+	if transaction.InstallmentPlanID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Installment postings are managed by their installment plan"})
+		return
+	}
+
+	if transaction.LoanID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Loan payments are managed by their loan"})
+		return
+	}
+
+	if transaction.IOUID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "IOU settlements are managed by their IOU"})
+		return
+	}
+
+	if transaction.SplitGroupID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Shared expense transactions are managed by their group"})
+		return
+	}
+
+	// Linked pairs must stay in sync, so they can only be reversed as a whole
+	if transaction.LinkedTransactionID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Linked transactions cannot be edited; delete the payment and record it again"})
+		return
+	}
+
 	var req models.UpdateTransactionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -210,6 +236,9 @@ func (h *TransactionHandler) Update(c *gin.Context) {
 	if req.Category != "" {
 		transaction.Category = req.Category
 	}
+	if req.Subcategory != nil {
+		transaction.Subcategory = *req.Subcategory
+	}
 	if req.Amount > 0 {
 		transaction.Amount = req.Amount
 	}
@@ -244,17 +273,17 @@ func (h *TransactionHandler) Update(c *gin.Context) {
 		transaction.CreditCardID = &creditCardID
 		transaction.AccountID = nil
 	}
+	if (req.AccountID != "" || req.CreditCardID != "") && !h.canPostTo(c, transaction.AccountID, transaction.CreditCardID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
 
 	// Reverse old balance change on old account/credit card
 	if oldAccountID != nil {
 		oldAccount, oldAccountErr := h.accountRepo.GetByID(*oldAccountID)
 		if oldAccountErr == nil && oldAccount != nil {
 			// Reverse old transaction effect on old account
-			if oldType == models.TransactionTypeIncome {
-				oldAccount.Balance -= oldAmount
-			} else if oldType == models.TransactionTypeExpense {
-				oldAccount.Balance += oldAmount
-			}
+			oldAccount.Balance -= oldAccount.BalanceEffect(oldType, oldAmount)
 			if err := h.accountRepo.Update(oldAccount); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update old account balance: " + err.Error()})
 				return
@@ -280,11 +309,7 @@ func (h *TransactionHandler) Update(c *gin.Context) {
 		newAccount, newAccountErr := h.accountRepo.GetByID(*transaction.AccountID)
 		if newAccountErr == nil && newAccount != nil {
 			// Apply new transaction effect on new account
-			if transaction.Type == models.TransactionTypeIncome {
-				newAccount.Balance += transaction.Amount
-			} else if transaction.Type == models.TransactionTypeExpense {
-				newAccount.Balance -= transaction.Amount
-			}
+			newAccount.Balance += newAccount.BalanceEffect(transaction.Type, transaction.Amount)
 			if err := h.accountRepo.Update(newAccount); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update new account balance: " + err.Error()})
 				return
@@ -328,24 +353,57 @@ func (h *TransactionHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	if transaction.UserID != userID.(uuid.UUID) {
+	if !h.canAccess(c, transaction) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
 
+	if transaction.InstallmentPlanID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Installment postings are managed by their installment plan"})
+		return
+	}
+
+	if transaction.LoanID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Loan payments are managed by their loan"})
+		return
+	}
+
+	if transaction.IOUID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "IOU settlements are managed by their IOU"})
+		return
+	}
+
+	if transaction.SplitGroupID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Shared expense transactions are managed by their group"})
+		return
+	}
+
+	// Deleting either half of a linked pair reverses both, so the other half's account
+	// or card has to be editable too
+	if transaction.LinkedTransactionID != nil {
+		partner, err := h.transactionRepo.GetByID(*transaction.LinkedTransactionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+			return
+		}
+		if !h.canPostTo(c, partner.AccountID, partner.CreditCardID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		if err := h.transactionRepo.DeleteLinkedPair(transaction); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Transaction deleted successfully"})
+		return
+	}
+
 	// Reverse account or credit card balance before deleting transaction
 	if transaction.AccountID != nil {
 		account, accountErr := h.accountRepo.GetByID(*transaction.AccountID)
 		if accountErr == nil && account != nil {
 			// It's a regular account transaction - reverse the balance change
-			if transaction.Type == models.TransactionTypeIncome {
-				// Income was added to balance, so subtract it
-				account.Balance -= transaction.Amount
-			} else if transaction.Type == models.TransactionTypeExpense {
-				// Expense was subtracted from balance, so add it back
-				account.Balance += transaction.Amount
-			}
+			account.Balance -= account.BalanceEffect(transaction.Type, transaction.Amount)
 			if err := h.accountRepo.Update(account); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account balance: " + err.Error()})
 				return
@@ -386,3 +444,34 @@ func (h *TransactionHandler) GetSummary(c *gin.Context) {
 
 	c.JSON(http.StatusOK, summary)
 }
+
+// canPostTo reports whether the current user can edit the account or card, which may
+// be shared into one of their households
+func (h *TransactionHandler) canPostTo(c *gin.Context, accountID, creditCardID *uuid.UUID) bool {
+	if accountID != nil {
+		account, err := h.accountRepo.GetByID(*accountID)
+		return err == nil && canEdit(c, account.UserID, account.HouseholdID)
+	}
+	if creditCardID != nil {
+		card, err := h.creditCardRepo.GetByID(*creditCardID)
+		return err == nil && canEdit(c, card.UserID, card.HouseholdID)
+	}
+	return false
+}
+
+// canAccess reports whether the current user can see the transaction, or change it for
+// anything but a read: its author always can, household members through its account or card
+func (h *TransactionHandler) canAccess(c *gin.Context, t *models.Transaction) bool {
+	if isOwner(c, t.UserID) {
+		return true
+	}
+	if t.AccountID != nil {
+		account, err := h.accountRepo.GetByID(*t.AccountID)
+		return err == nil && canAccess(c, account.UserID, account.HouseholdID)
+	}
+	if t.CreditCardID != nil {
+		card, err := h.creditCardRepo.GetByID(*t.CreditCardID)
+		return err == nil && canAccess(c, card.UserID, card.HouseholdID)
+	}
+	return false
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+	"github.com/financial-tracker/backend/internal/totp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TOTP two-factor authentication. Setup stores a secret that only takes effect once
+// Enable confirms a first code; from then on Login answers with a challenge that
+// VerifyTwoFactor exchanges for tokens given an authenticator or recovery code.
+
+const (
+	totpIssuer            = "Financial Tracker"
+	twoFactorChallengeTTL = 5 * time.Minute
+)
+
+// GetTwoFactorStatus reports whether the user has 2FA enabled or required
+func (h *AuthHandler) GetTwoFactorStatus(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	user, err := h.userRepo.GetByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	remaining, err := h.twoFactorRepo.CountRecoveryCodes(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorStatus{
+		Enabled:                user.TwoFactorEnabledAt != nil,
+		Required:               user.TwoFactorRequired,
+		RecoveryCodesRemaining: remaining,
+	})
+}
+
+// SetupTwoFactor starts enrolment with a new secret for the user's authenticator app.
+// Calling it again before Enable replaces the secret.
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	user, err := h.userRepo.GetByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactorEnabledAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+	if err := h.twoFactorRepo.SetPendingSecret(user.ID, secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(totpIssuer, user.Email, secret),
+	})
+}
+
+// EnableTwoFactor confirms enrolment with a first code and returns the recovery codes,
+// which are shown only this once. The response also carries a new access token, since
+// one issued while required 2FA was pending only opens the account settings.
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	var req models.EnableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	secret, enabled, err := h.twoFactorRepo.GetSecret(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get two-factor settings"})
+		return
+	}
+	if enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if secret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start two-factor setup first"})
+		return
+	}
+
+	step, ok := totp.Validate(secret, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	codes, err := h.twoFactorRepo.Enable(userID.(uuid.UUID), step)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+	sessionID, _ := c.Get("session_id")
+	token, expiresAt, err := generateToken(user, sessionID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+		"token":          token,
+		"expires_at":     expiresAt,
+	})
+}
+
+// DisableTwoFactor turns 2FA off given the password and a second factor. Users an
+// admin requires 2FA of can't turn it off.
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req models.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	user, err := h.userRepo.GetByID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactorEnabledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if user.TwoFactorRequired {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for your account"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password is incorrect"})
+		return
+	}
+	if !h.checkSecondFactor(c, user.ID, req.TwoFactorCodeRequest) {
+		return
+	}
+
+	if err := h.twoFactorRepo.Disable(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, e.g. after using some of them
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	_, enabled, err := h.twoFactorRepo.GetSecret(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get two-factor settings"})
+		return
+	}
+	if !enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if !h.checkSecondFactor(c, userID.(uuid.UUID), req) {
+		return
+	}
+
+	codes, err := h.twoFactorRepo.RegenerateRecoveryCodes(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// VerifyTwoFactor completes a login challenge from Login with an authenticator or
+// recovery code and issues the session's tokens
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req models.VerifyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := h.twoFactorRepo.GetChallenge(req.ChallengeToken)
+	if errors.Is(err, repository.ErrChallengeInvalid) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login challenge, please log in again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get login challenge"})
+		return
+	}
+
+	// Wrong codes count against the account as well as the challenge, so fresh
+	// challenges don't buy more guesses
+	account := userLoginGuardAccount(challenge.UserID)
+	if !h.checkLoginGuard(c, account) {
+		return
+	}
+
+	ok, err := h.verifySecondFactor(challenge.UserID, req.TwoFactorCodeRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check code"})
+		return
+	}
+	if !ok {
+		if err := h.twoFactorRepo.RecordChallengeFailure(challenge.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record attempt"})
+			return
+		}
+		if err := h.loginGuard.Failure(account, c.ClientIP()); err != nil {
+			log.Printf("Error recording failed login: %v", err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	completed, err := h.twoFactorRepo.CompleteChallenge(challenge.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete login challenge"})
+		return
+	}
+	if !completed {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login challenge, please log in again"})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(challenge.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	if err := h.loginGuard.Success(account); err != nil {
+		log.Printf("Error clearing login attempts: %v", err)
+	}
+	session, refreshToken, err := h.sessionRepo.Create(user.ID, refreshTokenTTL(), sessionClient(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	h.respondWithTokens(c, user, session, refreshToken)
+}
+
+// checkSecondFactor verifies a code for an account change and writes the error response itself
+func (h *AuthHandler) checkSecondFactor(c *gin.Context, userID uuid.UUID, req models.TwoFactorCodeRequest) bool {
+	ok, err := h.verifySecondFactor(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check code"})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return false
+	}
+	return true
+}
+
+// verifySecondFactor accepts a TOTP code not used before or an unused recovery code,
+// using it up
+func (h *AuthHandler) verifySecondFactor(userID uuid.UUID, req models.TwoFactorCodeRequest) (bool, error) {
+	if req.RecoveryCode != "" {
+		return h.twoFactorRepo.UseRecoveryCode(userID, req.RecoveryCode)
+	}
+	if req.Code == "" {
+		return false, nil
+	}
+
+	secret, enabled, err := h.twoFactorRepo.GetSecret(userID)
+	if err != nil || !enabled {
+		return false, err
+	}
+	step, ok := totp.Validate(secret, req.Code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return h.twoFactorRepo.UseStep(userID, step)
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+)
+
+// BudgetRolloverJob copies last month's budgets into the current month
+// for every user who enabled automatic rollover
+type BudgetRolloverJob struct {
+	budgetRepo *repository.BudgetRepository
+}
+
+func NewBudgetRolloverJob(budgetRepo *repository.BudgetRepository) *BudgetRolloverJob {
+	return &BudgetRolloverJob{budgetRepo: budgetRepo}
+}
+
+func (j *BudgetRolloverJob) Name() string {
+	return "budget-rollover"
+}
+
+func (j *BudgetRolloverJob) Run(now time.Time) error {
+	month, year := int(now.Month()), now.Year()
+	prev := time.Date(year, now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+
+	settings, err := j.budgetRepo.GetPendingRollovers(month, year)
+	if err != nil {
+		return err
+	}
+
+	for _, setting := range settings {
+		result, err := j.budgetRepo.CopyFromMonth(setting.UserID, models.BudgetCopyOptions{
+			FromMonth:     int(prev.Month()),
+			FromYear:      prev.Year(),
+			ToMonth:       month,
+			ToYear:        year,
+			Mode:          setting.Mode,
+			AdjustPercent: setting.AdjustPercent,
+			AmountSource:  setting.AmountSource,
+		})
+		if err != nil {
+			log.Printf("Budget rollover failed for user %s: %v", setting.UserID, err)
+			continue
+		}
+
+		if err := j.budgetRepo.MarkRolloverRun(setting.UserID, month, year); err != nil {
+			log.Printf("Failed to mark budget rollover for user %s: %v", setting.UserID, err)
+			continue
+		}
+
+		log.Printf("Budget rollover for user %s: %d created, %d overwritten, %d skipped, %d failed",
+			setting.UserID, len(result.Created), len(result.Overwritten), len(result.Skipped), len(result.Failed))
+	}
+
+	return nil
+}
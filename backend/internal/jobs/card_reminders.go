@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/billing"
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/notifier"
+	"github.com/financial-tracker/backend/internal/repository"
+)
+
+// CardReminderJob reminds users of upcoming and missed card payments and warns
+// when a card's utilization crosses its alert threshold. Everyone who can see the
+// card is notified, i.e. the members of its household too. Each event is keyed so
+// it's notified once per user no matter how often the job runs.
+type CardReminderJob struct {
+	cardRepo *repository.CreditCardRepository
+	notifier *notifier.Notifier
+}
+
+func NewCardReminderJob(cardRepo *repository.CreditCardRepository, n *notifier.Notifier) *CardReminderJob {
+	return &CardReminderJob{cardRepo: cardRepo, notifier: n}
+}
+
+func (j *CardReminderJob) Name() string {
+	return "card-reminders"
+}
+
+func (j *CardReminderJob) Run(now time.Time) error {
+	cards, err := j.cardRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for i := range cards {
+		if err := j.checkCard(&cards[i], now); err != nil {
+			log.Printf("Card reminders failed for card %s: %v", cards[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (j *CardReminderJob) checkCard(card *models.CreditCard, now time.Time) error {
+	today := billing.Day(now)
+	current := billing.CycleFor(card.BillingDate, card.PaymentDueDate, today)
+
+	stmt, err := j.cardRepo.GetStatement(card, current.Previous(), false)
+	if err != nil {
+		return err
+	}
+
+	if stmt.Outstanding > 0 {
+		daysLeft := int(stmt.DueDate.Sub(today).Hours() / 24)
+		due := stmt.DueDate.Format("2006-01-02")
+		switch {
+		case daysLeft < 0:
+			err = j.notify(card, models.NotificationTypePaymentOverdue, "payment_overdue:"+card.ID.String()+":"+due,
+				fmt.Sprintf("%s payment is overdue", card.CardName),
+				fmt.Sprintf("Rp %.0f of the statement due %s is still unpaid.", stmt.Outstanding, due))
+		case daysLeft <= card.ReminderDaysBefore:
+			err = j.notify(card, models.NotificationTypePaymentDue, "payment_due:"+card.ID.String()+":"+due,
+				fmt.Sprintf("%s payment due in %d day(s)", card.CardName, daysLeft),
+				fmt.Sprintf("Rp %.0f is due on %s (minimum Rp %.0f).", stmt.Outstanding, due, stmt.MinimumPayment))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// At most one utilization alert per statement cycle
+	if card.UtilizationAlertPercent > 0 && card.CreditLimit > 0 {
+		utilization := card.CurrentBalance / card.CreditLimit * 100
+		if utilization >= card.UtilizationAlertPercent {
+			return j.notify(card, models.NotificationTypeUtilization, "utilization:"+card.ID.String()+":"+current.Close.Format("2006-01-02"),
+				fmt.Sprintf("%s is at %.0f%% of its limit", card.CardName, utilization),
+				fmt.Sprintf("Balance Rp %.0f of Rp %.0f limit, above your %.0f%% alert.", card.CurrentBalance, card.CreditLimit, card.UtilizationAlertPercent))
+		}
+	}
+	return nil
+}
+
+func (j *CardReminderJob) notify(card *models.CreditCard, kind models.NotificationType, key, title, message string) error {
+	userIDs, err := j.cardRepo.GetViewerIDs(card)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		err := j.notifier.Notify(&models.Notification{
+			UserID:       userID,
+			CreditCardID: &card.ID,
+			Type:         kind,
+			Title:        title,
+			Message:      message,
+			DedupKey:     key,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
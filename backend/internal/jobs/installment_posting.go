@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+)
+
+// InstallmentPostingJob posts credit card and paylater installments once their statement date is reached
+type InstallmentPostingJob struct {
+	cardRepo        *repository.CreditCardRepository
+	paylaterRepo    *repository.PaylaterRepository
+	installmentRepo *repository.InstallmentRepository
+}
+
+func NewInstallmentPostingJob(cardRepo *repository.CreditCardRepository, paylaterRepo *repository.PaylaterRepository, installmentRepo *repository.InstallmentRepository) *InstallmentPostingJob {
+	return &InstallmentPostingJob{cardRepo: cardRepo, paylaterRepo: paylaterRepo, installmentRepo: installmentRepo}
+}
+
+func (j *InstallmentPostingJob) Name() string {
+	return "installment-posting"
+}
+
+func (j *InstallmentPostingJob) Run(now time.Time) error {
+	cardIDs, err := j.installmentRepo.GetCardsWithActivePlans()
+	if err != nil {
+		return err
+	}
+	for _, cardID := range cardIDs {
+		card, err := j.cardRepo.GetByID(cardID)
+		if err != nil {
+			log.Printf("Failed to load credit card %s for installments: %v", cardID, err)
+			continue
+		}
+		j.postTarget(card.InstallmentTarget(), now)
+	}
+
+	accountIDs, err := j.installmentRepo.GetPaylaterAccountsWithActivePlans()
+	if err != nil {
+		return err
+	}
+	for _, accountID := range accountIDs {
+		profile, err := j.paylaterRepo.GetByAccountID(accountID)
+		if err != nil {
+			log.Printf("Failed to load paylater profile %s for installments: %v", accountID, err)
+			continue
+		}
+		j.postTarget(profile.InstallmentTarget(), now)
+	}
+
+	return nil
+}
+
+func (j *InstallmentPostingJob) postTarget(target models.InstallmentTarget, now time.Time) {
+	plans, err := j.installmentRepo.GetByTarget(target)
+	if err != nil {
+		log.Printf("Failed to load installment plans: %v", err)
+		return
+	}
+	for i := range plans {
+		posted, err := j.installmentRepo.PostDue(&plans[i], now)
+		if err != nil {
+			log.Printf("Failed to post installments of plan %s: %v", plans[i].ID, err)
+			continue
+		}
+		if posted > 0 {
+			log.Printf("Posted %d installment(s) of plan %s", posted, plans[i].ID)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/repository"
+)
+
+// NetWorthSnapshotJob stores today's net worth for every user
+type NetWorthSnapshotJob struct {
+	userRepo     *repository.UserRepository
+	netWorthRepo *repository.NetWorthRepository
+}
+
+func NewNetWorthSnapshotJob(userRepo *repository.UserRepository, netWorthRepo *repository.NetWorthRepository) *NetWorthSnapshotJob {
+	return &NetWorthSnapshotJob{userRepo: userRepo, netWorthRepo: netWorthRepo}
+}
+
+func (j *NetWorthSnapshotJob) Name() string {
+	return "net-worth-snapshot"
+}
+
+func (j *NetWorthSnapshotJob) Run(now time.Time) error {
+	users, err := j.userRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		nw, err := j.netWorthRepo.Calculate(user.ID, now)
+		if err != nil {
+			log.Printf("Net worth snapshot failed for user %s: %v", user.ID, err)
+			continue
+		}
+		if err := j.netWorthRepo.UpsertSnapshot(user.ID, nw); err != nil {
+			log.Printf("Failed to store net worth snapshot for user %s: %v", user.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Job is a unit of background work run periodically by the Scheduler
+type Job interface {
+	Name() string
+	Run(now time.Time) error
+}
+
+type scheduledJob struct {
+	job      Job
+	interval time.Duration
+}
+
+// Scheduler runs registered jobs on a fixed interval in background goroutines
+type Scheduler struct {
+	jobs []scheduledJob
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers a job to run once at startup and then on every interval
+func (s *Scheduler) Every(interval time.Duration, job Job) {
+	s.jobs = append(s.jobs, scheduledJob{job: job, interval: interval})
+}
+
+// Start launches all jobs and returns immediately. Jobs stop when ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, sj := range s.jobs {
+		go s.loop(ctx, sj)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, sj scheduledJob) {
+	ticker := time.NewTicker(sj.interval)
+	defer ticker.Stop()
+
+	s.run(sj.job)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.run(sj.job)
+		}
+	}
+}
+
+func (s *Scheduler) run(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", job.Name(), r)
+		}
+	}()
+
+	if err := job.Run(time.Now()); err != nil {
+		log.Printf("Job %s failed: %v", job.Name(), err)
+	}
+}
@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/billing"
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/financial-tracker/backend/internal/repository"
+)
+
+// StatementCloseJob runs the monthly close of each card once its statement date has passed.
+// The statement before it is checked against what was paid by its due date, and interest,
+// late fee and (in its month) the annual fee are posted as charges dated on the statement date.
+// Charges belong to the card's owner; household members see them through the shared card.
+type StatementCloseJob struct {
+	cardRepo *repository.CreditCardRepository
+}
+
+func NewStatementCloseJob(cardRepo *repository.CreditCardRepository) *StatementCloseJob {
+	return &StatementCloseJob{cardRepo: cardRepo}
+}
+
+func (j *StatementCloseJob) Name() string {
+	return "statement-close"
+}
+
+func (j *StatementCloseJob) Run(now time.Time) error {
+	cards, err := j.cardRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for i := range cards {
+		if err := j.closeCard(&cards[i], now); err != nil {
+			log.Printf("Statement close failed for card %s: %v", cards[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (j *StatementCloseJob) closeCard(card *models.CreditCard, now time.Time) error {
+	today := billing.Day(now)
+	closed := billing.CycleFor(card.BillingDate, card.PaymentDueDate, today).Previous()
+	settled := closed.Previous()
+
+	// Wait until the previous statement's due date has passed
+	if !settled.Due.Before(today) {
+		return nil
+	}
+
+	stmt, err := j.cardRepo.GetStatement(card, settled, false)
+	if err != nil {
+		return err
+	}
+	paidByDue, err := j.cardRepo.GetCreditsBetween(card.ID, settled.End(), settled.Due.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	charges := card.Terms().ChargesFor(stmt.StatementBalance, paidByDue)
+	due := settled.Due.Format("2006-01-02")
+
+	var postings []models.Transaction
+	add := func(subcategory string, amount float64, description string) {
+		if amount <= 0 {
+			return
+		}
+		postings = append(postings, models.Transaction{
+			UserID:          card.UserID,
+			CreditCardID:    &card.ID,
+			Type:            models.TransactionTypeExpense,
+			Category:        models.CategoryCreditCardCharges,
+			Subcategory:     subcategory,
+			Amount:          amount,
+			Description:     description,
+			TransactionDate: closed.Close,
+		})
+	}
+	add(models.SubcategoryInterest, charges.Interest, fmt.Sprintf("Interest on unpaid balance (due %s)", due))
+	add(models.SubcategoryLateFee, charges.LateFee, fmt.Sprintf("Late payment fee (due %s)", due))
+	if card.AnnualFeeMonth == int(closed.Close.Month()) {
+		add(models.SubcategoryAnnualFee, card.AnnualFee, fmt.Sprintf("Annual fee %d", closed.Close.Year()))
+	}
+
+	posted, err := j.cardRepo.CloseStatement(card, closed.Close, postings)
+	if err != nil {
+		return err
+	}
+	if posted && len(postings) > 0 {
+		log.Printf("Posted %d charge(s) to card %s for statement %s", len(postings), card.ID, closed.Close.Format("2006-01-02"))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// APITokenValidator looks up personal access tokens
+type APITokenValidator interface {
+	// AuthenticateAPIToken returns the token if it is valid, nil if not, and records its use from ip
+	AuthenticateAPIToken(token, ip string) (*models.APIToken, error)
+}
+
+// authenticateAPIToken finishes AuthMiddleware for a personal access token. The token
+// goes in the context as "api_token" for RequireScope.
+func authenticateAPIToken(c *gin.Context, apiTokens APITokenValidator, tokenString string) {
+	token, err := apiTokens.AuthenticateAPIToken(tokenString, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+		c.Abort()
+		return
+	}
+	if token == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", token.UserID)
+	c.Set("api_token", token)
+	c.Next()
+}
+
+// RequireScope checks that a personal access token may use the resource's routes:
+// reading takes the read scope or the resource's write scope, anything else the write
+// scope ("<resource>:write"). Requests with access tokens pass. It must run after
+// AuthMiddleware.
+func RequireScope(resource string) gin.HandlerFunc {
+	writeScope := resource + ":write"
+	return func(c *gin.Context) {
+		value, exists := c.Get("api_token")
+		if !exists {
+			c.Next()
+			return
+		}
+		token := value.(*models.APIToken)
+
+		scope := writeScope
+		allowed := token.HasScope(writeScope)
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = models.ScopeRead
+			allowed = allowed || token.HasScope(models.ScopeRead)
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Token is missing the " + scope + " scope", "code": "insufficient_scope"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -5,19 +5,43 @@ import (
 	"os"
 	"strings"
 
+	"github.com/financial-tracker/backend/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
 type Claims struct {
-	UserID  uuid.UUID `json:"user_id"`
-	Email   string    `json:"email"`
-	IsAdmin bool      `json:"is_admin"`
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+	// The session the token was issued for and the user's token version at the time
+	SessionID    uuid.UUID `json:"sid"`
+	TokenVersion int       `json:"ver"`
+	// 2FA is required for the user but not set up yet
+	TwoFactorSetupRequired bool `json:"tfa_setup"`
 	jwt.RegisteredClaims
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// TokenValidator checks that a correctly signed access token hasn't been revoked since it was issued
+type TokenValidator interface {
+	IsAccessTokenValid(userID, sessionID uuid.UUID, tokenVersion int) (bool, error)
+}
+
+// AuthMiddleware accepts access tokens and personal access tokens; groups it guards
+// check the latter's scopes with RequireScope. Users who must set up 2FA first are
+// refused until they have; see AccountAuthMiddleware.
+func AuthMiddleware(validator TokenValidator, apiTokens APITokenValidator) gin.HandlerFunc {
+	return authenticate(validator, apiTokens, false)
+}
+
+// AccountAuthMiddleware is AuthMiddleware for the account's own settings, which stay
+// open to users who still have to set up required 2FA so they can do it. Personal
+// access tokens are refused, so one can't be used to make another or change the password.
+func AccountAuthMiddleware(validator TokenValidator) gin.HandlerFunc {
+	return authenticate(validator, nil, true)
+}
+
+func authenticate(validator TokenValidator, apiTokens APITokenValidator, allowPendingTwoFactorSetup bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -33,9 +57,19 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if strings.HasPrefix(tokenString, models.APITokenPrefix) {
+			if apiTokens == nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Personal access tokens can't be used here"})
+				c.Abort()
+				return
+			}
+			authenticateAPIToken(c, apiTokens, tokenString)
+			return
+		}
+
 		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
@@ -50,22 +84,28 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
-		c.Set("is_admin", claims.IsAdmin)
-		c.Next()
-	}
-}
+		// Logged out sessions and tokens from before "log out everywhere" are refused
+		valid, err := validator.IsAccessTokenValid(claims.UserID, claims.SessionID, claims.TokenVersion)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+			c.Abort()
+			return
+		}
+		if !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
 
-// AdminMiddleware checks if user is admin
-func AdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		isAdmin, exists := c.Get("is_admin")
-		if !exists || !isAdmin.(bool) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		if claims.TwoFactorSetupRequired && !allowPendingTwoFactorSetup {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Set up two-factor authentication to continue", "code": "two_factor_setup_required"})
 			c.Abort()
 			return
 		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("email", claims.Email)
+		c.Set("session_id", claims.SessionID)
 		c.Next()
 	}
 }
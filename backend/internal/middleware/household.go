@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// HouseholdRoleLoader looks up the role a user holds in each of their households
+type HouseholdRoleLoader interface {
+	GetRolesByUser(userID uuid.UUID) (map[uuid.UUID]models.HouseholdRole, error)
+}
+
+// HouseholdRoles puts the current user's household roles in the context as
+// "household_roles", for handlers checking access to shared accounts, budgets and cards.
+// It must run after AuthMiddleware.
+func HouseholdRoles(loader HouseholdRoleLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
+			c.Abort()
+			return
+		}
+
+		roles, err := loader.GetRolesByUser(userID.(uuid.UUID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load households"})
+			c.Abort()
+			return
+		}
+
+		c.Set("household_roles", roles)
+		c.Next()
+	}
+}
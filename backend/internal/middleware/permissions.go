@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PermissionLoader looks up the permissions a user's roles grant
+type PermissionLoader interface {
+	GetPermissionsByUser(userID uuid.UUID) ([]string, error)
+}
+
+type cachedPermissions struct {
+	permissions map[string]bool
+	expiresAt   time.Time
+}
+
+// Permissions checks routes against the permissions users hold through their roles.
+// Each user's permissions are cached for ttl, so a role change made elsewhere reaches
+// other instances within that time; Invalidate applies one here immediately.
+type Permissions struct {
+	loader PermissionLoader
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedPermissions
+}
+
+func NewPermissions(loader PermissionLoader, ttl time.Duration) *Permissions {
+	return &Permissions{loader: loader, ttl: ttl, cache: map[uuid.UUID]cachedPermissions{}}
+}
+
+// Require refuses requests from users without the permission, putting the user's
+// permissions in the context as "permissions". Personal access tokens hold none, so
+// they can't be used for these routes. It must run after AuthMiddleware.
+func (p *Permissions) Require(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
+			c.Abort()
+			return
+		}
+
+		granted := map[string]bool{}
+		if _, viaToken := c.Get("api_token"); !viaToken {
+			var err error
+			if granted, err = p.get(userID.(uuid.UUID)); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load permissions"})
+				c.Abort()
+				return
+			}
+		}
+		c.Set("permissions", granted)
+
+		if !granted[permission] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to do this", "code": "permission_denied", "permission": permission})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Invalidate drops the user's cached permissions after their roles change
+func (p *Permissions) Invalidate(userID uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.cache, userID)
+}
+
+func (p *Permissions) get(userID uuid.UUID) (map[string]bool, error) {
+	now := time.Now()
+	p.mu.Lock()
+	entry, ok := p.cache[userID]
+	p.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.permissions, nil
+	}
+
+	names, err := p.loader.GetPermissionsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	granted := make(map[string]bool, len(names))
+	for _, name := range names {
+		granted[name] = true
+	}
+
+	p.mu.Lock()
+	// Drop expired entries now and then so users who stopped calling don't pile up
+	if len(p.cache) > 10000 {
+		for id, e := range p.cache {
+			if !now.Before(e.expiresAt) {
+				delete(p.cache, id)
+			}
+		}
+	}
+	p.cache[userID] = cachedPermissions{permissions: granted, expiresAt: now.Add(p.ttl)}
+	p.mu.Unlock()
+	return granted, nil
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"log"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit allows each client IP rate.Limit requests per rate.Window to the routes it
+// guards, counted under name, and answers 429 with Retry-After beyond that
+func RateLimit(store ratelimit.Store, name string, rate ratelimit.Rate) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		counter, err := store.Incr("ip:"+name+":"+ip, rate.Window)
+		if err != nil {
+			// Don't lock everyone out because the store is down
+			log.Printf("Rate limit store failed: %v", err)
+			c.Next()
+			return
+		}
+
+		if counter.Count > rate.Limit {
+			if counter.Count == rate.Limit+1 {
+				log.Printf("Rate limit: %s exceeded %d %s requests per %s", ip, rate.Limit, name, rate.Window)
+			}
+			ratelimit.TooManyRequests(c, time.Until(counter.ExpiresAt), "Too many attempts, please try again later")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Features that can be held back until the user verifies their email address
+const (
+	FeatureHouseholdInvites = "household_invites"
+	FeatureShareLinks       = "share_links"
+)
+
+// DefaultUnverifiedRestrictions is used when UNVERIFIED_EMAIL_RESTRICTIONS is unset
+const DefaultUnverifiedRestrictions = FeatureHouseholdInvites + "," + FeatureShareLinks
+
+// EmailVerificationChecker reports whether a user has verified their email address
+type EmailVerificationChecker interface {
+	IsEmailVerified(userID uuid.UUID) (bool, error)
+}
+
+// VerificationPolicy decides which features unverified users are kept out of
+type VerificationPolicy struct {
+	checker    EmailVerificationChecker
+	restricted map[string]bool
+}
+
+// NewVerificationPolicy restricts the features in a comma-separated list such as
+// "household_invites,share_links"; "none" or an empty list restricts nothing
+func NewVerificationPolicy(checker EmailVerificationChecker, restrictions string) *VerificationPolicy {
+	restricted := map[string]bool{}
+	for _, feature := range strings.Split(restrictions, ",") {
+		feature = strings.TrimSpace(feature)
+		if feature != "" && feature != "none" {
+			restricted[feature] = true
+		}
+	}
+	return &VerificationPolicy{checker: checker, restricted: restricted}
+}
+
+// Require rejects unverified users with 403 when the feature is restricted. It must run
+// after AuthMiddleware.
+func (p *VerificationPolicy) Require(feature string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !p.restricted[feature] {
+			c.Next()
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
+			c.Abort()
+			return
+		}
+
+		verified, err := p.checker.IsEmailVerified(userID.(uuid.UUID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email verification"})
+			c.Abort()
+			return
+		}
+		if !verified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Verify your email address to use this feature", "code": "email_not_verified"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
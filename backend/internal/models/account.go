@@ -25,12 +25,36 @@ type Account struct {
 	Icon            string      `db:"icon" json:"icon"`
 	Color           string      `db:"color" json:"color"`
 	ParentAccountID *uuid.UUID  `db:"parent_account_id" json:"parent_account_id,omitempty"`
+	// Set when the account is shared into a household; pockets follow their parent
+	HouseholdID *uuid.UUID `db:"household_id" json:"household_id,omitempty"`
 	CreatedAt       time.Time   `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time   `db:"updated_at" json:"updated_at"`
 	// For response only - child accounts (pockets)
 	SubAccounts []Account `db:"-" json:"sub_accounts,omitempty"`
 }
 
+// IsLiability reports whether the balance is money owed rather than money held
+func (a *Account) IsLiability() bool {
+	return a.Type == AccountTypePaylater
+}
+
+// BalanceEffect returns how a transaction changes the balance. Income adds to and expense
+// takes from a cash balance; a paylater balance is the debt, so spending raises it and
+// repayments lower it.
+func (a *Account) BalanceEffect(t TransactionType, amount float64) float64 {
+	var effect float64
+	switch t {
+	case TransactionTypeIncome:
+		effect = amount
+	case TransactionTypeExpense:
+		effect = -amount
+	}
+	if a.IsLiability() {
+		return -effect
+	}
+	return effect
+}
+
 type CreateAccountRequest struct {
 	Name            string      `json:"name" binding:"required"`
 	Type            AccountType `json:"type" binding:"required"`
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// APITokenPrefix starts every personal access token, so they can't be mistaken for
+// access tokens and are easy to spot when leaked
+const APITokenPrefix = "ftk_"
+
+// Personal access token scopes. ScopeRead allows every GET a user can make; a
+// "<resource>:write" scope allows changing that resource, named after its route group.
+const (
+	ScopeRead               = "read"
+	ScopeAccountsWrite      = "accounts:write"
+	ScopeTransactionsWrite  = "transactions:write"
+	ScopeBudgetsWrite       = "budgets:write"
+	ScopeCreditCardsWrite   = "credit-cards:write"
+	ScopeLoansWrite         = "loans:write"
+	ScopeContactsWrite      = "contacts:write"
+	ScopeIOUsWrite          = "ious:write"
+	ScopeSplitGroupsWrite   = "split-groups:write"
+	ScopeGoldWrite          = "gold:write"
+	ScopeNotificationsWrite = "notifications:write"
+)
+
+// APITokenScopes are the scopes a token can be given. Households and account settings
+// have none: they can only be changed when logged in.
+var APITokenScopes = []string{
+	ScopeRead,
+	ScopeAccountsWrite,
+	ScopeTransactionsWrite,
+	ScopeBudgetsWrite,
+	ScopeCreditCardsWrite,
+	ScopeLoansWrite,
+	ScopeContactsWrite,
+	ScopeIOUsWrite,
+	ScopeSplitGroupsWrite,
+	ScopeGoldWrite,
+	ScopeNotificationsWrite,
+}
+
+// IsValidAPITokenScope reports whether scope is one of APITokenScopes
+func IsValidAPITokenScope(scope string) bool {
+	for _, s := range APITokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIToken - a personal access token a user created for a script or integration
+type APIToken struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	UserID      uuid.UUID      `db:"user_id" json:"user_id"`
+	Name        string         `db:"name" json:"name"`
+	TokenPrefix string         `db:"token_prefix" json:"token_prefix"`
+	Scopes      pq.StringArray `db:"scopes" json:"scopes"`
+	ExpiresAt   *time.Time     `db:"expires_at" json:"expires_at"`
+	LastUsedAt  *time.Time     `db:"last_used_at" json:"last_used_at"`
+	LastUsedIP  string         `db:"last_used_ip" json:"last_used_ip"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	RevokedAt   *time.Time     `db:"revoked_at" json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the token was given scope
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPITokenRequest - ExpiresInDays may be left out for a token that doesn't expire
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays *int     `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+// CreateAPITokenResponse carries the token itself, which is shown only this once
+type CreateAPITokenResponse struct {
+	APIToken
+	Token string `json:"token"`
+}
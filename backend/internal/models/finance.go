@@ -3,19 +3,33 @@ package models
 import (
 	"time"
 
+	"github.com/financial-tracker/backend/internal/billing"
 	"github.com/google/uuid"
 )
 
 // Budget - simplified with month/year instead of date range
+// A budget can optionally be scoped to one account (including its pockets) or one credit card
 type Budget struct {
-	ID          uuid.UUID `db:"id" json:"id"`
-	UserID      uuid.UUID `db:"user_id" json:"user_id"`
-	Category    string    `db:"category" json:"category"`
-	Amount      float64   `db:"amount" json:"amount"`
-	BudgetMonth int       `db:"budget_month" json:"budget_month"`
-	BudgetYear  int       `db:"budget_year" json:"budget_year"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	ID           uuid.UUID  `db:"id" json:"id"`
+	UserID       uuid.UUID  `db:"user_id" json:"user_id"`
+	Category     string     `db:"category" json:"category"`
+	Amount       float64    `db:"amount" json:"amount"`
+	BudgetMonth  int        `db:"budget_month" json:"budget_month"`
+	BudgetYear   int        `db:"budget_year" json:"budget_year"`
+	AccountID    *uuid.UUID `db:"account_id" json:"account_id,omitempty"`
+	CreditCardID *uuid.UUID `db:"credit_card_id" json:"credit_card_id,omitempty"`
+	// Set when the budget is shared into a household; it then counts every member's spending
+	HouseholdID *uuid.UUID `db:"household_id" json:"household_id,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// BudgetProgress - budget with spending of matching transactions in its month
+type BudgetProgress struct {
+	Budget
+	Spent       float64 `db:"spent" json:"spent"`
+	Remaining   float64 `db:"-" json:"remaining"`
+	PercentUsed float64 `db:"-" json:"percent_used"`
 }
 
 type CreateBudgetRequest struct {
@@ -32,6 +46,59 @@ type CopyBudgetRequest struct {
 	ToYear    int `json:"to_year" binding:"required,min=2020"`
 }
 
+// BudgetCopyMode decides what happens when the target month already has a budget for the category
+type BudgetCopyMode string
+
+const (
+	BudgetCopyModeSkip      BudgetCopyMode = "skip"
+	BudgetCopyModeOverwrite BudgetCopyMode = "overwrite"
+)
+
+// BudgetAmountSource decides where the copied amount comes from
+type BudgetAmountSource string
+
+const (
+	BudgetAmountSourceBudget            BudgetAmountSource = "budget"
+	BudgetAmountSourceLastMonthActual   BudgetAmountSource = "last_month_actual"
+	BudgetAmountSourceThreeMonthAverage BudgetAmountSource = "three_month_average"
+)
+
+type BudgetCopyOptions struct {
+	FromMonth     int
+	FromYear      int
+	ToMonth       int
+	ToYear        int
+	Mode          BudgetCopyMode
+	AdjustPercent float64 // e.g. 10 = +10%, -5 = -5%
+	AmountSource  BudgetAmountSource
+}
+
+type BudgetCopyFailure struct {
+	Category string `json:"category"`
+	Error    string `json:"error"`
+}
+
+// BudgetCopyResult lists exactly what happened to every source budget
+type BudgetCopyResult struct {
+	Created     []Budget            `json:"created"`
+	Overwritten []Budget            `json:"overwritten"`
+	Skipped     []Budget            `json:"skipped"`
+	Failed      []BudgetCopyFailure `json:"failed"`
+}
+
+// BudgetRolloverSetting - per-user automatic copy of budgets at the start of each month
+type BudgetRolloverSetting struct {
+	UserID        uuid.UUID          `db:"user_id" json:"user_id"`
+	Enabled       bool               `db:"enabled" json:"enabled"`
+	Mode          BudgetCopyMode     `db:"mode" json:"mode"`
+	AdjustPercent float64            `db:"adjust_percent" json:"adjust_percent"`
+	AmountSource  BudgetAmountSource `db:"amount_source" json:"amount_source"`
+	LastRunMonth  *int               `db:"last_run_month" json:"last_run_month,omitempty"`
+	LastRunYear   *int               `db:"last_run_year" json:"last_run_year,omitempty"`
+	CreatedAt     time.Time          `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `db:"updated_at" json:"updated_at"`
+}
+
 // Credit Card - kept separate for specific features
 type CreditCard struct {
 	ID             uuid.UUID `db:"id" json:"id"`
@@ -42,8 +109,118 @@ type CreditCard struct {
 	CurrentBalance float64   `db:"current_balance" json:"current_balance"`
 	BillingDate    int       `db:"billing_date" json:"billing_date"`
 	PaymentDueDate int       `db:"payment_due_date" json:"payment_due_date"`
-	CreatedAt      time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+	// Reminders: days before the due date to remind, and the utilization percent that triggers an alert (0 = off)
+	ReminderDaysBefore      int     `db:"reminder_days_before" json:"reminder_days_before"`
+	UtilizationAlertPercent float64 `db:"utilization_alert_percent" json:"utilization_alert_percent"`
+	// Terms: monthly interest on unpaid balances, late fee below the minimum, annual fee billed in AnnualFeeMonth (0 = none)
+	InterestRate      float64 `db:"interest_rate" json:"interest_rate"`
+	LateFee           float64 `db:"late_fee" json:"late_fee"`
+	AnnualFee         float64 `db:"annual_fee" json:"annual_fee"`
+	AnnualFeeMonth    int     `db:"annual_fee_month" json:"annual_fee_month"`
+	MinPaymentPercent float64 `db:"min_payment_percent" json:"min_payment_percent"`
+	MinPaymentFloor   float64 `db:"min_payment_floor" json:"min_payment_floor"`
+	// Set when the card is shared into a household
+	HouseholdID *uuid.UUID `db:"household_id" json:"household_id,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+	// Calculated fields: installment principal not yet billed still blocks the limit
+	InstallmentOutstanding float64 `db:"-" json:"installment_outstanding"`
+	AvailableLimit         float64 `db:"-" json:"available_limit"`
+}
+
+// Terms returns the card's interest, fee and minimum payment settings
+func (c *CreditCard) Terms() billing.Terms {
+	return billing.Terms{
+		InterestRate:   c.InterestRate,
+		LateFee:        c.LateFee,
+		MinimumPercent: c.MinPaymentPercent,
+		MinimumFloor:   c.MinPaymentFloor,
+	}
+}
+
+// Categories of the charges posted by the monthly statement close
+const (
+	CategoryCreditCardCharges = "Credit Card Charges"
+	SubcategoryInterest       = "Interest"
+	SubcategoryLateFee        = "Late Fee"
+	SubcategoryAnnualFee      = "Annual Fee"
+)
+
+type InstallmentPlanStatus string
+
+const (
+	InstallmentPlanStatusActive    InstallmentPlanStatus = "active"
+	InstallmentPlanStatusCompleted InstallmentPlanStatus = "completed"
+)
+
+// InstallmentPlan - a card or paylater purchase paid in monthly installments (cicilan).
+// Each installment is posted as an expense on the statement date of its cycle.
+type InstallmentPlan struct {
+	ID           uuid.UUID             `db:"id" json:"id"`
+	UserID       uuid.UUID             `db:"user_id" json:"user_id"`
+	CreditCardID *uuid.UUID            `db:"credit_card_id" json:"credit_card_id,omitempty"`
+	AccountID    *uuid.UUID            `db:"account_id" json:"account_id,omitempty"`
+	Description  string                `db:"description" json:"description"`
+	Category     string                `db:"category" json:"category"`
+	Subcategory  string                `db:"subcategory" json:"subcategory"`
+	Principal    float64               `db:"principal" json:"principal"`
+	TenorMonths  int                   `db:"tenor_months" json:"tenor_months"`
+	InterestRate float64               `db:"interest_rate" json:"interest_rate"` // flat, percent per month
+	AdminFee     float64               `db:"admin_fee" json:"admin_fee"`
+	PurchaseDate time.Time             `db:"purchase_date" json:"purchase_date"`
+	Status       InstallmentPlanStatus `db:"status" json:"status"`
+	PostedCount  int                   `db:"posted_count" json:"posted_count"`
+	CreatedAt    time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time             `db:"updated_at" json:"updated_at"`
+	// Calculated fields (from the card's statement cycle)
+	MonthlyAmount      float64               `db:"-" json:"monthly_amount"`
+	RemainingPrincipal float64               `db:"-" json:"remaining_principal"`
+	RemainingAmount    float64               `db:"-" json:"remaining_amount"`
+	NextPostingDate    *time.Time            `db:"-" json:"next_posting_date,omitempty"`
+	Schedule           []billing.Installment `db:"-" json:"schedule,omitempty"`
+}
+
+// InstallmentTarget - what installments are billed to (a credit card or a paylater account)
+// and the statement cycle they post on
+type InstallmentTarget struct {
+	CreditCardID *uuid.UUID
+	AccountID    *uuid.UUID
+	BillingDay   int
+	DueDay       int
+}
+
+// InstallmentTarget returns the card as the target of its installment plans
+func (c *CreditCard) InstallmentTarget() InstallmentTarget {
+	return InstallmentTarget{CreditCardID: &c.ID, BillingDay: c.BillingDate, DueDay: c.PaymentDueDate}
+}
+
+type CreateInstallmentPlanRequest struct {
+	Description  string  `json:"description" binding:"required"`
+	Category     string  `json:"category" binding:"required"`
+	Subcategory  string  `json:"subcategory"`
+	Principal    float64 `json:"principal" binding:"required,gt=0"`
+	TenorMonths  int     `json:"tenor_months" binding:"required,min=1,max=60"`
+	InterestRate float64 `json:"interest_rate" binding:"gte=0"`
+	AdminFee     float64 `json:"admin_fee" binding:"gte=0"`
+	PurchaseDate string  `json:"purchase_date"`
+}
+
+// CreditCardStatement - one statement cycle of a card, computed from its transactions.
+// Card expenses are charges, card income (payments, refunds) are credits.
+type CreditCardStatement struct {
+	CreditCardID     uuid.UUID     `json:"credit_card_id"`
+	PeriodStart      time.Time     `json:"period_start"`
+	StatementDate    time.Time     `json:"statement_date"`
+	DueDate          time.Time     `json:"due_date"`
+	IsClosed         bool          `json:"is_closed"`
+	OpeningBalance   float64       `json:"opening_balance"`
+	Charges          float64       `json:"charges"`
+	Credits          float64       `json:"credits"`
+	StatementBalance float64       `json:"statement_balance"`
+	PaidAfterClose   float64       `json:"paid_after_close"`
+	Outstanding      float64       `json:"outstanding"`
+	MinimumPayment   float64       `json:"minimum_payment"`
+	Transactions     []Transaction `json:"transactions,omitempty"`
 }
 
 type CreateCreditCardRequest struct {
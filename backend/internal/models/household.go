@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type HouseholdRole string
+
+const (
+	HouseholdRoleOwner  HouseholdRole = "owner"
+	HouseholdRoleEditor HouseholdRole = "editor"
+	HouseholdRoleViewer HouseholdRole = "viewer"
+)
+
+// CanEdit reports whether the role may change shared accounts, budgets and cards
+func (r HouseholdRole) CanEdit() bool {
+	return r == HouseholdRoleOwner || r == HouseholdRoleEditor
+}
+
+// Household - a shared workspace, e.g. a couple keeping one set of books. Accounts,
+// budgets and cards shared into it stay owned by their creator but are visible to
+// every member, and editable by owners and editors.
+type Household struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	// The current user's role
+	MyRole      HouseholdRole         `db:"my_role" json:"my_role"`
+	Members     []HouseholdMember     `db:"-" json:"members,omitempty"`
+	Invitations []HouseholdInvitation `db:"-" json:"invitations,omitempty"`
+}
+
+type HouseholdMember struct {
+	UserID   uuid.UUID     `db:"user_id" json:"user_id"`
+	Username *string       `db:"username" json:"username"`
+	FullName string        `db:"full_name" json:"full_name"`
+	Email    string        `db:"email" json:"email"`
+	Role     HouseholdRole `db:"role" json:"role"`
+	JoinedAt time.Time     `db:"joined_at" json:"joined_at"`
+}
+
+type HouseholdInvitationStatus string
+
+const (
+	HouseholdInvitationPending  HouseholdInvitationStatus = "pending"
+	HouseholdInvitationAccepted HouseholdInvitationStatus = "accepted"
+	HouseholdInvitationDeclined HouseholdInvitationStatus = "declined"
+	HouseholdInvitationRevoked  HouseholdInvitationStatus = "revoked"
+)
+
+// HouseholdInvitation - an invitation to join a household, addressed by email so
+// people can be invited before they register
+type HouseholdInvitation struct {
+	ID          uuid.UUID                 `db:"id" json:"id"`
+	HouseholdID uuid.UUID                 `db:"household_id" json:"household_id"`
+	Email       string                    `db:"email" json:"email"`
+	Role        HouseholdRole             `db:"role" json:"role"`
+	Status      HouseholdInvitationStatus `db:"status" json:"status"`
+	InvitedBy   uuid.UUID                 `db:"invited_by" json:"invited_by"`
+	CreatedAt   time.Time                 `db:"created_at" json:"created_at"`
+	RespondedAt *time.Time                `db:"responded_at" json:"responded_at,omitempty"`
+	// For the invitee's inbox
+	HouseholdName string `db:"household_name" json:"household_name,omitempty"`
+}
+
+type CreateHouseholdRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type UpdateHouseholdRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type CreateHouseholdInvitationRequest struct {
+	Email string        `json:"email" binding:"required,email"`
+	Role  HouseholdRole `json:"role" binding:"required,oneof=editor viewer"`
+}
+
+type UpdateHouseholdMemberRequest struct {
+	Role HouseholdRole `json:"role" binding:"required,oneof=owner editor viewer"`
+}
+
+// ShareRequest moves an account, budget or card into a household, or back to
+// personal when HouseholdID is null
+type ShareRequest struct {
+	HouseholdID *uuid.UUID `json:"household_id"`
+}
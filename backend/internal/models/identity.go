@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity - an account at an OpenID Connect provider linked to a user
+type UserIdentity struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	UserID      uuid.UUID `db:"user_id" json:"user_id"`
+	Provider    string    `db:"provider" json:"provider"`
+	Subject     string    `db:"subject" json:"-"`
+	Email       string    `db:"email" json:"email"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	LastLoginAt time.Time `db:"last_login_at" json:"last_login_at"`
+}
+
+// OIDCLoginState - what a sign-in with a provider needs back when the user returns
+type OIDCLoginState struct {
+	Nonce        string `db:"nonce"`
+	CodeVerifier string `db:"code_verifier"`
+}
+
+// OIDCProvider - a provider users can sign in with, for the login page
+type OIDCProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OIDCStartResponse - where to send the user. The frontend keeps State to check that the
+// callback belongs to a sign-in it started.
+type OIDCStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// OIDCCallbackRequest carries the query parameters the provider redirected back with
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Contact - a person the user lends money to or borrows from
+type Contact struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	UserID     uuid.UUID `db:"user_id" json:"user_id"`
+	Name       string    `db:"name" json:"name"`
+	Phone      string    `db:"phone" json:"phone"`
+	Email      string    `db:"email" json:"email"`
+	Notes      string    `db:"notes" json:"notes"`
+	ShareToken *string   `db:"share_token" json:"share_token,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	// Outstanding totals of the contact's open IOUs
+	OwedToMe float64 `db:"owed_to_me" json:"owed_to_me"`
+	IOwe     float64 `db:"i_owe" json:"i_owe"`
+	Balance  float64 `db:"balance" json:"balance"` // positive when the contact owes the user
+}
+
+type CreateContactRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Phone string `json:"phone"`
+	Email string `json:"email" binding:"omitempty,email"`
+	Notes string `json:"notes"`
+}
+
+type UpdateContactRequest struct {
+	Name  string  `json:"name"`
+	Phone *string `json:"phone"`
+	Email *string `json:"email" binding:"omitempty,email"`
+	Notes *string `json:"notes"`
+}
+
+type IOUDirection string
+
+const (
+	IOUDirectionIOwe     IOUDirection = "i_owe"
+	IOUDirectionOwedToMe IOUDirection = "owed_to_me"
+)
+
+type IOUStatus string
+
+const (
+	IOUStatusOpen    IOUStatus = "open"
+	IOUStatusSettled IOUStatus = "settled"
+)
+
+// CategoryIOUSettlement is used for the account transactions created when settling an IOU
+const CategoryIOUSettlement = "IOU Settlement"
+
+// IOU - money owed between the user and a contact, settled in one or more parts
+type IOU struct {
+	ID          uuid.UUID    `db:"id" json:"id"`
+	UserID      uuid.UUID    `db:"user_id" json:"user_id"`
+	ContactID   uuid.UUID    `db:"contact_id" json:"contact_id"`
+	ContactName string       `db:"contact_name" json:"contact_name"`
+	Direction   IOUDirection `db:"direction" json:"direction"`
+	Amount      float64      `db:"amount" json:"amount"`
+	Description string       `db:"description" json:"description"`
+	IOUDate     time.Time    `db:"iou_date" json:"iou_date"`
+	DueDate     *time.Time   `db:"due_date" json:"due_date,omitempty"`
+	// The transaction the IOU came from, e.g. the dinner the user paid for
+	TransactionID *uuid.UUID      `db:"transaction_id" json:"transaction_id,omitempty"`
+	Status        IOUStatus       `db:"status" json:"status"`
+	Settled       float64         `db:"settled" json:"settled"`
+	Outstanding   float64         `db:"outstanding" json:"outstanding"`
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+	Settlements   []IOUSettlement `db:"-" json:"settlements,omitempty"`
+}
+
+// IOUSettlement - a (partial) repayment of an IOU, optionally posted to an account
+type IOUSettlement struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	IOUID          uuid.UUID  `db:"iou_id" json:"iou_id"`
+	Amount         float64    `db:"amount" json:"amount"`
+	SettlementDate time.Time  `db:"settlement_date" json:"settlement_date"`
+	Note           string     `db:"note" json:"note"`
+	TransactionID  *uuid.UUID `db:"transaction_id" json:"transaction_id,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+type CreateIOURequest struct {
+	ContactID     string       `json:"contact_id" binding:"required"`
+	Direction     IOUDirection `json:"direction" binding:"required,oneof=i_owe owed_to_me"`
+	Amount        float64      `json:"amount" binding:"required,gt=0"`
+	Description   string       `json:"description"`
+	IOUDate       string       `json:"iou_date"`
+	DueDate       string       `json:"due_date"`
+	TransactionID string       `json:"transaction_id"`
+}
+
+type UpdateIOURequest struct {
+	Amount      float64 `json:"amount" binding:"omitempty,gt=0"`
+	Description *string `json:"description"`
+	DueDate     *string `json:"due_date"` // empty string clears it
+}
+
+type CreateIOUSettlementRequest struct {
+	// Defaults to the whole outstanding amount
+	Amount         float64 `json:"amount" binding:"omitempty,gt=0"`
+	SettlementDate string  `json:"settlement_date"`
+	Note           string  `json:"note"`
+	// When set, the repayment is posted to this account
+	AccountID string `json:"account_id"`
+}
+
+// SharedIOUSummary - what a contact sees through a share link, from the contact's side
+type SharedIOUSummary struct {
+	OwnerName   string      `json:"owner_name"`
+	ContactName string      `json:"contact_name"`
+	YouOwe      float64     `json:"you_owe"`
+	OwedToYou   float64     `json:"owed_to_you"`
+	Balance     float64     `json:"balance"` // positive when the contact owes the owner
+	OpenItems   []SharedIOU `json:"open_items"`
+	GeneratedAt time.Time   `json:"generated_at"`
+}
+
+// SharedIOU - one open IOU in a shared summary
+type SharedIOU struct {
+	Direction   string     `json:"direction"` // you_owe or owed_to_you, from the contact's side
+	Amount      float64    `json:"amount"`
+	Outstanding float64    `json:"outstanding"`
+	Description string     `json:"description"`
+	IOUDate     time.Time  `json:"iou_date"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+}
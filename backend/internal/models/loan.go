@@ -0,0 +1,184 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/billing"
+	"github.com/google/uuid"
+)
+
+type LoanKind string
+
+const (
+	LoanKindMortgage LoanKind = "mortgage" // KPR
+	LoanKindVehicle  LoanKind = "vehicle"  // KKB
+	LoanKindPersonal LoanKind = "personal"
+	LoanKindFamily   LoanKind = "family"
+	LoanKindOther    LoanKind = "other"
+)
+
+// LoanDirection - whether the user owes the money (hutang) or is owed it (piutang)
+type LoanDirection string
+
+const (
+	LoanDirectionBorrowed LoanDirection = "borrowed"
+	LoanDirectionLent     LoanDirection = "lent"
+)
+
+type LoanStatus string
+
+const (
+	LoanStatusActive  LoanStatus = "active"
+	LoanStatusPaidOff LoanStatus = "paid_off"
+)
+
+// Categories of the account transactions behind loan payments
+const (
+	CategoryLoanPayment  = "Loan Payment"
+	CategoryLoanReceived = "Loan Repayment Received"
+)
+
+// Loan - money borrowed or lent outside accounts and cards, repaid in monthly installments.
+// Payments are recorded from (or into) an account and split into interest and principal.
+type Loan struct {
+	ID           uuid.UUID                  `db:"id" json:"id"`
+	UserID       uuid.UUID                  `db:"user_id" json:"user_id"`
+	Name         string                     `db:"name" json:"name"`
+	Kind         LoanKind                   `db:"kind" json:"kind"`
+	Direction    LoanDirection              `db:"direction" json:"direction"`
+	Counterparty string                     `db:"counterparty" json:"counterparty"`
+	Principal    float64                    `db:"principal" json:"principal"`
+	InterestRate float64                    `db:"interest_rate" json:"interest_rate"` // percent per year
+	Method       billing.AmortizationMethod `db:"method" json:"method"`
+	TenorMonths  int                        `db:"tenor_months" json:"tenor_months"`
+	StartDate    time.Time                  `db:"start_date" json:"start_date"`
+	Notes        string                     `db:"notes" json:"notes"`
+	Status       LoanStatus                 `db:"status" json:"status"`
+	CreatedAt    time.Time                  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time                  `db:"updated_at" json:"updated_at"`
+	// Totals of recorded payments
+	PrincipalPaid float64 `db:"principal_paid" json:"principal_paid"`
+	InterestPaid  float64 `db:"interest_paid" json:"interest_paid"`
+	// Calculated fields (from the amortization schedule)
+	Outstanding    float64                   `db:"-" json:"outstanding"`
+	MonthlyPayment float64                   `db:"-" json:"monthly_payment"`
+	NextDueDate    *time.Time                `db:"-" json:"next_due_date,omitempty"`
+	Schedule       []billing.AmortizationRow `db:"-" json:"schedule,omitempty"`
+}
+
+// FillSchedule computes the loan's amortization schedule and what is still owed
+func (l *Loan) FillSchedule() {
+	l.Schedule = billing.AmortizationSchedule(l.Principal, l.InterestRate, l.TenorMonths, l.Method, l.StartDate)
+	l.Outstanding = math.Max(math.Round((l.Principal-l.PrincipalPaid)*100)/100, 0)
+	l.MonthlyPayment, l.NextDueDate = 0, nil
+	if len(l.Schedule) > 0 {
+		l.MonthlyPayment = l.Schedule[0].Payment
+	}
+	if l.Outstanding <= 0 {
+		return
+	}
+	// The next installment is the first one the principal paid so far doesn't cover
+	for _, row := range l.Schedule {
+		if row.Balance < l.Outstanding {
+			due := row.DueDate
+			l.NextDueDate = &due
+			return
+		}
+	}
+}
+
+// PeriodFor returns the installment period a payment made on date counts toward:
+// from the day after the previous due date through the next due date.
+// Payments after the last due date share one overdue period.
+func (l *Loan) PeriodFor(date time.Time) (start, end time.Time) {
+	date = billing.Day(date)
+	start = billing.Day(l.StartDate)
+	for _, row := range l.Schedule {
+		end = row.DueDate
+		if !date.After(row.DueDate) {
+			return start, end
+		}
+		start = row.DueDate.AddDate(0, 0, 1)
+	}
+	return start, date
+}
+
+// PeriodInterest is the interest charged for one installment period at the current outstanding:
+// a month of the rate on what is still owed for annuity loans, on the original principal for flat loans
+func (l *Loan) PeriodInterest() float64 {
+	if l.Outstanding <= 0 {
+		return 0
+	}
+	base := l.Outstanding
+	if l.Method == billing.AmortizationFlat {
+		base = l.Principal
+	}
+	return math.Round(base*l.InterestRate/12) / 100
+}
+
+// Allocate splits a payment into interest and principal. Interest for the period
+// is paid first, less what earlier payments in the same period already covered.
+func (l *Loan) Allocate(amount, interestPaidInPeriod float64) (principal, interest float64) {
+	interest = math.Max(l.PeriodInterest()-interestPaidInPeriod, 0)
+	if interest > amount {
+		interest = amount
+	}
+	principal = math.Round((amount-interest)*100) / 100
+	return principal, interest
+}
+
+// LoanPayment - one payment on a loan and how it was split
+type LoanPayment struct {
+	ID            uuid.UUID  `db:"id" json:"id"`
+	LoanID        uuid.UUID  `db:"loan_id" json:"loan_id"`
+	TransactionID uuid.UUID  `db:"transaction_id" json:"transaction_id"`
+	AccountID     *uuid.UUID `db:"account_id" json:"account_id,omitempty"`
+	Amount        float64    `db:"amount" json:"amount"`
+	Principal     float64    `db:"principal" json:"principal"`
+	Interest      float64    `db:"interest" json:"interest"`
+	PaymentDate   time.Time  `db:"payment_date" json:"payment_date"`
+	Description   string     `db:"description" json:"description"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+type CreateLoanRequest struct {
+	Name         string                     `json:"name" binding:"required"`
+	Kind         LoanKind                   `json:"kind" binding:"omitempty,oneof=mortgage vehicle personal family other"`
+	Direction    LoanDirection              `json:"direction" binding:"required,oneof=borrowed lent"`
+	Counterparty string                     `json:"counterparty"`
+	Principal    float64                    `json:"principal" binding:"required,gt=0"`
+	InterestRate float64                    `json:"interest_rate" binding:"gte=0"`
+	Method       billing.AmortizationMethod `json:"method" binding:"omitempty,oneof=annuity flat"`
+	TenorMonths  int                        `json:"tenor_months" binding:"required,min=1,max=360"`
+	// For a loan already partly repaid, use the current outstanding as principal,
+	// the remaining tenor and the last due date as start date
+	StartDate string `json:"start_date"`
+	Notes     string `json:"notes"`
+}
+
+// UpdateLoanRequest - the terms fixed by recorded payments can't change, only the description
+type UpdateLoanRequest struct {
+	Name         string   `json:"name"`
+	Kind         LoanKind `json:"kind" binding:"omitempty,oneof=mortgage vehicle personal family other"`
+	Counterparty *string  `json:"counterparty"`
+	Notes        *string  `json:"notes"`
+}
+
+type CreateLoanPaymentRequest struct {
+	AccountID string `json:"account_id" binding:"required"`
+	// Defaults to the scheduled installment, capped at what is still owed
+	Amount      float64 `json:"amount" binding:"omitempty,gt=0"`
+	PaymentDate string  `json:"payment_date"`
+	Description string  `json:"description"`
+}
+
+// DebtPayoffPlan - snowball and avalanche plans for the same monthly budget across loans and cards
+type DebtPayoffPlan struct {
+	Debts          []billing.Debt     `json:"debts"`
+	MinimumPayment float64            `json:"minimum_payment"`
+	Snowball       billing.PayoffPlan `json:"snowball"`
+	Avalanche      billing.PayoffPlan `json:"avalanche"`
+	InterestSaved  float64            `json:"interest_saved"` // by avalanche over snowball
+	Recommended    string             `json:"recommended"`
+}
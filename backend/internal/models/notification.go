@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationType string
+
+const (
+	NotificationTypePaymentDue     NotificationType = "payment_due"
+	NotificationTypePaymentOverdue NotificationType = "payment_overdue"
+	NotificationTypeUtilization    NotificationType = "utilization"
+)
+
+// Notification - an in-app notification, also handed to the configured delivery channels.
+// DedupKey identifies the event so it is only notified once.
+type Notification struct {
+	ID           uuid.UUID        `db:"id" json:"id"`
+	UserID       uuid.UUID        `db:"user_id" json:"user_id"`
+	CreditCardID *uuid.UUID       `db:"credit_card_id" json:"credit_card_id,omitempty"`
+	Type         NotificationType `db:"type" json:"type"`
+	Title        string           `db:"title" json:"title"`
+	Message      string           `db:"message" json:"message"`
+	DedupKey     string           `db:"dedup_key" json:"-"`
+	ReadAt       *time.Time       `db:"read_at" json:"read_at,omitempty"`
+	CreatedAt    time.Time        `db:"created_at" json:"created_at"`
+}
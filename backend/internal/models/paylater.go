@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/financial-tracker/backend/internal/billing"
+	"github.com/google/uuid"
+)
+
+type PaylaterProvider string
+
+const (
+	PaylaterProviderGoPayLater PaylaterProvider = "gopaylater"
+	PaylaterProviderKredivo    PaylaterProvider = "kredivo"
+	PaylaterProviderSPayLater  PaylaterProvider = "spaylater"
+	PaylaterProviderAkulaku    PaylaterProvider = "akulaku"
+	PaylaterProviderOther      PaylaterProvider = "other"
+)
+
+// PaylaterProfile - the credit terms of a paylater account. The account's balance is what is
+// owed; installment purchases post monthly on the billing day and are due on the due day.
+type PaylaterProfile struct {
+	AccountID    uuid.UUID        `db:"account_id" json:"account_id"`
+	Provider     PaylaterProvider `db:"provider" json:"provider"`
+	CreditLimit  float64          `db:"credit_limit" json:"credit_limit"`
+	BillingDay   int              `db:"billing_day" json:"billing_day"`
+	DueDay       int              `db:"due_day" json:"due_day"`
+	InterestRate float64          `db:"interest_rate" json:"interest_rate"` // flat, percent per month on installments
+	AdminFee     float64          `db:"admin_fee" json:"admin_fee"`         // per installment purchase
+	CreatedAt    time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time        `db:"updated_at" json:"updated_at"`
+	// Calculated fields
+	Balance                float64        `db:"-" json:"balance"`
+	InstallmentOutstanding float64        `db:"-" json:"installment_outstanding"`
+	AvailableLimit         float64        `db:"-" json:"available_limit"`
+	CurrentCycle           *billing.Cycle `db:"-" json:"current_cycle,omitempty"`
+}
+
+// InstallmentTarget returns the paylater account as the target of its installment plans
+func (p *PaylaterProfile) InstallmentTarget() InstallmentTarget {
+	return InstallmentTarget{AccountID: &p.AccountID, BillingDay: p.BillingDay, DueDay: p.DueDay}
+}
+
+type UpsertPaylaterProfileRequest struct {
+	Provider     PaylaterProvider `json:"provider" binding:"required,oneof=gopaylater kredivo spaylater akulaku other"`
+	CreditLimit  float64          `json:"credit_limit" binding:"required,gt=0"`
+	BillingDay   int              `json:"billing_day" binding:"required,min=1,max=31"`
+	DueDay       int              `json:"due_day" binding:"required,min=1,max=31"`
+	InterestRate float64          `json:"interest_rate" binding:"gte=0"`
+	AdminFee     float64          `json:"admin_fee" binding:"gte=0"`
+}
+
+// CreatePaylaterPurchaseRequest - a paylater purchase split over a tenor.
+// Interest and admin fee default to the profile's; a 1-month tenor carries no interest.
+type CreatePaylaterPurchaseRequest struct {
+	Description  string   `json:"description" binding:"required"`
+	Category     string   `json:"category" binding:"required"`
+	Subcategory  string   `json:"subcategory"`
+	Amount       float64  `json:"amount" binding:"required,gt=0"`
+	TenorMonths  int      `json:"tenor_months" binding:"required,oneof=1 3 6 12"`
+	InterestRate *float64 `json:"interest_rate" binding:"omitempty,gte=0"`
+	AdminFee     *float64 `json:"admin_fee" binding:"omitempty,gte=0"`
+	PurchaseDate string   `json:"purchase_date"`
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RewardType string
+
+const (
+	RewardTypeCashback RewardType = "cashback"
+	RewardTypePoints   RewardType = "points"
+)
+
+// RewardRule - what a card earns on spending in a category.
+// An empty category is the card's base rate for every category without its own rule.
+// Cashback earns Rate percent of the amount; points earn Rate points per PerAmount spent,
+// each worth PointValue when comparing cards.
+type RewardRule struct {
+	ID           uuid.UUID  `db:"id" json:"id"`
+	UserID       uuid.UUID  `db:"user_id" json:"user_id"`
+	CreditCardID uuid.UUID  `db:"credit_card_id" json:"credit_card_id"`
+	Category     string     `db:"category" json:"category"`
+	RewardType   RewardType `db:"reward_type" json:"reward_type"`
+	Rate         float64    `db:"rate" json:"rate"`
+	PerAmount    float64    `db:"per_amount" json:"per_amount"`
+	PointValue   float64    `db:"point_value" json:"point_value"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// Earn returns the cashback or points earned on a purchase
+func (r *RewardRule) Earn(amount float64) float64 {
+	if r.RewardType == RewardTypePoints {
+		if r.PerAmount <= 0 {
+			return 0
+		}
+		return math.Floor(amount/r.PerAmount) * r.Rate
+	}
+	return math.Round(amount*r.Rate) / 100
+}
+
+// Value returns what a purchase earns in money, converting points at PointValue
+func (r *RewardRule) Value(amount float64) float64 {
+	earned := r.Earn(amount)
+	if r.RewardType == RewardTypePoints {
+		return math.Round(earned*r.PointValue*100) / 100
+	}
+	return earned
+}
+
+// MatchRewardRule returns the rule for a category: its own rule if there is one, else the base rule
+func MatchRewardRule(rules []RewardRule, category string) *RewardRule {
+	var base *RewardRule
+	for i := range rules {
+		switch {
+		case rules[i].Category == "":
+			base = &rules[i]
+		case strings.EqualFold(rules[i].Category, category):
+			return &rules[i]
+		}
+	}
+	return base
+}
+
+type UpsertRewardRuleRequest struct {
+	Category   string     `json:"category"`
+	RewardType RewardType `json:"reward_type" binding:"required,oneof=cashback points"`
+	Rate       float64    `json:"rate" binding:"gte=0"`
+	PerAmount  float64    `json:"per_amount" binding:"omitempty,gt=0"`
+	PointValue float64    `json:"point_value" binding:"gte=0"`
+}
+
+// RewardEarning - what one card purchase earned, recorded with the rule's rate when it posted.
+// RuleID is nil once that rule has been deleted.
+type RewardEarning struct {
+	TransactionID uuid.UUID   `db:"transaction_id" json:"-"`
+	CreditCardID  uuid.UUID   `db:"credit_card_id" json:"-"`
+	Transaction   Transaction `db:"-" json:"transaction"`
+	RuleID        *uuid.UUID  `db:"rule_id" json:"rule_id"`
+	Category      string      `db:"category" json:"-"`
+	RewardType    RewardType  `db:"reward_type" json:"reward_type"`
+	Rate          float64     `db:"rate" json:"rate"`
+	PerAmount     float64     `db:"per_amount" json:"per_amount"`
+	Earned        float64     `db:"earned" json:"earned"`
+	CreatedAt     time.Time   `db:"created_at" json:"-"`
+	UpdatedAt     time.Time   `db:"updated_at" json:"-"`
+}
+
+// RewardRedemption - cashback or points taken out of a card's rewards balance
+type RewardRedemption struct {
+	ID           uuid.UUID  `db:"id" json:"id"`
+	UserID       uuid.UUID  `db:"user_id" json:"user_id"`
+	CreditCardID uuid.UUID  `db:"credit_card_id" json:"credit_card_id"`
+	RewardType   RewardType `db:"reward_type" json:"reward_type"`
+	Amount       float64    `db:"amount" json:"amount"`
+	Description  string     `db:"description" json:"description"`
+	RedeemedAt   time.Time  `db:"redeemed_at" json:"redeemed_at"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
+
+type CreateRewardRedemptionRequest struct {
+	RewardType  RewardType `json:"reward_type" binding:"required,oneof=cashback points"`
+	Amount      float64    `json:"amount" binding:"required,gt=0"`
+	Description string     `json:"description"`
+	RedeemedAt  string     `json:"redeemed_at"`
+}
+
+// RewardBalance - earned, redeemed and remaining rewards of one type on a card
+type RewardBalance struct {
+	RewardType RewardType `db:"reward_type" json:"reward_type"`
+	Earned     float64    `db:"earned" json:"earned"`
+	Redeemed   float64    `db:"redeemed" json:"redeemed"`
+	Balance    float64    `db:"balance" json:"balance"`
+}
+
+// CardRewardValue - what a card would have earned on some spending
+type CardRewardValue struct {
+	CreditCardID uuid.UUID  `json:"credit_card_id"`
+	CardName     string     `json:"card_name"`
+	RewardType   RewardType `json:"reward_type"`
+	Earned       float64    `json:"earned"`
+	Value        float64    `json:"value"`
+}
+
+// BestCardCategory - the card that would have earned the most on a category's spend
+type BestCardCategory struct {
+	Category string            `json:"category"`
+	Spent    float64           `json:"spent"`
+	BestCard *CardRewardValue  `json:"best_card"`
+	Cards    []CardRewardValue `json:"cards"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Permissions checked by routes; roles in the database grant them
+const (
+	PermStatsRead       = "stats:read"
+	PermUsersRead       = "users:read"
+	PermUsersWrite      = "users:write"
+	PermRolesWrite      = "roles:write"
+	PermAPIConfigsRead  = "api_configs:read"
+	PermAPIConfigsWrite = "api_configs:write"
+	PermGoldPricesWrite = "gold_prices:write"
+)
+
+// RoleAdmin is the role users.is_admin mirrors
+const RoleAdmin = "admin"
+
+// Role - a named set of permissions
+type Role struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	Name        string         `db:"name" json:"name"`
+	Description string         `db:"description" json:"description"`
+	Permissions pq.StringArray `db:"permissions" json:"permissions"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+}
+
+// SetUserRolesRequest replaces all of a user's roles
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles" binding:"required"`
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"time"
+
+	"github.com/financial-tracker/backend/internal/split"
+	"github.com/google/uuid"
+)
+
+// SplitGroup - registered users sharing expenses, e.g. housemates or a trip
+type SplitGroup struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	// The current user's net balance in the group, positive when they are owed money
+	MyBalance float64       `db:"my_balance" json:"my_balance"`
+	Members   []SplitMember `db:"-" json:"members,omitempty"`
+}
+
+// SplitMember - a group member and their net balance, positive when they are owed money
+type SplitMember struct {
+	UserID   uuid.UUID `db:"user_id" json:"user_id"`
+	Username *string   `db:"username" json:"username"`
+	FullName string    `db:"full_name" json:"full_name"`
+	Paid     float64   `db:"paid" json:"paid"`
+	Share    float64   `db:"share" json:"share"`
+	Balance  float64   `db:"balance" json:"balance"`
+	JoinedAt time.Time `db:"joined_at" json:"joined_at"`
+}
+
+// SplitGroupInvitation - a user asked to join a group. They only become a member,
+// and can only be made to share its expenses, once they accept.
+type SplitGroupInvitation struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	GroupID       uuid.UUID `db:"group_id" json:"group_id"`
+	UserID        uuid.UUID `db:"user_id" json:"user_id"`
+	InvitedBy     uuid.UUID `db:"invited_by" json:"invited_by"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	GroupName     string    `db:"group_name" json:"group_name"`
+	InvitedByName string    `db:"invited_by_name" json:"invited_by_name"`
+}
+
+// SplitExpense - a bill one member paid, divided between participants
+type SplitExpense struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	GroupID     uuid.UUID  `db:"group_id" json:"group_id"`
+	PayerID     uuid.UUID  `db:"payer_id" json:"payer_id"`
+	CreatedBy   uuid.UUID  `db:"created_by" json:"created_by"`
+	Description string     `db:"description" json:"description"`
+	Category    string     `db:"category" json:"category"`
+	Amount      float64    `db:"amount" json:"amount"`
+	SplitMode   split.Mode `db:"split_mode" json:"split_mode"`
+	ExpenseDate time.Time  `db:"expense_date" json:"expense_date"`
+	// The payer's own expense, when the bill was posted to one of their accounts
+	PayerTransactionID *uuid.UUID          `db:"payer_transaction_id" json:"payer_transaction_id,omitempty"`
+	CreatedAt          time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time           `db:"updated_at" json:"updated_at"`
+	Shares             []SplitExpenseShare `db:"-" json:"shares"`
+}
+
+// SplitExpenseShare - what one participant owes of an expense
+type SplitExpenseShare struct {
+	ExpenseID uuid.UUID `db:"expense_id" json:"-"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	Shares    float64   `db:"shares" json:"shares"`
+	Amount    float64   `db:"amount" json:"amount"`
+}
+
+type SplitSettlementStatus string
+
+const (
+	SplitSettlementPending   SplitSettlementStatus = "pending"
+	SplitSettlementConfirmed SplitSettlementStatus = "confirmed"
+)
+
+// CategorySplitSettlement is used for both sides of a settlement between group members
+const CategorySplitSettlement = "Shared Expense Settlement"
+
+// SplitSettlement - a payment from one member to another. The payer records it from
+// their account; it counts once the receiver confirms it into one of theirs.
+type SplitSettlement struct {
+	ID                uuid.UUID             `db:"id" json:"id"`
+	GroupID           uuid.UUID             `db:"group_id" json:"group_id"`
+	FromUserID        uuid.UUID             `db:"from_user_id" json:"from_user_id"`
+	ToUserID          uuid.UUID             `db:"to_user_id" json:"to_user_id"`
+	Amount            float64               `db:"amount" json:"amount"`
+	SettlementDate    time.Time             `db:"settlement_date" json:"settlement_date"`
+	Status            SplitSettlementStatus `db:"status" json:"status"`
+	FromTransactionID *uuid.UUID            `db:"from_transaction_id" json:"from_transaction_id,omitempty"`
+	ToTransactionID   *uuid.UUID            `db:"to_transaction_id" json:"to_transaction_id,omitempty"`
+	CreatedAt         time.Time             `db:"created_at" json:"created_at"`
+	ConfirmedAt       *time.Time            `db:"confirmed_at" json:"confirmed_at,omitempty"`
+}
+
+// SplitBalances - who owes whom in a group, and the fewest transfers that settle it
+type SplitBalances struct {
+	Members            []SplitMember     `json:"members"`
+	SimplifiedDebts    []split.Transfer  `json:"simplified_debts"`
+	PendingSettlements []SplitSettlement `json:"pending_settlements"`
+}
+
+type CreateSplitGroupRequest struct {
+	Name string `json:"name" binding:"required"`
+	// Usernames or emails of the users to invite
+	Members []string `json:"members"`
+}
+
+type InviteSplitMemberRequest struct {
+	UsernameOrEmail string `json:"username_or_email" binding:"required"`
+}
+
+type SplitParticipantRequest struct {
+	UserID string  `json:"user_id" binding:"required"`
+	Shares float64 `json:"shares"`
+	Amount float64 `json:"amount"`
+}
+
+type CreateSplitExpenseRequest struct {
+	Description string     `json:"description" binding:"required"`
+	Category    string     `json:"category" binding:"required"`
+	Amount      float64    `json:"amount" binding:"required,gt=0"`
+	SplitMode   split.Mode `json:"split_mode" binding:"required,oneof=equal shares exact"`
+	// Defaults to the current user
+	PayerID     string `json:"payer_id"`
+	ExpenseDate string `json:"expense_date"`
+	// Defaults to every member, split equally
+	Participants []SplitParticipantRequest `json:"participants"`
+	// When the current user paid, posts the whole bill as an expense on this account
+	AccountID string `json:"account_id"`
+}
+
+type CreateSplitSettlementRequest struct {
+	ToUserID  string `json:"to_user_id" binding:"required"`
+	AccountID string `json:"account_id" binding:"required"`
+	// Defaults to what the simplified debts say the current user owes the receiver
+	Amount         float64 `json:"amount" binding:"omitempty,gt=0"`
+	SettlementDate string  `json:"settlement_date"`
+}
+
+type ConfirmSplitSettlementRequest struct {
+	AccountID string `json:"account_id" binding:"required"`
+}
@@ -1,9 +1,13 @@
 package models
 
+import "time"
+
 type TransactionSummary struct {
 	TotalIncome  float64 `json:"total_income"`
 	TotalExpense float64 `json:"total_expense"`
 	Balance      float64 `json:"balance"`
+	// Spending on credit, not included in TotalExpense because it doesn't reduce cash
+	TotalCreditExpense float64 `json:"total_credit_expense"`
 }
 
 type DashboardStats struct {
@@ -15,3 +19,87 @@ type DashboardStats struct {
 	TotalCreditCards int                 `json:"total_credit_cards"`
 	RecentTransactions []Transaction     `json:"recent_transactions"`
 }
+
+// Reports
+
+// MonthlyReport - income and expense of one calendar month.
+// Expense includes credit card and paylater spending on its transaction date; repaying them is not income.
+type MonthlyReport struct {
+	Month         string  `db:"month" json:"month"`
+	Income        float64 `db:"income" json:"income"`
+	Expense       float64 `db:"expense" json:"expense"`
+	CreditExpense float64 `db:"credit_expense" json:"credit_expense"`
+	Net           float64 `db:"-" json:"net"`
+}
+
+type SubcategoryReport struct {
+	Subcategory string  `json:"subcategory"`
+	Total       float64 `json:"total"`
+	Count       int     `json:"count"`
+	Percent     float64 `json:"percent"`
+}
+
+type CategoryReport struct {
+	Category      string              `json:"category"`
+	Total         float64             `json:"total"`
+	Count         int                 `json:"count"`
+	Percent       float64             `json:"percent"`
+	Subcategories []SubcategoryReport `json:"subcategories"`
+}
+
+type PeriodReport struct {
+	StartDate  string           `json:"start_date"`
+	EndDate    string           `json:"end_date"`
+	Income     float64          `json:"income"`
+	Expense    float64          `json:"expense"`
+	Net        float64          `json:"net"`
+	Categories []CategoryReport `json:"categories"`
+}
+
+// ReportDelta - change from the previous period. Percent is nil when the previous value is zero.
+type ReportDelta struct {
+	Current  float64  `json:"current"`
+	Previous float64  `json:"previous"`
+	Delta    float64  `json:"delta"`
+	Percent  *float64 `json:"percent"`
+}
+
+type CategoryComparison struct {
+	Category string `json:"category"`
+	ReportDelta
+}
+
+type PeriodComparison struct {
+	Current    PeriodReport         `json:"current"`
+	Previous   PeriodReport         `json:"previous"`
+	Income     ReportDelta          `json:"income"`
+	Expense    ReportDelta          `json:"expense"`
+	Net        ReportDelta          `json:"net"`
+	Categories []CategoryComparison `json:"categories"`
+}
+
+// Net worth
+
+type NetWorthSource string
+
+const (
+	NetWorthSourceLive          NetWorthSource = "live"
+	NetWorthSourceSnapshot      NetWorthSource = "snapshot"
+	NetWorthSourceReconstructed NetWorthSource = "reconstructed"
+)
+
+// NetWorth - accounts and pockets, gold at market price and money lent, minus credit card,
+// paylater and loan debt
+type NetWorth struct {
+	Date             time.Time      `db:"snapshot_date" json:"date"`
+	Cash             float64        `db:"cash" json:"cash"`
+	Gold             float64        `db:"gold" json:"gold"`
+	LoanReceivable   float64        `db:"loan_receivable" json:"loan_receivable"`
+	TotalAssets      float64        `db:"total_assets" json:"total_assets"`
+	CreditCardDebt   float64        `db:"credit_card_debt" json:"credit_card_debt"`
+	PaylaterDebt     float64        `db:"paylater_debt" json:"paylater_debt"`
+	LoanDebt         float64        `db:"loan_debt" json:"loan_debt"`
+	TotalLiabilities float64        `db:"total_liabilities" json:"total_liabilities"`
+	NetWorth         float64        `db:"net_worth" json:"net_worth"`
+	Source           NetWorthSource `db:"-" json:"source"`
+}
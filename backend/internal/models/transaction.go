@@ -14,6 +14,9 @@ const (
 	TransactionTypeTransfer TransactionType = "transfer"
 )
 
+// CategoryCreditCardPayment is used for both halves of a card payment made from an account
+const CategoryCreditCardPayment = "Credit Card Payment"
+
 type Transaction struct {
 	ID              uuid.UUID       `db:"id" json:"id"`
 	UserID          uuid.UUID       `db:"user_id" json:"user_id"`
@@ -21,11 +24,23 @@ type Transaction struct {
 	CreditCardID    *uuid.UUID      `db:"credit_card_id" json:"credit_card_id,omitempty"`
 	Type            TransactionType `db:"type" json:"type"`
 	Category        string          `db:"category" json:"category"`
+	Subcategory     string          `db:"subcategory" json:"subcategory"`
 	Amount          float64         `db:"amount" json:"amount"`
 	Description     string          `db:"description" json:"description"`
 	TransactionDate time.Time       `db:"transaction_date" json:"transaction_date"`
-	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	// Other half of a transfer pair, e.g. the bank debit of a credit card payment
+	LinkedTransactionID *uuid.UUID `db:"linked_transaction_id" json:"linked_transaction_id,omitempty"`
+	// Set on the monthly postings of an installment plan
+	InstallmentPlanID *uuid.UUID `db:"installment_plan_id" json:"installment_plan_id,omitempty"`
+	InstallmentNumber *int       `db:"installment_number" json:"installment_number,omitempty"`
+	// Set on the account transaction behind a loan payment
+	LoanID *uuid.UUID `db:"loan_id" json:"loan_id,omitempty"`
+	// Set on the account transaction created when settling an IOU
+	IOUID *uuid.UUID `db:"iou_id" json:"iou_id,omitempty"`
+	// Set on transactions posted for a shared expense group: the payer's bill and settlements
+	SplitGroupID *uuid.UUID `db:"split_group_id" json:"split_group_id,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 type CreateTransactionRequest struct {
@@ -33,6 +48,7 @@ type CreateTransactionRequest struct {
 	CreditCardID    string          `json:"credit_card_id"`
 	Type            TransactionType `json:"type" binding:"required"`
 	Category        string          `json:"category" binding:"required"`
+	Subcategory     string          `json:"subcategory"`
 	Amount          float64         `json:"amount" binding:"required,gt=0"`
 	Description     string          `json:"description"`
 	TransactionDate string          `json:"transaction_date"`
@@ -43,7 +59,30 @@ type UpdateTransactionRequest struct {
 	CreditCardID    string          `json:"credit_card_id"`
 	Type            TransactionType `json:"type"`
 	Category        string          `json:"category"`
+	Subcategory     *string         `json:"subcategory"`
 	Amount          float64         `json:"amount" binding:"gt=0"`
 	Description     string          `json:"description"`
 	TransactionDate string          `json:"transaction_date"`
-}
\ No newline at end of file
+}
+
+type CardPaymentType string
+
+const (
+	CardPaymentTypeFull    CardPaymentType = "full"
+	CardPaymentTypeMinimum CardPaymentType = "minimum"
+	CardPaymentTypeCustom  CardPaymentType = "custom"
+)
+
+type CreateCardPaymentRequest struct {
+	AccountID   string          `json:"account_id" binding:"required"`
+	PaymentType CardPaymentType `json:"payment_type" binding:"required,oneof=full minimum custom"`
+	Amount      float64         `json:"amount" binding:"omitempty,gt=0"`
+	PaymentDate string          `json:"payment_date"`
+	Description string          `json:"description"`
+}
+
+// CardPayment - a card payment as the pair of its card credit and account debit
+type CardPayment struct {
+	CardTransaction    Transaction `json:"card_transaction"`
+	AccountTransaction Transaction `json:"account_transaction"`
+}
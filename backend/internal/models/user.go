@@ -13,8 +13,16 @@ type User struct {
 	PasswordHash string    `db:"password_hash" json:"-"`
 	FullName     string    `db:"full_name" json:"full_name"`
 	IsAdmin      bool      `db:"is_admin" json:"is_admin"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	// Carried in access tokens; bumped to invalidate all of them at once
+	TokenVersion int `db:"token_version" json:"-"`
+	// Nil until the user follows the link in their verification email
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"email_verified_at"`
+	// Set once TOTP enrolment is confirmed
+	TwoFactorEnabledAt *time.Time `db:"totp_enabled_at" json:"two_factor_enabled_at"`
+	// Set by an admin: the user has to enrol before using anything but their account settings
+	TwoFactorRequired bool      `db:"two_factor_required" json:"two_factor_required"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
 }
 
 type RegisterRequest struct {
@@ -31,7 +39,28 @@ type LoginRequest struct {
 
 type LoginResponse struct {
 	Token string `json:"token"`
-	User  User   `json:"user"`
+	// When the access token expires; trade the refresh token for a new pair before then
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+	User         User      `json:"user"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
 }
 
 // Admin models
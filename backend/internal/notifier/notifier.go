@@ -0,0 +1,98 @@
+// Package notifier delivers user notifications. Every notification is recorded
+// in a Store first, which drops duplicates, and new ones are then passed to each
+// configured Channel (log, webhook, ...).
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+)
+
+// Store records notifications and reports whether one is new
+type Store interface {
+	Record(n *models.Notification) (bool, error)
+}
+
+// Channel delivers a notification outside the app
+type Channel interface {
+	Name() string
+	Send(n *models.Notification) error
+}
+
+type Notifier struct {
+	store    Store
+	channels []Channel
+}
+
+func New(store Store, channels ...Channel) *Notifier {
+	return &Notifier{store: store, channels: channels}
+}
+
+// Notify records the notification and delivers it if it hasn't been sent before.
+// A failing channel is logged and doesn't stop delivery to the others.
+func (n *Notifier) Notify(notification *models.Notification) error {
+	isNew, err := n.store.Record(notification)
+	if err != nil {
+		return err
+	}
+	if !isNew {
+		return nil
+	}
+
+	for _, ch := range n.channels {
+		if err := ch.Send(notification); err != nil {
+			log.Printf("Notification channel %s failed: %v", ch.Name(), err)
+		}
+	}
+	return nil
+}
+
+// LogChannel writes notifications to the server log
+type LogChannel struct{}
+
+func (LogChannel) Name() string {
+	return "log"
+}
+
+func (LogChannel) Send(n *models.Notification) error {
+	log.Printf("Notification for user %s [%s]: %s - %s", n.UserID, n.Type, n.Title, n.Message)
+	return nil
+}
+
+// WebhookChannel posts notifications as JSON to a URL
+type WebhookChannel struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookChannel(url string) *WebhookChannel {
+	return &WebhookChannel{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *WebhookChannel) Name() string {
+	return "webhook"
+}
+
+func (w *WebhookChannel) Send(n *models.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
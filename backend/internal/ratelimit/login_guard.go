@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// LoginGuard slows down password guessing against one account, wherever it comes from.
+// The first FreeAttempts failures cost nothing; after that each attempt has to wait
+// BaseDelay, doubling with every further failure, and LockoutThreshold failures lock
+// the account for LockoutDuration. Failures are forgotten Window after the first one
+// or on a successful login.
+type LoginGuard struct {
+	store            Store
+	FreeAttempts     int
+	LockoutThreshold int
+	Window           time.Duration
+	BaseDelay        time.Duration
+	LockoutDuration  time.Duration
+}
+
+func NewLoginGuard(store Store) *LoginGuard {
+	return &LoginGuard{
+		store:            store,
+		FreeAttempts:     3,
+		LockoutThreshold: 10,
+		Window:           15 * time.Minute,
+		BaseDelay:        time.Second,
+		LockoutDuration:  15 * time.Minute,
+	}
+}
+
+// Wait returns how long the account has to wait before its next login attempt, zero if
+// it may try now
+func (g *LoginGuard) Wait(account string) (time.Duration, error) {
+	now := time.Now()
+	lock, err := g.store.Get(lockKey(account))
+	if err != nil {
+		return 0, err
+	}
+	if lock.Count > 0 {
+		return lock.ExpiresAt.Sub(now), nil
+	}
+
+	failures, err := g.store.Get(failureKey(account))
+	if err != nil {
+		return 0, err
+	}
+	if failures.Count < g.FreeAttempts {
+		return 0, nil
+	}
+	wait := failures.LastAt.Add(g.delay(failures.Count)).Sub(now)
+	if wait < 0 {
+		return 0, nil
+	}
+	return wait, nil
+}
+
+// Failure records a failed login for the account from ip and locks the account once it
+// reaches the threshold
+func (g *LoginGuard) Failure(account, ip string) error {
+	failures, err := g.store.Incr(failureKey(account), g.Window)
+	if err != nil {
+		return err
+	}
+	if failures.Count < g.LockoutThreshold {
+		return nil
+	}
+
+	if _, err := g.store.Incr(lockKey(account), g.LockoutDuration); err != nil {
+		return err
+	}
+	log.Printf("Login lockout: %q locked for %s after %d failed attempts, last from %s", normalizeAccount(account), g.LockoutDuration, failures.Count, ip)
+	return g.store.Delete(failureKey(account))
+}
+
+// Success forgets the account's failures
+func (g *LoginGuard) Success(account string) error {
+	return g.store.Delete(failureKey(account))
+}
+
+// delay is the wait after the given number of failures
+func (g *LoginGuard) delay(failures int) time.Duration {
+	shift := failures - g.FreeAttempts
+	if shift > 16 {
+		shift = 16
+	}
+	return g.BaseDelay << shift
+}
+
+func failureKey(account string) string {
+	return "login-failures:" + normalizeAccount(account)
+}
+
+func lockKey(account string) string {
+	return "login-lock:" + normalizeAccount(account)
+}
+
+// normalizeAccount makes "Alice@Example.com " and "alice@example.com" the same account
+func normalizeAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
@@ -0,0 +1,20 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TooManyRequests answers 429 with message and a Retry-After of wait, rounded up to
+// seconds. The rate limit middleware and the login throttling both answer this way.
+func TooManyRequests(c *gin.Context, wait time.Duration, message string) {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": message, "retry_after": seconds})
+}
@@ -0,0 +1,127 @@
+// Package ratelimit counts requests and failed logins per key (an IP address, an
+// account) in fixed windows. Counters live in a Store: MemoryStore suits a single
+// instance, while several instances behind a load balancer need a shared Store
+// implementation, e.g. on Redis, so they see the same counts.
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Counter is the state of one key
+type Counter struct {
+	Count int
+	// When the window started by the first hit ends and the counter is dropped
+	ExpiresAt time.Time
+	LastAt    time.Time
+}
+
+// Store keeps counters that expire a fixed time after their first hit
+type Store interface {
+	// Incr adds a hit to key, starting a window of ttl if there is none, and returns the counter
+	Incr(key string, ttl time.Duration) (Counter, error)
+	// Get returns the counter for key; Count is 0 if there is none
+	Get(key string) (Counter, error)
+	Delete(key string) error
+}
+
+// MemoryStore is a Store for a single instance. Counters are lost on restart.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]Counter
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]Counter{}, lastSweep: time.Now()}
+}
+
+func (s *MemoryStore) Incr(key string, ttl time.Duration) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	counter, ok := s.counters[key]
+	if !ok || !now.Before(counter.ExpiresAt) {
+		counter = Counter{ExpiresAt: now.Add(ttl)}
+	}
+	counter.Count++
+	counter.LastAt = now
+	s.counters[key] = counter
+	return counter, nil
+}
+
+func (s *MemoryStore) Get(key string) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter, ok := s.counters[key]
+	if !ok || !time.Now().Before(counter.ExpiresAt) {
+		return Counter{}, nil
+	}
+	return counter, nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counters, key)
+	return nil
+}
+
+// sweep drops expired counters, at most once a minute. Callers hold the lock.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	for key, counter := range s.counters {
+		if !now.Before(counter.ExpiresAt) {
+			delete(s.counters, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// Rate is a number of requests allowed per window
+type Rate struct {
+	Limit  int
+	Window time.Duration
+}
+
+// ParseRate reads a rate such as "10/1m" or "100/1h", returning fallback when s is
+// empty or invalid
+func ParseRate(s string, fallback Rate) Rate {
+	if s == "" {
+		return fallback
+	}
+	rate, err := parseRate(s)
+	if err != nil {
+		log.Printf("Invalid rate %q, using %d/%s: %v", s, fallback.Limit, fallback.Window, err)
+		return fallback
+	}
+	return rate
+}
+
+func parseRate(s string) (Rate, error) {
+	count, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("expected <count>/<window>")
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || limit <= 0 {
+		return Rate{}, fmt.Errorf("invalid count %q", count)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || d <= 0 {
+		return Rate{}, fmt.Errorf("invalid window %q", window)
+	}
+	return Rate{Limit: limit, Window: d}, nil
+}
@@ -17,6 +17,8 @@ func NewAccountRepository(db *sqlx.DB) *AccountRepository {
 	return &AccountRepository{db: db}
 }
 
+const accountColumns = `id, user_id, name, type, balance, currency, icon, color, parent_account_id, household_id, created_at, updated_at`
+
 func (r *AccountRepository) Create(account *models.Account) error {
 	account.ID = uuid.New()
 	account.CreatedAt = time.Now()
@@ -24,10 +26,10 @@ func (r *AccountRepository) Create(account *models.Account) error {
 	account.Balance = 0 // Always start with 0 balance
 
 	query := `
-		INSERT INTO accounts (id, user_id, name, type, balance, currency, icon, color, parent_account_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO accounts (id, user_id, name, type, balance, currency, icon, color, parent_account_id, household_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
-	_, err := r.db.Exec(query, account.ID, account.UserID, account.Name, account.Type, account.Balance, account.Currency, account.Icon, account.Color, account.ParentAccountID, account.CreatedAt, account.UpdatedAt)
+	_, err := r.db.Exec(query, account.ID, account.UserID, account.Name, account.Type, account.Balance, account.Currency, account.Icon, account.Color, account.ParentAccountID, account.HouseholdID, account.CreatedAt, account.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create account: %w", err)
 	}
@@ -35,10 +37,11 @@ func (r *AccountRepository) Create(account *models.Account) error {
 	return nil
 }
 
-// GetByUserID returns all main accounts (no parent) with their sub-accounts
+// GetByUserID returns all main accounts (no parent) with their sub-accounts,
+// including accounts shared into the user's households
 func (r *AccountRepository) GetByUserID(userID uuid.UUID) ([]models.Account, error) {
 	var allAccounts []models.Account
-	query := `SELECT id, user_id, name, type, balance, currency, icon, color, parent_account_id, created_at, updated_at FROM accounts WHERE user_id = $1 ORDER BY created_at DESC`
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE ` + visibleToUser + ` ORDER BY created_at DESC`
 	err := r.db.Select(&allAccounts, query, userID)
 	if err != nil {
 		return nil, err
@@ -69,7 +72,7 @@ func (r *AccountRepository) GetByUserID(userID uuid.UUID) ([]models.Account, err
 // GetSubAccounts returns all sub-accounts for a parent account
 func (r *AccountRepository) GetSubAccounts(parentID uuid.UUID) ([]models.Account, error) {
 	var accounts []models.Account
-	query := `SELECT id, user_id, name, type, balance, currency, icon, color, parent_account_id, created_at, updated_at FROM accounts WHERE parent_account_id = $1 ORDER BY created_at DESC`
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE parent_account_id = $1 ORDER BY created_at DESC`
 	err := r.db.Select(&accounts, query, parentID)
 	if err != nil {
 		return nil, err
@@ -79,7 +82,7 @@ func (r *AccountRepository) GetSubAccounts(parentID uuid.UUID) ([]models.Account
 
 func (r *AccountRepository) GetByID(id uuid.UUID) (*models.Account, error) {
 	var account models.Account
-	query := `SELECT id, user_id, name, type, balance, currency, icon, color, parent_account_id, created_at, updated_at FROM accounts WHERE id = $1`
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1`
 	err := r.db.Get(&account, query, id)
 	if err != nil {
 		return nil, err
@@ -106,3 +109,18 @@ func (r *AccountRepository) Delete(id uuid.UUID) error {
 	_, err := r.db.Exec(query, id)
 	return err
 }
+
+// SetHousehold shares the account and its pockets into a household, or makes them personal again when householdID is nil
+func (r *AccountRepository) SetHousehold(account *models.Account, householdID *uuid.UUID) error {
+	account.HouseholdID = householdID
+	account.UpdatedAt = time.Now()
+	query := `UPDATE accounts SET household_id = $1, updated_at = $2 WHERE id = $3 OR parent_account_id = $3`
+	_, err := r.db.Exec(query, account.HouseholdID, account.UpdatedAt, account.ID)
+	if err != nil {
+		return err
+	}
+	for i := range account.SubAccounts {
+		account.SubAccounts[i].HouseholdID = householdID
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type APITokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewAPITokenRepository(db *sqlx.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+const apiTokenColumns = `id, user_id, name, token_prefix, scopes, expires_at, last_used_at, last_used_ip, created_at, revoked_at`
+
+// Create stores a new token for the user and returns it with the token itself, which
+// can't be recovered later
+func (r *APITokenRepository) Create(userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*models.APIToken, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(buf)
+	token := models.APITokenPrefix + secret
+
+	apiToken := &models.APIToken{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Name:        name,
+		TokenPrefix: models.APITokenPrefix + secret[:8],
+		Scopes:      pq.StringArray(scopes),
+		ExpiresAt:   expiresAt,
+		CreatedAt:   time.Now(),
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO api_tokens (id, user_id, name, token_prefix, token_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		apiToken.ID, apiToken.UserID, apiToken.Name, apiToken.TokenPrefix, hashToken(token), apiToken.Scopes, apiToken.ExpiresAt, apiToken.CreatedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create API token: %w", err)
+	}
+	return apiToken, token, nil
+}
+
+func (r *APITokenRepository) GetByID(id uuid.UUID) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.Get(&token, `SELECT `+apiTokenColumns+` FROM api_tokens WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetActiveByUser returns the user's tokens that are neither revoked nor expired, newest first
+func (r *APITokenRepository) GetActiveByUser(userID uuid.UUID) ([]models.APIToken, error) {
+	tokens := []models.APIToken{}
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+		ORDER BY created_at DESC`
+	err := r.db.Select(&tokens, query, userID, time.Now())
+	return tokens, err
+}
+
+func (r *APITokenRepository) CountActiveByUser(userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.Get(&count, `SELECT COUNT(*) FROM api_tokens WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)`,
+		userID, time.Now())
+	return count, err
+}
+
+// Revoke stops a token working immediately
+func (r *APITokenRepository) Revoke(id uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE api_tokens SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`, time.Now(), id)
+	return err
+}
+
+// AuthenticateAPIToken returns the token matching a presented one if it is still valid,
+// nil otherwise, and records where it was used from. Tokens of users who have to set up
+// required 2FA don't work until they have. It implements middleware.APITokenValidator.
+func (r *APITokenRepository) AuthenticateAPIToken(token, ip string) (*models.APIToken, error) {
+	var apiToken models.APIToken
+	now := time.Now()
+	query := `SELECT t.id, t.user_id, t.name, t.token_prefix, t.scopes, t.expires_at, t.last_used_at, t.last_used_ip, t.created_at, t.revoked_at
+		FROM api_tokens t JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND t.revoked_at IS NULL AND (t.expires_at IS NULL OR t.expires_at > $2)
+			AND (NOT u.two_factor_required OR u.totp_enabled_at IS NOT NULL)`
+	err := r.db.Get(&apiToken, query, hashToken(token), now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Like sessions' last_seen_at, only written when stale or used from somewhere new
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) >= lastSeenResolution || apiToken.LastUsedIP != ip {
+		if _, err := r.db.Exec(`UPDATE api_tokens SET last_used_at = $1, last_used_ip = $2 WHERE id = $3`, now, ip, apiToken.ID); err != nil {
+			return nil, err
+		}
+		apiToken.LastUsedAt = &now
+		apiToken.LastUsedIP = ip
+	}
+	return &apiToken, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/financial-tracker/backend/internal/models"
@@ -17,16 +18,18 @@ func NewBudgetRepository(db *sqlx.DB) *BudgetRepository {
 	return &BudgetRepository{db: db}
 }
 
+const budgetColumns = `id, user_id, category, amount, budget_month, budget_year, account_id, credit_card_id, household_id, created_at, updated_at`
+
 func (r *BudgetRepository) Create(budget *models.Budget) error {
 	budget.ID = uuid.New()
 	budget.CreatedAt = time.Now()
 	budget.UpdatedAt = time.Now()
 
 	query := `
-		INSERT INTO budgets (id, user_id, category, amount, budget_month, budget_year, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO budgets (id, user_id, category, amount, budget_month, budget_year, account_id, credit_card_id, household_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
-	_, err := r.db.Exec(query, budget.ID, budget.UserID, budget.Category, budget.Amount, budget.BudgetMonth, budget.BudgetYear, budget.CreatedAt, budget.UpdatedAt)
+	_, err := r.db.Exec(query, budget.ID, budget.UserID, budget.Category, budget.Amount, budget.BudgetMonth, budget.BudgetYear, budget.AccountID, budget.CreditCardID, budget.HouseholdID, budget.CreatedAt, budget.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create budget: %w", err)
 	}
@@ -34,9 +37,10 @@ func (r *BudgetRepository) Create(budget *models.Budget) error {
 	return nil
 }
 
+// GetByUserID returns the user's budgets, including budgets shared into their households
 func (r *BudgetRepository) GetByUserID(userID uuid.UUID) ([]models.Budget, error) {
 	var budgets []models.Budget
-	query := `SELECT id, user_id, category, amount, budget_month, budget_year, created_at, updated_at FROM budgets WHERE user_id = $1 ORDER BY budget_year DESC, budget_month DESC, category ASC`
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE ` + visibleToUser + ` ORDER BY budget_year DESC, budget_month DESC, category ASC, created_at ASC`
 	err := r.db.Select(&budgets, query, userID)
 	if err != nil {
 		return nil, err
@@ -44,12 +48,12 @@ func (r *BudgetRepository) GetByUserID(userID uuid.UUID) ([]models.Budget, error
 	return budgets, nil
 }
 
-// GetByMonthYear returns budgets for specific month/year
+// GetByMonthYear returns budgets for specific month/year, including budgets shared into the user's households
 func (r *BudgetRepository) GetByMonthYear(userID uuid.UUID, month, year int) ([]models.Budget, error) {
 	var budgets []models.Budget
-	query := `SELECT id, user_id, category, amount, budget_month, budget_year, created_at, updated_at 
-		FROM budgets WHERE user_id = $1 AND budget_month = $2 AND budget_year = $3 
-		ORDER BY category ASC`
+	query := `SELECT ` + budgetColumns + `
+		FROM budgets WHERE ` + visibleToUser + ` AND budget_month = $2 AND budget_year = $3 
+		ORDER BY category ASC, created_at ASC`
 	err := r.db.Select(&budgets, query, userID, month, year)
 	if err != nil {
 		return nil, err
@@ -57,9 +61,55 @@ func (r *BudgetRepository) GetByMonthYear(userID uuid.UUID, month, year int) ([]
 	return budgets, nil
 }
 
+// budgetSpentCondition matches expense transactions counted against budget b.
+// Account-scoped budgets include the account's pockets; unscoped budgets count every transaction in the category.
+// Household budgets count the spending of every member of the household.
+const budgetSpentCondition = `(t.user_id = b.user_id OR t.user_id IN (SELECT hm.user_id FROM household_members hm WHERE hm.household_id = b.household_id))
+	AND t.type = 'expense' AND t.category = b.category AND t.linked_transaction_id IS NULL
+	AND (b.account_id IS NULL OR t.account_id IN (SELECT a.id FROM accounts a WHERE a.id = b.account_id OR a.parent_account_id = b.account_id))
+	AND (b.credit_card_id IS NULL OR t.credit_card_id = b.credit_card_id)`
+
+// GetProgressByMonthYear returns budgets for a month, including household ones, with the spending of matching transactions
+func (r *BudgetRepository) GetProgressByMonthYear(userID uuid.UUID, month, year int) ([]models.BudgetProgress, error) {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	progress := []models.BudgetProgress{}
+	query := `SELECT b.id, b.user_id, b.category, b.amount, b.budget_month, b.budget_year, b.account_id, b.credit_card_id, b.household_id, b.created_at, b.updated_at,
+			COALESCE(s.spent, 0) AS spent
+		FROM budgets b
+		LEFT JOIN LATERAL (
+			SELECT SUM(t.amount) AS spent FROM transactions t
+			WHERE ` + budgetSpentCondition + ` AND t.transaction_date >= $4 AND t.transaction_date < $5
+		) s ON true
+		WHERE b.id IN (SELECT id FROM budgets WHERE ` + visibleToUser + `) AND b.budget_month = $2 AND b.budget_year = $3
+		ORDER BY b.category ASC, b.created_at ASC`
+	if err := r.db.Select(&progress, query, userID, month, year, start, end); err != nil {
+		return nil, err
+	}
+
+	for i := range progress {
+		progress[i].Remaining = progress[i].Amount - progress[i].Spent
+		if progress[i].Amount > 0 {
+			progress[i].PercentUsed = progress[i].Spent / progress[i].Amount * 100
+		}
+	}
+	return progress, nil
+}
+
+// GetSpent returns the spending matched by a budget's category and scope in [start, end)
+func (r *BudgetRepository) GetSpent(budgetID uuid.UUID, start, end time.Time) (float64, error) {
+	var spent float64
+	query := `SELECT COALESCE(SUM(t.amount), 0) FROM budgets b
+		JOIN transactions t ON ` + budgetSpentCondition + `
+		WHERE b.id = $1 AND t.transaction_date >= $2 AND t.transaction_date < $3`
+	err := r.db.Get(&spent, query, budgetID, start, end)
+	return spent, err
+}
+
 func (r *BudgetRepository) GetByID(id uuid.UUID) (*models.Budget, error) {
 	var budget models.Budget
-	query := `SELECT id, user_id, category, amount, budget_month, budget_year, created_at, updated_at FROM budgets WHERE id = $1`
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE id = $1`
 	err := r.db.Get(&budget, query, id)
 	if err != nil {
 		return nil, err
@@ -69,8 +119,16 @@ func (r *BudgetRepository) GetByID(id uuid.UUID) (*models.Budget, error) {
 
 func (r *BudgetRepository) Update(budget *models.Budget) error {
 	budget.UpdatedAt = time.Now()
-	query := `UPDATE budgets SET category = $1, amount = $2, budget_month = $3, budget_year = $4, updated_at = $5 WHERE id = $6`
-	_, err := r.db.Exec(query, budget.Category, budget.Amount, budget.BudgetMonth, budget.BudgetYear, budget.UpdatedAt, budget.ID)
+	query := `UPDATE budgets SET category = $1, amount = $2, budget_month = $3, budget_year = $4, account_id = $5, credit_card_id = $6, updated_at = $7 WHERE id = $8`
+	_, err := r.db.Exec(query, budget.Category, budget.Amount, budget.BudgetMonth, budget.BudgetYear, budget.AccountID, budget.CreditCardID, budget.UpdatedAt, budget.ID)
+	return err
+}
+
+// SetHousehold shares the budget into a household, or makes it personal again when householdID is nil
+func (r *BudgetRepository) SetHousehold(budget *models.Budget, householdID *uuid.UUID) error {
+	budget.HouseholdID = householdID
+	budget.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`UPDATE budgets SET household_id = $1, updated_at = $2 WHERE id = $3`, budget.HouseholdID, budget.UpdatedAt, budget.ID)
 	return err
 }
 
@@ -80,29 +138,179 @@ func (r *BudgetRepository) Delete(id uuid.UUID) error {
 	return err
 }
 
-// CopyFromMonth copies all budgets from one month to another
-func (r *BudgetRepository) CopyFromMonth(userID uuid.UUID, fromMonth, fromYear, toMonth, toYear int) (int, error) {
-	// Get budgets from source month
-	sourceBudgets, err := r.GetByMonthYear(userID, fromMonth, fromYear)
+// CopyFromMonth copies all of the user's own budgets from one month to another, keeping their household.
+// Existing target budgets are skipped or overwritten depending on opts.Mode,
+// and every source budget is reported back as created, overwritten, skipped or failed.
+func (r *BudgetRepository) CopyFromMonth(userID uuid.UUID, opts models.BudgetCopyOptions) (*models.BudgetCopyResult, error) {
+	if opts.Mode == "" {
+		opts.Mode = models.BudgetCopyModeSkip
+	}
+	if opts.AmountSource == "" {
+		opts.AmountSource = models.BudgetAmountSourceBudget
+	}
+
+	sourceBudgets, err := r.getOwnByMonthYear(userID, opts.FromMonth, opts.FromYear)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	targetBudgets, err := r.getOwnByMonthYear(userID, opts.ToMonth, opts.ToYear)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]models.Budget, len(targetBudgets))
+	for _, b := range targetBudgets {
+		existing[budgetKey(b)] = b
+	}
+
+	result := &models.BudgetCopyResult{
+		Created:     []models.Budget{},
+		Overwritten: []models.Budget{},
+		Skipped:     []models.Budget{},
+		Failed:      []models.BudgetCopyFailure{},
 	}
 
-	count := 0
 	for _, sb := range sourceBudgets {
+		amount, err := r.sourceAmount(sb, opts.AmountSource)
+		if err != nil {
+			result.Failed = append(result.Failed, models.BudgetCopyFailure{Category: sb.Category, Error: err.Error()})
+			continue
+		}
+		amount = roundAmount(amount * (1 + opts.AdjustPercent/100))
+		if amount <= 0 {
+			result.Failed = append(result.Failed, models.BudgetCopyFailure{Category: sb.Category, Error: "calculated amount is not positive"})
+			continue
+		}
+
+		if current, ok := existing[budgetKey(sb)]; ok {
+			if opts.Mode != models.BudgetCopyModeOverwrite {
+				result.Skipped = append(result.Skipped, current)
+				continue
+			}
+			current.Amount = amount
+			if err := r.Update(&current); err != nil {
+				result.Failed = append(result.Failed, models.BudgetCopyFailure{Category: sb.Category, Error: err.Error()})
+				continue
+			}
+			result.Overwritten = append(result.Overwritten, current)
+			continue
+		}
+
 		newBudget := models.Budget{
-			UserID:      userID,
-			Category:    sb.Category,
-			Amount:      sb.Amount,
-			BudgetMonth: toMonth,
-			BudgetYear:  toYear,
+			UserID:       userID,
+			Category:     sb.Category,
+			Amount:       amount,
+			BudgetMonth:  opts.ToMonth,
+			BudgetYear:   opts.ToYear,
+			AccountID:    sb.AccountID,
+			CreditCardID: sb.CreditCardID,
+			HouseholdID:  sb.HouseholdID,
 		}
-		// Try to create, skip if duplicate
-		err := r.Create(&newBudget)
-		if err == nil {
-			count++
+		if err := r.Create(&newBudget); err != nil {
+			result.Failed = append(result.Failed, models.BudgetCopyFailure{Category: sb.Category, Error: err.Error()})
+			continue
 		}
+		result.Created = append(result.Created, newBudget)
+	}
+
+	return result, nil
+}
+
+// getOwnByMonthYear returns the budgets the user created for a month, leaving out
+// household budgets of other members
+func (r *BudgetRepository) getOwnByMonthYear(userID uuid.UUID, month, year int) ([]models.Budget, error) {
+	var budgets []models.Budget
+	query := `SELECT ` + budgetColumns + `
+		FROM budgets WHERE user_id = $1 AND budget_month = $2 AND budget_year = $3
+		ORDER BY category ASC, created_at ASC`
+	err := r.db.Select(&budgets, query, userID, month, year)
+	return budgets, err
+}
+
+// sourceAmount returns the amount a copied budget starts from, before adjustment.
+// Budgets without any recorded spending keep the budgeted amount.
+func (r *BudgetRepository) sourceAmount(source models.Budget, amountSource models.BudgetAmountSource) (float64, error) {
+	sourceStart := time.Date(source.BudgetYear, time.Month(source.BudgetMonth), 1, 0, 0, 0, 0, time.UTC)
+	sourceEnd := sourceStart.AddDate(0, 1, 0)
+
+	var spent float64
+	var err error
+	switch amountSource {
+	case models.BudgetAmountSourceLastMonthActual:
+		spent, err = r.GetSpent(source.ID, sourceStart, sourceEnd)
+	case models.BudgetAmountSourceThreeMonthAverage:
+		spent, err = r.GetSpent(source.ID, sourceStart.AddDate(0, -2, 0), sourceEnd)
+		spent = spent / 3
+	default:
+		return source.Amount, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if spent > 0 {
+		return spent, nil
 	}
+	return source.Amount, nil
+}
+
+// budgetKey identifies a budget within a month: category plus its optional account/card scope
+func budgetKey(b models.Budget) string {
+	key := b.Category
+	if b.AccountID != nil {
+		key += "|account:" + b.AccountID.String()
+	}
+	if b.CreditCardID != nil {
+		key += "|card:" + b.CreditCardID.String()
+	}
+	return key
+}
+
+// Rollover settings
+
+func (r *BudgetRepository) GetRolloverSetting(userID uuid.UUID) (*models.BudgetRolloverSetting, error) {
+	var setting models.BudgetRolloverSetting
+	query := `SELECT user_id, enabled, mode, adjust_percent, amount_source, last_run_month, last_run_year, created_at, updated_at
+		FROM budget_rollover_settings WHERE user_id = $1`
+	err := r.db.Get(&setting, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *BudgetRepository) UpsertRolloverSetting(setting *models.BudgetRolloverSetting) error {
+	now := time.Now()
+	query := `
+		INSERT INTO budget_rollover_settings (user_id, enabled, mode, adjust_percent, amount_source, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (user_id)
+		DO UPDATE SET enabled = $2, mode = $3, adjust_percent = $4, amount_source = $5, updated_at = $6
+		RETURNING created_at, updated_at
+	`
+	return r.db.QueryRow(query, setting.UserID, setting.Enabled, setting.Mode, setting.AdjustPercent, setting.AmountSource, now).
+		Scan(&setting.CreatedAt, &setting.UpdatedAt)
+}
+
+// GetPendingRollovers returns enabled settings that haven't run for the given month yet
+func (r *BudgetRepository) GetPendingRollovers(month, year int) ([]models.BudgetRolloverSetting, error) {
+	var settings []models.BudgetRolloverSetting
+	query := `SELECT user_id, enabled, mode, adjust_percent, amount_source, last_run_month, last_run_year, created_at, updated_at
+		FROM budget_rollover_settings
+		WHERE enabled = true AND (last_run_year IS NULL OR last_run_month IS NULL OR last_run_year <> $2 OR last_run_month <> $1)`
+	err := r.db.Select(&settings, query, month, year)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *BudgetRepository) MarkRolloverRun(userID uuid.UUID, month, year int) error {
+	query := `UPDATE budget_rollover_settings SET last_run_month = $1, last_run_year = $2, updated_at = $3 WHERE user_id = $4`
+	_, err := r.db.Exec(query, month, year, time.Now(), userID)
+	return err
+}
 
-	return count, nil
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
 }
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ContactRepository struct {
+	db *sqlx.DB
+}
+
+func NewContactRepository(db *sqlx.DB) *ContactRepository {
+	return &ContactRepository{db: db}
+}
+
+// contactColumns includes the outstanding totals of the contact's open IOUs
+const contactColumns = `c.id, c.user_id, c.name, c.phone, c.email, c.notes, c.share_token, c.created_at, c.updated_at,
+	COALESCE(b.owed_to_me, 0) AS owed_to_me, COALESCE(b.i_owe, 0) AS i_owe,
+	COALESCE(b.owed_to_me, 0) - COALESCE(b.i_owe, 0) AS balance`
+
+const contactBalancesJoin = `
+	LEFT JOIN (
+		SELECT i.contact_id,
+			SUM(i.amount - ` + iouSettledSubquery + `) FILTER (WHERE i.direction = 'owed_to_me') AS owed_to_me,
+			SUM(i.amount - ` + iouSettledSubquery + `) FILTER (WHERE i.direction = 'i_owe') AS i_owe
+		FROM ious i
+		WHERE i.status = 'open'
+		GROUP BY i.contact_id
+	) b ON b.contact_id = c.id`
+
+func (r *ContactRepository) Create(contact *models.Contact) error {
+	contact.ID = uuid.New()
+	contact.CreatedAt = time.Now()
+	contact.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO contacts (id, user_id, name, phone, email, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.Exec(query, contact.ID, contact.UserID, contact.Name, contact.Phone, contact.Email, contact.Notes, contact.CreatedAt, contact.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create contact: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID returns the user's contacts with their outstanding balances, largest first
+func (r *ContactRepository) GetByUserID(userID uuid.UUID) ([]models.Contact, error) {
+	contacts := []models.Contact{}
+	query := `SELECT ` + contactColumns + ` FROM contacts c` + contactBalancesJoin + `
+		WHERE c.user_id = $1 ORDER BY ABS(COALESCE(b.owed_to_me, 0) - COALESCE(b.i_owe, 0)) DESC, c.name ASC`
+	err := r.db.Select(&contacts, query, userID)
+	return contacts, err
+}
+
+func (r *ContactRepository) GetByID(id uuid.UUID) (*models.Contact, error) {
+	var contact models.Contact
+	query := `SELECT ` + contactColumns + ` FROM contacts c` + contactBalancesJoin + ` WHERE c.id = $1`
+	if err := r.db.Get(&contact, query, id); err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (r *ContactRepository) GetByShareToken(token string) (*models.Contact, error) {
+	var contact models.Contact
+	query := `SELECT ` + contactColumns + ` FROM contacts c` + contactBalancesJoin + ` WHERE c.share_token = $1`
+	if err := r.db.Get(&contact, query, token); err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (r *ContactRepository) Update(contact *models.Contact) error {
+	contact.UpdatedAt = time.Now()
+	query := `UPDATE contacts SET name = $1, phone = $2, email = $3, notes = $4, updated_at = $5 WHERE id = $6`
+	_, err := r.db.Exec(query, contact.Name, contact.Phone, contact.Email, contact.Notes, contact.UpdatedAt, contact.ID)
+	return err
+}
+
+// Delete removes a contact with all their IOUs
+func (r *ContactRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM contacts WHERE id = $1`, id)
+	return err
+}
+
+// SetShareToken gives the contact a new random share token, replacing any previous one
+func (r *ContactRepository) SetShareToken(contact *models.Contact) error {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	if _, err := r.db.Exec(`UPDATE contacts SET share_token = $1, updated_at = $2 WHERE id = $3`, token, time.Now(), contact.ID); err != nil {
+		return err
+	}
+	contact.ShareToken = &token
+	return nil
+}
+
+// ClearShareToken revokes the contact's share link
+func (r *ContactRepository) ClearShareToken(contact *models.Contact) error {
+	_, err := r.db.Exec(`UPDATE contacts SET share_token = NULL, updated_at = $1 WHERE id = $2`, time.Now(), contact.ID)
+	contact.ShareToken = nil
+	return err
+}
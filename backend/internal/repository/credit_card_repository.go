@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/financial-tracker/backend/internal/billing"
 	"github.com/financial-tracker/backend/internal/models"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -17,16 +18,21 @@ func NewCreditCardRepository(db *sqlx.DB) *CreditCardRepository {
 	return &CreditCardRepository{db: db}
 }
 
+const creditCardColumns = `id, user_id, card_name, last_four_digits, credit_limit, current_balance, billing_date, payment_due_date, reminder_days_before, utilization_alert_percent,
+	interest_rate, late_fee, annual_fee, annual_fee_month, min_payment_percent, min_payment_floor, household_id, created_at, updated_at`
+
 func (r *CreditCardRepository) Create(card *models.CreditCard) error {
 	card.ID = uuid.New()
 	card.CreatedAt = time.Now()
 	card.UpdatedAt = time.Now()
 
 	query := `
-		INSERT INTO credit_cards (id, user_id, card_name, last_four_digits, credit_limit, current_balance, billing_date, payment_due_date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO credit_cards (id, user_id, card_name, last_four_digits, credit_limit, current_balance, billing_date, payment_due_date, reminder_days_before, utilization_alert_percent,
+			interest_rate, late_fee, annual_fee, annual_fee_month, min_payment_percent, min_payment_floor, household_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 	`
-	_, err := r.db.Exec(query, card.ID, card.UserID, card.CardName, card.LastFourDigits, card.CreditLimit, card.CurrentBalance, card.BillingDate, card.PaymentDueDate, card.CreatedAt, card.UpdatedAt)
+	_, err := r.db.Exec(query, card.ID, card.UserID, card.CardName, card.LastFourDigits, card.CreditLimit, card.CurrentBalance, card.BillingDate, card.PaymentDueDate, card.ReminderDaysBefore, card.UtilizationAlertPercent,
+		card.InterestRate, card.LateFee, card.AnnualFee, card.AnnualFeeMonth, card.MinPaymentPercent, card.MinPaymentFloor, card.HouseholdID, card.CreatedAt, card.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create credit card: %w", err)
 	}
@@ -34,9 +40,10 @@ func (r *CreditCardRepository) Create(card *models.CreditCard) error {
 	return nil
 }
 
+// GetByUserID returns the user's cards, including cards shared into their households
 func (r *CreditCardRepository) GetByUserID(userID uuid.UUID) ([]models.CreditCard, error) {
 	var cards []models.CreditCard
-	query := `SELECT id, user_id, card_name, last_four_digits, credit_limit, current_balance, billing_date, payment_due_date, created_at, updated_at FROM credit_cards WHERE user_id = $1 ORDER BY created_at DESC`
+	query := `SELECT ` + creditCardColumns + ` FROM credit_cards WHERE ` + visibleToUser + ` ORDER BY created_at DESC`
 	err := r.db.Select(&cards, query, userID)
 	if err != nil {
 		return nil, err
@@ -44,9 +51,33 @@ func (r *CreditCardRepository) GetByUserID(userID uuid.UUID) ([]models.CreditCar
 	return cards, nil
 }
 
+// GetAll returns every user's cards, for background jobs
+func (r *CreditCardRepository) GetAll() ([]models.CreditCard, error) {
+	var cards []models.CreditCard
+	query := `SELECT ` + creditCardColumns + ` FROM credit_cards ORDER BY user_id, created_at`
+	err := r.db.Select(&cards, query)
+	return cards, err
+}
+
+// GetViewerIDs returns the users who can see the card: its owner and, when it is shared,
+// the members of its household
+func (r *CreditCardRepository) GetViewerIDs(card *models.CreditCard) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{card.UserID}
+	if card.HouseholdID == nil {
+		return ids, nil
+	}
+
+	var members []uuid.UUID
+	query := `SELECT user_id FROM household_members WHERE household_id = $1 AND user_id <> $2 ORDER BY joined_at`
+	if err := r.db.Select(&members, query, *card.HouseholdID, card.UserID); err != nil {
+		return nil, err
+	}
+	return append(ids, members...), nil
+}
+
 func (r *CreditCardRepository) GetByID(id uuid.UUID) (*models.CreditCard, error) {
 	var card models.CreditCard
-	query := `SELECT id, user_id, card_name, last_four_digits, credit_limit, current_balance, billing_date, payment_due_date, created_at, updated_at FROM credit_cards WHERE id = $1`
+	query := `SELECT ` + creditCardColumns + ` FROM credit_cards WHERE id = $1`
 	err := r.db.Get(&card, query, id)
 	if err != nil {
 		return nil, err
@@ -57,8 +88,10 @@ func (r *CreditCardRepository) GetByID(id uuid.UUID) (*models.CreditCard, error)
 func (r *CreditCardRepository) Update(card *models.CreditCard) error {
 	card.UpdatedAt = time.Now()
 	// Don't update current_balance - it should be calculated from transactions
-	query := `UPDATE credit_cards SET card_name = $1, credit_limit = $2, billing_date = $3, payment_due_date = $4, updated_at = $5 WHERE id = $6`
-	_, err := r.db.Exec(query, card.CardName, card.CreditLimit, card.BillingDate, card.PaymentDueDate, card.UpdatedAt, card.ID)
+	query := `UPDATE credit_cards SET card_name = $1, credit_limit = $2, billing_date = $3, payment_due_date = $4, reminder_days_before = $5, utilization_alert_percent = $6,
+		interest_rate = $7, late_fee = $8, annual_fee = $9, annual_fee_month = $10, min_payment_percent = $11, min_payment_floor = $12, updated_at = $13 WHERE id = $14`
+	_, err := r.db.Exec(query, card.CardName, card.CreditLimit, card.BillingDate, card.PaymentDueDate, card.ReminderDaysBefore, card.UtilizationAlertPercent,
+		card.InterestRate, card.LateFee, card.AnnualFee, card.AnnualFeeMonth, card.MinPaymentPercent, card.MinPaymentFloor, card.UpdatedAt, card.ID)
 	return err
 }
 
@@ -68,8 +101,138 @@ func (r *CreditCardRepository) Delete(id uuid.UUID) error {
 	return err
 }
 
+// SetHousehold shares the card into a household, or makes it personal again when householdID is nil
+func (r *CreditCardRepository) SetHousehold(card *models.CreditCard, householdID *uuid.UUID) error {
+	card.HouseholdID = householdID
+	card.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`UPDATE credit_cards SET household_id = $1, updated_at = $2 WHERE id = $3`, card.HouseholdID, card.UpdatedAt, card.ID)
+	return err
+}
+
 func (r *CreditCardRepository) UpdateBalance(id uuid.UUID, amount float64) error {
 	query := `UPDATE credit_cards SET current_balance = current_balance + $1, updated_at = $2 WHERE id = $3`
 	_, err := r.db.Exec(query, amount, time.Now(), id)
 	return err
-}
\ No newline at end of file
+}
+
+// GetBalanceBefore returns the card balance built up by transactions dated before t
+func (r *CreditCardRepository) GetBalanceBefore(id uuid.UUID, t time.Time) (float64, error) {
+	var balance float64
+	query := `SELECT COALESCE(SUM(CASE WHEN type = 'expense' THEN amount WHEN type = 'income' THEN -amount ELSE 0 END), 0)
+		FROM transactions WHERE credit_card_id = $1 AND transaction_date < $2`
+	err := r.db.Get(&balance, query, id, t)
+	return balance, err
+}
+
+// GetStatement computes the statement of a card for one billing cycle.
+// A cycle that hasn't closed yet is returned with IsClosed=false and its charges so far.
+func (r *CreditCardRepository) GetStatement(card *models.CreditCard, cycle billing.Cycle, withTransactions bool) (*models.CreditCardStatement, error) {
+	stmt := &models.CreditCardStatement{
+		CreditCardID:  card.ID,
+		PeriodStart:   cycle.Start,
+		StatementDate: cycle.Close,
+		DueDate:       cycle.Due,
+		IsClosed:      billing.Day(time.Now()).After(cycle.Close),
+	}
+
+	opening, err := r.GetBalanceBefore(card.ID, cycle.Start)
+	if err != nil {
+		return nil, err
+	}
+	stmt.OpeningBalance = opening
+
+	query := `SELECT
+			COALESCE(SUM(amount) FILTER (WHERE type = 'expense' AND transaction_date >= $2 AND transaction_date < $3), 0) AS charges,
+			COALESCE(SUM(amount) FILTER (WHERE type = 'income' AND transaction_date >= $2 AND transaction_date < $3), 0) AS credits,
+			COALESCE(SUM(amount) FILTER (WHERE type = 'income' AND transaction_date >= $3 AND transaction_date < $4), 0) AS paid_after_close
+		FROM transactions WHERE credit_card_id = $1`
+	next := cycle.Next()
+	err = r.db.QueryRow(query, card.ID, cycle.Start, cycle.End(), next.End()).Scan(&stmt.Charges, &stmt.Credits, &stmt.PaidAfterClose)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.StatementBalance = stmt.OpeningBalance + stmt.Charges - stmt.Credits
+	stmt.Outstanding = stmt.StatementBalance - stmt.PaidAfterClose
+	if stmt.Outstanding < 0 {
+		stmt.Outstanding = 0
+	}
+	stmt.MinimumPayment = card.Terms().MinimumPayment(stmt.StatementBalance)
+
+	if withTransactions {
+		stmt.Transactions = []models.Transaction{}
+		query := `SELECT ` + transactionColumns + `
+			FROM transactions
+			WHERE credit_card_id = $1 AND transaction_date >= $2 AND transaction_date < $3
+			ORDER BY transaction_date ASC, created_at ASC`
+		if err := r.db.Select(&stmt.Transactions, query, card.ID, cycle.Start, cycle.End()); err != nil {
+			return nil, err
+		}
+	}
+
+	return stmt, nil
+}
+
+// GetCreditsBetween returns payments and refunds on the card dated in [start, end)
+func (r *CreditCardRepository) GetCreditsBetween(id uuid.UUID, start, end time.Time) (float64, error) {
+	var credits float64
+	query := `SELECT COALESCE(SUM(amount), 0) FROM transactions
+		WHERE credit_card_id = $1 AND type = 'income' AND transaction_date >= $2 AND transaction_date < $3`
+	err := r.db.Get(&credits, query, id, start, end)
+	return credits, err
+}
+
+// CloseStatement records the monthly close of the statement issued on statementDate and
+// posts its charges to the card. It does nothing and returns false if that statement was
+// already closed, so the close can safely be retried.
+func (r *CreditCardRepository) CloseStatement(card *models.CreditCard, statementDate time.Time, charges []models.Transaction) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	amounts := map[string]float64{}
+	for _, t := range charges {
+		amounts[t.Subcategory] += t.Amount
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO card_statement_closings (credit_card_id, statement_date, interest, late_fee, annual_fee, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (credit_card_id, statement_date) DO NOTHING`,
+		card.ID, statementDate, amounts[models.SubcategoryInterest], amounts[models.SubcategoryLateFee], amounts[models.SubcategoryAnnualFee], time.Now())
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	now := time.Now()
+	var total float64
+	for i := range charges {
+		t := &charges[i]
+		t.ID = uuid.New()
+		t.CreatedAt, t.UpdatedAt = now, now
+		_, err := tx.Exec(`
+			INSERT INTO transactions (id, user_id, credit_card_id, type, category, subcategory, amount, description, transaction_date, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			t.ID, t.UserID, t.CreditCardID, t.Type, t.Category, t.Subcategory, t.Amount, t.Description, t.TransactionDate, t.CreatedAt, t.UpdatedAt)
+		if err != nil {
+			return false, fmt.Errorf("failed to create transaction: %w", err)
+		}
+		total += t.Amount
+	}
+
+	if total > 0 {
+		if _, err := tx.Exec(`UPDATE credit_cards SET current_balance = current_balance + $1, updated_at = $2 WHERE id = $3`, total, now, card.ID); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
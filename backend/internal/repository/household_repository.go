@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type HouseholdRepository struct {
+	db *sqlx.DB
+}
+
+func NewHouseholdRepository(db *sqlx.DB) *HouseholdRepository {
+	return &HouseholdRepository{db: db}
+}
+
+// visibleToUser matches accounts, budgets or cards owned by user $1 or shared into one
+// of their households. Columns are unqualified, so use it on a single-table query or subquery.
+const visibleToUser = `(user_id = $1 OR household_id IN (SELECT household_id FROM household_members WHERE user_id = $1))`
+
+const householdInvitationColumns = `i.id, i.household_id, i.email, i.role, i.status, i.invited_by, i.created_at, i.responded_at`
+
+// Create creates a household with its creator as owner
+func (r *HouseholdRepository) Create(household *models.Household) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	household.ID = uuid.New()
+	household.CreatedAt = time.Now()
+	household.UpdatedAt = time.Now()
+	household.MyRole = models.HouseholdRoleOwner
+	_, err = tx.Exec(`INSERT INTO households (id, name, created_by, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`,
+		household.ID, household.Name, household.CreatedBy, household.CreatedAt, household.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create household: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO household_members (household_id, user_id, role, joined_at) VALUES ($1, $2, $3, $4)`,
+		household.ID, household.CreatedBy, household.MyRole, household.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add household owner: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetByUserID returns the households the user belongs to with the user's role in each
+func (r *HouseholdRepository) GetByUserID(userID uuid.UUID) ([]models.Household, error) {
+	households := []models.Household{}
+	query := `SELECT h.id, h.name, h.created_by, h.created_at, h.updated_at, m.role AS my_role
+		FROM households h JOIN household_members m ON m.household_id = h.id
+		WHERE m.user_id = $1 ORDER BY h.created_at ASC`
+	err := r.db.Select(&households, query, userID)
+	return households, err
+}
+
+func (r *HouseholdRepository) GetByID(id uuid.UUID) (*models.Household, error) {
+	var household models.Household
+	query := `SELECT id, name, created_by, created_at, updated_at FROM households WHERE id = $1`
+	if err := r.db.Get(&household, query, id); err != nil {
+		return nil, err
+	}
+	return &household, nil
+}
+
+func (r *HouseholdRepository) Update(household *models.Household) error {
+	household.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`UPDATE households SET name = $1, updated_at = $2 WHERE id = $3`, household.Name, household.UpdatedAt, household.ID)
+	return err
+}
+
+// Delete removes a household. Shared accounts, budgets and cards go back to being
+// personal records of their owners.
+func (r *HouseholdRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM households WHERE id = $1`, id)
+	return err
+}
+
+// GetRolesByUser returns the user's role in each of their households, keyed by household
+func (r *HouseholdRepository) GetRolesByUser(userID uuid.UUID) (map[uuid.UUID]models.HouseholdRole, error) {
+	var rows []struct {
+		HouseholdID uuid.UUID            `db:"household_id"`
+		Role        models.HouseholdRole `db:"role"`
+	}
+	if err := r.db.Select(&rows, `SELECT household_id, role FROM household_members WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	roles := make(map[uuid.UUID]models.HouseholdRole, len(rows))
+	for _, row := range rows {
+		roles[row.HouseholdID] = row.Role
+	}
+	return roles, nil
+}
+
+func (r *HouseholdRepository) GetMembers(householdID uuid.UUID) ([]models.HouseholdMember, error) {
+	members := []models.HouseholdMember{}
+	query := `SELECT m.user_id, u.username, u.full_name, u.email, m.role, m.joined_at
+		FROM household_members m JOIN users u ON u.id = m.user_id
+		WHERE m.household_id = $1 ORDER BY m.joined_at ASC, u.full_name ASC`
+	err := r.db.Select(&members, query, householdID)
+	return members, err
+}
+
+func (r *HouseholdRepository) GetMember(householdID, userID uuid.UUID) (*models.HouseholdMember, error) {
+	var member models.HouseholdMember
+	query := `SELECT m.user_id, u.username, u.full_name, u.email, m.role, m.joined_at
+		FROM household_members m JOIN users u ON u.id = m.user_id
+		WHERE m.household_id = $1 AND m.user_id = $2`
+	if err := r.db.Get(&member, query, householdID, userID); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// CountOwners returns how many owners the household has, so the last one can't leave or be demoted
+func (r *HouseholdRepository) CountOwners(householdID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.Get(&count, `SELECT COUNT(*) FROM household_members WHERE household_id = $1 AND role = 'owner'`, householdID)
+	return count, err
+}
+
+func (r *HouseholdRepository) UpdateMemberRole(householdID, userID uuid.UUID, role models.HouseholdRole) error {
+	_, err := r.db.Exec(`UPDATE household_members SET role = $1 WHERE household_id = $2 AND user_id = $3`, role, householdID, userID)
+	return err
+}
+
+// RemoveMember takes a member out of the household. Whatever they shared into it goes
+// back to being personal, so the remaining members stop seeing it.
+func (r *HouseholdRepository) RemoveMember(householdID, userID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"accounts", "budgets", "credit_cards"} {
+		_, err := tx.Exec(`UPDATE `+table+` SET household_id = NULL, updated_at = $1 WHERE household_id = $2 AND user_id = $3`,
+			time.Now(), householdID, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM household_members WHERE household_id = $1 AND user_id = $2`, householdID, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Invitations
+
+func (r *HouseholdRepository) CreateInvitation(invitation *models.HouseholdInvitation) error {
+	invitation.ID = uuid.New()
+	invitation.Status = models.HouseholdInvitationPending
+	invitation.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO household_invitations (id, household_id, email, role, status, invited_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		invitation.ID, invitation.HouseholdID, invitation.Email, invitation.Role, invitation.Status, invitation.InvitedBy, invitation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+	return nil
+}
+
+// GetPendingInvitations returns the household's invitations that haven't been answered yet
+func (r *HouseholdRepository) GetPendingInvitations(householdID uuid.UUID) ([]models.HouseholdInvitation, error) {
+	invitations := []models.HouseholdInvitation{}
+	query := `SELECT ` + householdInvitationColumns + ` FROM household_invitations i
+		WHERE i.household_id = $1 AND i.status = 'pending' ORDER BY i.created_at ASC`
+	err := r.db.Select(&invitations, query, householdID)
+	return invitations, err
+}
+
+// GetPendingInvitationsByEmail returns the invitations waiting for an address, with the household names
+func (r *HouseholdRepository) GetPendingInvitationsByEmail(email string) ([]models.HouseholdInvitation, error) {
+	invitations := []models.HouseholdInvitation{}
+	query := `SELECT ` + householdInvitationColumns + `, h.name AS household_name
+		FROM household_invitations i JOIN households h ON h.id = i.household_id
+		WHERE LOWER(i.email) = LOWER($1) AND i.status = 'pending' ORDER BY i.created_at DESC`
+	err := r.db.Select(&invitations, query, email)
+	return invitations, err
+}
+
+// HasPendingInvitation reports whether the address already has an open invitation to the household
+func (r *HouseholdRepository) HasPendingInvitation(householdID uuid.UUID, email string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM household_invitations WHERE household_id = $1 AND LOWER(email) = LOWER($2) AND status = 'pending')`
+	err := r.db.Get(&exists, query, householdID, email)
+	return exists, err
+}
+
+func (r *HouseholdRepository) GetInvitation(id uuid.UUID) (*models.HouseholdInvitation, error) {
+	var invitation models.HouseholdInvitation
+	query := `SELECT ` + householdInvitationColumns + `, h.name AS household_name
+		FROM household_invitations i JOIN households h ON h.id = i.household_id
+		WHERE i.id = $1`
+	if err := r.db.Get(&invitation, query, id); err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// AcceptInvitation closes the invitation and adds the user to the household with the invited role
+func (r *HouseholdRepository) AcceptInvitation(invitation *models.HouseholdInvitation, userID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := respondToInvitation(tx, invitation, models.HouseholdInvitationAccepted, now); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO household_members (household_id, user_id, role, joined_at) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`,
+		invitation.HouseholdID, userID, invitation.Role, now)
+	if err != nil {
+		return fmt.Errorf("failed to add household member: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// CloseInvitation marks a pending invitation declined or revoked
+func (r *HouseholdRepository) CloseInvitation(invitation *models.HouseholdInvitation, status models.HouseholdInvitationStatus) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := respondToInvitation(tx, invitation, status, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// respondToInvitation moves a pending invitation to status. It fails if the invitation
+// was answered in the meantime, so it can't be both accepted and revoked.
+func respondToInvitation(tx *sqlx.Tx, invitation *models.HouseholdInvitation, status models.HouseholdInvitationStatus, now time.Time) error {
+	res, err := tx.Exec(`UPDATE household_invitations SET status = $1, responded_at = $2 WHERE id = $3 AND status = 'pending'`,
+		status, now, invitation.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("invitation is no longer pending")
+	}
+
+	invitation.Status = status
+	invitation.RespondedAt = &now
+	return nil
+}
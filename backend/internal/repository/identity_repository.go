@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrLoginStateInvalid is returned for unknown, expired or used sign-in states
+var ErrLoginStateInvalid = errors.New("invalid sign-in state")
+
+type IdentityRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdentityRepository(db *sqlx.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+const identityColumns = `id, user_id, provider, subject, email, created_at, last_login_at`
+
+// CreateLoginState remembers a sign-in sent to a provider and returns its state token
+func (r *IdentityRepository) CreateLoginState(provider, nonce, codeVerifier string, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	state := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	_, err := r.db.Exec(`INSERT INTO oidc_login_states (id, provider, state_hash, nonce, code_verifier, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		uuid.New(), provider, hashToken(state), nonce, codeVerifier, now.Add(ttl), now)
+	if err != nil {
+		return "", fmt.Errorf("failed to create sign-in state: %w", err)
+	}
+	return state, nil
+}
+
+// ConsumeLoginState uses up an open sign-in state of the provider, or returns ErrLoginStateInvalid
+func (r *IdentityRepository) ConsumeLoginState(provider, state string) (*models.OIDCLoginState, error) {
+	var loginState models.OIDCLoginState
+	now := time.Now()
+	err := r.db.Get(&loginState, `UPDATE oidc_login_states SET used_at = $1
+		WHERE state_hash = $2 AND provider = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING nonce, code_verifier`, now, hashToken(state), provider)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLoginStateInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loginState, nil
+}
+
+// RecordLogin returns the user the provider account is linked to and notes the sign-in.
+// It returns sql.ErrNoRows if the account isn't linked.
+func (r *IdentityRepository) RecordLogin(provider, subject, email string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.Get(&userID, `UPDATE user_identities SET last_login_at = $1, email = $2
+		WHERE provider = $3 AND subject = $4 RETURNING user_id`, time.Now(), email, provider, subject)
+	return userID, err
+}
+
+// Link connects a provider account to an existing user
+func (r *IdentityRepository) Link(userID uuid.UUID, provider, subject, email string) error {
+	now := time.Now()
+	_, err := r.db.Exec(`INSERT INTO user_identities (id, user_id, provider, subject, email, created_at, last_login_at) VALUES ($1, $2, $3, $4, $5, $6, $6)`,
+		uuid.New(), userID, provider, subject, email, now)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return nil
+}
+
+// CreateUser creates a user signing up through a provider, with their email already
+// verified by it, and links the provider account
+func (r *IdentityRepository) CreateUser(user *models.User, provider, subject string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	user.ID = uuid.New()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	user.EmailVerifiedAt = &now
+	_, err = tx.Exec(`
+		INSERT INTO users (id, email, username, password_hash, full_name, is_admin, email_verified_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		user.ID, user.Email, user.Username, user.PasswordHash, user.FullName, user.IsAdmin, user.EmailVerifiedAt, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO user_identities (id, user_id, provider, subject, email, created_at, last_login_at) VALUES ($1, $2, $3, $4, $5, $6, $6)`,
+		uuid.New(), user.ID, provider, subject, user.Email, now)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetByUser lists the provider accounts linked to the user
+func (r *IdentityRepository) GetByUser(userID uuid.UUID) ([]models.UserIdentity, error) {
+	identities := []models.UserIdentity{}
+	err := r.db.Select(&identities, `SELECT `+identityColumns+` FROM user_identities WHERE user_id = $1 ORDER BY created_at`, userID)
+	return identities, err
+}
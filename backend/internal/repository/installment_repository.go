@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/billing"
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type InstallmentRepository struct {
+	db *sqlx.DB
+}
+
+func NewInstallmentRepository(db *sqlx.DB) *InstallmentRepository {
+	return &InstallmentRepository{db: db}
+}
+
+const installmentPlanColumns = `p.id, p.user_id, p.credit_card_id, p.account_id, p.description, p.category, p.subcategory, p.principal, p.tenor_months,
+	p.interest_rate, p.admin_fee, p.purchase_date, p.status, p.created_at, p.updated_at,
+	(SELECT COUNT(*) FROM transactions t WHERE t.installment_plan_id = p.id) AS posted_count`
+
+func (r *InstallmentRepository) Create(plan *models.InstallmentPlan) error {
+	plan.ID = uuid.New()
+	plan.Status = models.InstallmentPlanStatusActive
+	plan.CreatedAt = time.Now()
+	plan.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO installment_plans (id, user_id, credit_card_id, account_id, description, category, subcategory, principal, tenor_months, interest_rate, admin_fee, purchase_date, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+	_, err := r.db.Exec(query, plan.ID, plan.UserID, plan.CreditCardID, plan.AccountID, plan.Description, plan.Category, plan.Subcategory, plan.Principal, plan.TenorMonths, plan.InterestRate, plan.AdminFee, plan.PurchaseDate, plan.Status, plan.CreatedAt, plan.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create installment plan: %w", err)
+	}
+
+	return nil
+}
+
+// GetByTarget returns the plans of a card or paylater account, newest purchase first, with their schedules filled in
+func (r *InstallmentRepository) GetByTarget(target models.InstallmentTarget) ([]models.InstallmentPlan, error) {
+	plans := []models.InstallmentPlan{}
+	query := `SELECT ` + installmentPlanColumns + ` FROM installment_plans p
+		WHERE (p.credit_card_id = $1 OR p.account_id = $2) ORDER BY p.purchase_date DESC, p.created_at DESC`
+	if err := r.db.Select(&plans, query, target.CreditCardID, target.AccountID); err != nil {
+		return nil, err
+	}
+	for i := range plans {
+		fillSchedule(target, &plans[i])
+	}
+	return plans, nil
+}
+
+// GetByID returns one plan of a card or paylater account with its schedule filled in
+func (r *InstallmentRepository) GetByID(target models.InstallmentTarget, id uuid.UUID) (*models.InstallmentPlan, error) {
+	var plan models.InstallmentPlan
+	query := `SELECT ` + installmentPlanColumns + ` FROM installment_plans p WHERE p.id = $1 AND (p.credit_card_id = $2 OR p.account_id = $3)`
+	if err := r.db.Get(&plan, query, id, target.CreditCardID, target.AccountID); err != nil {
+		return nil, err
+	}
+	fillSchedule(target, &plan)
+	return &plan, nil
+}
+
+// GetCardsWithActivePlans returns the IDs of cards that still have installments to post
+func (r *InstallmentRepository) GetCardsWithActivePlans() ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := `SELECT DISTINCT credit_card_id FROM installment_plans WHERE status = $1 AND credit_card_id IS NOT NULL`
+	err := r.db.Select(&ids, query, models.InstallmentPlanStatusActive)
+	return ids, err
+}
+
+// GetPaylaterAccountsWithActivePlans returns the IDs of paylater accounts that still have installments to post
+func (r *InstallmentRepository) GetPaylaterAccountsWithActivePlans() ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := `SELECT DISTINCT account_id FROM installment_plans WHERE status = $1 AND account_id IS NOT NULL`
+	err := r.db.Select(&ids, query, models.InstallmentPlanStatusActive)
+	return ids, err
+}
+
+// GetOutstandingPrincipal returns the installment principal that hasn't been billed yet
+func (r *InstallmentRepository) GetOutstandingPrincipal(target models.InstallmentTarget) (float64, error) {
+	plans, err := r.GetByTarget(target)
+	if err != nil {
+		return 0, err
+	}
+	var outstanding float64
+	for _, plan := range plans {
+		outstanding += plan.RemainingPrincipal
+	}
+	return roundAmount(outstanding), nil
+}
+
+// PostDue posts every installment of the plan whose statement date is on or before asOf
+// and hasn't been posted yet, adds them to the card or paylater balance and completes finished plans.
+// It returns the number of installments posted.
+func (r *InstallmentRepository) PostDue(plan *models.InstallmentPlan, asOf time.Time) (int, error) {
+	if plan.Status != models.InstallmentPlanStatusActive {
+		return 0, nil
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	asOf = billing.Day(asOf)
+	posted := 0
+	for _, inst := range plan.Schedule {
+		if inst.Date.After(asOf) {
+			break
+		}
+		posting := models.Transaction{
+			ID: uuid.New(), UserID: plan.UserID, CreditCardID: plan.CreditCardID, AccountID: plan.AccountID,
+			Type: models.TransactionTypeExpense, Category: plan.Category, Amount: inst.Amount,
+		}
+		number := inst.Number
+		res, err := tx.Exec(`
+			INSERT INTO transactions (id, user_id, credit_card_id, account_id, type, category, subcategory, amount, description, transaction_date, installment_plan_id, installment_number, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			ON CONFLICT (installment_plan_id, installment_number) WHERE installment_plan_id IS NOT NULL DO NOTHING`,
+			posting.ID, plan.UserID, plan.CreditCardID, plan.AccountID, models.TransactionTypeExpense, plan.Category, plan.Subcategory, inst.Amount,
+			fmt.Sprintf("%s (installment %d/%d)", plan.Description, inst.Number, plan.TenorMonths),
+			inst.Date, plan.ID, number, now, now)
+		if err != nil {
+			return 0, fmt.Errorf("failed to post installment: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		if err := addInstallmentDebt(tx, plan, inst.Amount); err != nil {
+			return 0, err
+		}
+		if err := recordRewardEarning(tx, &posting); err != nil {
+			return 0, err
+		}
+		posted++
+	}
+
+	if plan.PostedCount+posted >= plan.TenorMonths {
+		if _, err := tx.Exec(`UPDATE installment_plans SET status = $1, updated_at = $2 WHERE id = $3`, models.InstallmentPlanStatusCompleted, now, plan.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return posted, nil
+}
+
+// Delete removes a plan with its postings and takes the posted amounts off the card or paylater balance
+func (r *InstallmentRepository) Delete(plan *models.InstallmentPlan) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var posted float64
+	if err := tx.Get(&posted, `SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE installment_plan_id = $1`, plan.ID); err != nil {
+		return err
+	}
+	if err := addInstallmentDebt(tx, plan, -posted); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM transactions WHERE installment_plan_id = $1`, plan.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM installment_plans WHERE id = $1`, plan.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addInstallmentDebt adds amount to the debt of the plan's card or paylater account.
+// Both balances are amounts owed, so postings raise them.
+func addInstallmentDebt(tx *sqlx.Tx, plan *models.InstallmentPlan, amount float64) error {
+	if plan.CreditCardID != nil {
+		_, err := tx.Exec(`UPDATE credit_cards SET current_balance = current_balance + $1, updated_at = $2 WHERE id = $3`, amount, time.Now(), *plan.CreditCardID)
+		return err
+	}
+	_, err := tx.Exec(`UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3`, amount, time.Now(), *plan.AccountID)
+	return err
+}
+
+// fillSchedule computes the plan's schedule on the target's cycles and the amounts still to be billed
+func fillSchedule(target models.InstallmentTarget, plan *models.InstallmentPlan) {
+	first := billing.CycleFor(target.BillingDay, target.DueDay, plan.PurchaseDate)
+	plan.Schedule = billing.InstallmentSchedule(plan.Principal, plan.TenorMonths, plan.InterestRate, plan.AdminFee, first)
+	plan.RemainingPrincipal, plan.RemainingAmount, plan.NextPostingDate = 0, 0, nil
+
+	for _, inst := range plan.Schedule {
+		if inst.Number == 1 {
+			plan.MonthlyAmount = inst.Amount - inst.Fee
+		}
+		if inst.Number <= plan.PostedCount {
+			continue
+		}
+		if plan.NextPostingDate == nil {
+			date := inst.Date
+			plan.NextPostingDate = &date
+		}
+		plan.RemainingPrincipal += inst.Principal
+		plan.RemainingAmount += inst.Amount
+	}
+	plan.RemainingPrincipal = roundAmount(plan.RemainingPrincipal)
+	plan.RemainingAmount = roundAmount(plan.RemainingAmount)
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type IOURepository struct {
+	db *sqlx.DB
+}
+
+func NewIOURepository(db *sqlx.DB) *IOURepository {
+	return &IOURepository{db: db}
+}
+
+const iouSettledSubquery = `COALESCE((SELECT SUM(s.amount) FROM iou_settlements s WHERE s.iou_id = i.id), 0)`
+
+const iouColumns = `i.id, i.user_id, i.contact_id, c.name AS contact_name, i.direction, i.amount, i.description, i.iou_date,
+	i.due_date, i.transaction_id, i.status, i.created_at, i.updated_at,
+	` + iouSettledSubquery + ` AS settled,
+	i.amount - ` + iouSettledSubquery + ` AS outstanding`
+
+// IOUFilter narrows GetByUserID; zero values don't filter
+type IOUFilter struct {
+	ContactID *uuid.UUID
+	Status    models.IOUStatus
+}
+
+func (r *IOURepository) Create(iou *models.IOU) error {
+	iou.ID = uuid.New()
+	iou.Status = models.IOUStatusOpen
+	iou.Outstanding = iou.Amount
+	iou.CreatedAt = time.Now()
+	iou.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO ious (id, user_id, contact_id, direction, amount, description, iou_date, due_date, transaction_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	_, err := r.db.Exec(query, iou.ID, iou.UserID, iou.ContactID, iou.Direction, iou.Amount, iou.Description, iou.IOUDate, iou.DueDate, iou.TransactionID, iou.Status, iou.CreatedAt, iou.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create IOU: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID returns the user's IOUs, open ones first and then by date, newest first
+func (r *IOURepository) GetByUserID(userID uuid.UUID, filter IOUFilter) ([]models.IOU, error) {
+	ious := []models.IOU{}
+	query := `SELECT ` + iouColumns + ` FROM ious i JOIN contacts c ON c.id = i.contact_id WHERE i.user_id = $1`
+	args := []interface{}{userID}
+	if filter.ContactID != nil {
+		args = append(args, *filter.ContactID)
+		query += fmt.Sprintf(" AND i.contact_id = $%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND i.status = $%d", len(args))
+	}
+	query += ` ORDER BY i.status ASC, i.iou_date DESC, i.created_at DESC`
+
+	err := r.db.Select(&ious, query, args...)
+	return ious, err
+}
+
+// GetByID returns an IOU with its settlements
+func (r *IOURepository) GetByID(id uuid.UUID) (*models.IOU, error) {
+	var iou models.IOU
+	query := `SELECT ` + iouColumns + ` FROM ious i JOIN contacts c ON c.id = i.contact_id WHERE i.id = $1`
+	if err := r.db.Get(&iou, query, id); err != nil {
+		return nil, err
+	}
+
+	iou.Settlements = []models.IOUSettlement{}
+	query = `SELECT id, iou_id, amount, settlement_date, note, transaction_id, created_at
+		FROM iou_settlements WHERE iou_id = $1 ORDER BY settlement_date DESC, created_at DESC`
+	if err := r.db.Select(&iou.Settlements, query, id); err != nil {
+		return nil, err
+	}
+	return &iou, nil
+}
+
+// Update saves the IOU's amount, description and due date and reopens or settles it to match the new amount
+func (r *IOURepository) Update(iou *models.IOU) error {
+	iou.UpdatedAt = time.Now()
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE ious SET amount = $1, description = $2, due_date = $3, updated_at = $4 WHERE id = $5`
+	if _, err := tx.Exec(query, iou.Amount, iou.Description, iou.DueDate, iou.UpdatedAt, iou.ID); err != nil {
+		return err
+	}
+	if err := updateIOUStatus(tx, iou.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes an IOU with its settlements. Transactions posted by the
+// settlements stay on their accounts, no longer tied to the IOU.
+func (r *IOURepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM ious WHERE id = $1`, id)
+	return err
+}
+
+// CreateSettlement records a repayment of the IOU. When t is not nil the repayment is
+// also posted as that transaction on its account.
+func (r *IOURepository) CreateSettlement(iou *models.IOU, settlement *models.IOUSettlement, t *models.Transaction) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if t != nil {
+		t.ID = uuid.New()
+		t.IOUID = &iou.ID
+		t.CreatedAt, t.UpdatedAt = now, now
+		insert := `
+			INSERT INTO transactions (id, user_id, account_id, type, category, subcategory, amount, description, transaction_date, iou_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		`
+		if _, err := tx.Exec(insert, t.ID, t.UserID, t.AccountID, t.Type, t.Category, t.Subcategory, t.Amount, t.Description, t.TransactionDate, t.IOUID, t.CreatedAt, t.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		if err := applyBalanceEffect(tx, t, 1); err != nil {
+			return err
+		}
+		settlement.TransactionID = &t.ID
+	}
+
+	settlement.ID = uuid.New()
+	settlement.IOUID = iou.ID
+	settlement.CreatedAt = now
+	_, err = tx.Exec(`
+		INSERT INTO iou_settlements (id, iou_id, amount, settlement_date, note, transaction_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		settlement.ID, settlement.IOUID, settlement.Amount, settlement.SettlementDate, settlement.Note, settlement.TransactionID, settlement.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create IOU settlement: %w", err)
+	}
+
+	if err := updateIOUStatus(tx, iou.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteSettlement removes a settlement and reverses the transaction it posted, if any
+func (r *IOURepository) DeleteSettlement(settlement *models.IOUSettlement) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM iou_settlements WHERE id = $1`, settlement.ID); err != nil {
+		return err
+	}
+	if settlement.TransactionID != nil {
+		var t models.Transaction
+		if err := tx.Get(&t, `SELECT `+transactionColumns+` FROM transactions WHERE id = $1`, *settlement.TransactionID); err != nil {
+			return err
+		}
+		if err := applyBalanceEffect(tx, &t, -1); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM transactions WHERE id = $1`, t.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := updateIOUStatus(tx, settlement.IOUID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// updateIOUStatus marks an IOU settled once its settlements cover the amount, and open otherwise
+func updateIOUStatus(tx *sqlx.Tx, iouID uuid.UUID) error {
+	_, err := tx.Exec(`
+		UPDATE ious i SET updated_at = $2, status = CASE
+			WHEN i.amount - `+iouSettledSubquery+` <= 0 THEN $3
+			ELSE $4 END
+		WHERE i.id = $1`,
+		iouID, time.Now(), models.IOUStatusSettled, models.IOUStatusOpen)
+	return err
+}
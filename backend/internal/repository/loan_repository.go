@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoanRepository struct {
+	db *sqlx.DB
+}
+
+func NewLoanRepository(db *sqlx.DB) *LoanRepository {
+	return &LoanRepository{db: db}
+}
+
+const loanColumns = `l.id, l.user_id, l.name, l.kind, l.direction, l.counterparty, l.principal, l.interest_rate, l.method,
+	l.tenor_months, l.start_date, l.notes, l.status, l.created_at, l.updated_at,
+	COALESCE((SELECT SUM(p.principal) FROM loan_payments p WHERE p.loan_id = l.id), 0) AS principal_paid,
+	COALESCE((SELECT SUM(p.interest) FROM loan_payments p WHERE p.loan_id = l.id), 0) AS interest_paid`
+
+const loanPaymentColumns = `p.id, p.loan_id, p.transaction_id, t.account_id, p.amount, p.principal, p.interest, p.payment_date, t.description, p.created_at`
+
+func (r *LoanRepository) Create(loan *models.Loan) error {
+	loan.ID = uuid.New()
+	loan.Status = models.LoanStatusActive
+	loan.CreatedAt = time.Now()
+	loan.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO loans (id, user_id, name, kind, direction, counterparty, principal, interest_rate, method, tenor_months, start_date, notes, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+	_, err := r.db.Exec(query, loan.ID, loan.UserID, loan.Name, loan.Kind, loan.Direction, loan.Counterparty, loan.Principal, loan.InterestRate, loan.Method, loan.TenorMonths, loan.StartDate, loan.Notes, loan.Status, loan.CreatedAt, loan.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create loan: %w", err)
+	}
+
+	loan.FillSchedule()
+	return nil
+}
+
+// GetByUserID returns the user's loans, active ones first, without their schedules
+func (r *LoanRepository) GetByUserID(userID uuid.UUID) ([]models.Loan, error) {
+	loans := []models.Loan{}
+	query := `SELECT ` + loanColumns + ` FROM loans l WHERE l.user_id = $1 ORDER BY l.status ASC, l.start_date DESC, l.created_at DESC`
+	if err := r.db.Select(&loans, query, userID); err != nil {
+		return nil, err
+	}
+	for i := range loans {
+		loans[i].FillSchedule()
+		loans[i].Schedule = nil
+	}
+	return loans, nil
+}
+
+func (r *LoanRepository) GetByID(id uuid.UUID) (*models.Loan, error) {
+	var loan models.Loan
+	query := `SELECT ` + loanColumns + ` FROM loans l WHERE l.id = $1`
+	if err := r.db.Get(&loan, query, id); err != nil {
+		return nil, err
+	}
+	loan.FillSchedule()
+	return &loan, nil
+}
+
+func (r *LoanRepository) Update(loan *models.Loan) error {
+	loan.UpdatedAt = time.Now()
+	query := `UPDATE loans SET name = $1, kind = $2, counterparty = $3, notes = $4, updated_at = $5 WHERE id = $6`
+	_, err := r.db.Exec(query, loan.Name, loan.Kind, loan.Counterparty, loan.Notes, loan.UpdatedAt, loan.ID)
+	return err
+}
+
+// Delete removes a loan with its payment records. The account transactions of
+// the payments stay, since the money did move, but are no longer tied to the loan.
+func (r *LoanRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM loans WHERE id = $1`, id)
+	return err
+}
+
+func (r *LoanRepository) GetPayments(loanID uuid.UUID) ([]models.LoanPayment, error) {
+	payments := []models.LoanPayment{}
+	query := `SELECT ` + loanPaymentColumns + ` FROM loan_payments p JOIN transactions t ON t.id = p.transaction_id
+		WHERE p.loan_id = $1 ORDER BY p.payment_date DESC, p.created_at DESC`
+	err := r.db.Select(&payments, query, loanID)
+	return payments, err
+}
+
+func (r *LoanRepository) GetPaymentByID(loanID, id uuid.UUID) (*models.LoanPayment, error) {
+	var payment models.LoanPayment
+	query := `SELECT ` + loanPaymentColumns + ` FROM loan_payments p JOIN transactions t ON t.id = p.transaction_id
+		WHERE p.id = $1 AND p.loan_id = $2`
+	if err := r.db.Get(&payment, query, id, loanID); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetInterestPaidBetween returns the interest covered by the loan's payments dated in [from, to]
+func (r *LoanRepository) GetInterestPaidBetween(loanID uuid.UUID, from, to time.Time) (float64, error) {
+	var paid float64
+	query := `SELECT COALESCE(SUM(interest), 0) FROM loan_payments WHERE loan_id = $1 AND payment_date >= $2 AND payment_date <= $3`
+	err := r.db.Get(&paid, query, loanID, from, to)
+	return paid, err
+}
+
+// CreatePayment records a payment as a transaction on the account (an expense for
+// borrowed money, income for money lent) and marks the loan paid off once nothing is owed
+func (r *LoanRepository) CreatePayment(loan *models.Loan, t *models.Transaction, payment *models.LoanPayment) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	t.ID = uuid.New()
+	t.LoanID = &loan.ID
+	t.CreatedAt, t.UpdatedAt = now, now
+	insert := `
+		INSERT INTO transactions (id, user_id, account_id, type, category, subcategory, amount, description, transaction_date, loan_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	if _, err := tx.Exec(insert, t.ID, t.UserID, t.AccountID, t.Type, t.Category, t.Subcategory, t.Amount, t.Description, t.TransactionDate, t.LoanID, t.CreatedAt, t.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+	if err := applyBalanceEffect(tx, t, 1); err != nil {
+		return err
+	}
+
+	payment.ID = uuid.New()
+	payment.LoanID = loan.ID
+	payment.TransactionID = t.ID
+	payment.AccountID = t.AccountID
+	payment.Description = t.Description
+	payment.CreatedAt = now
+	_, err = tx.Exec(`
+		INSERT INTO loan_payments (id, loan_id, transaction_id, amount, principal, interest, payment_date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		payment.ID, payment.LoanID, payment.TransactionID, payment.Amount, payment.Principal, payment.Interest, payment.PaymentDate, payment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create loan payment: %w", err)
+	}
+
+	if err := updateLoanStatus(tx, loan.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeletePayment removes a payment with its transaction, reversing the account balance
+func (r *LoanRepository) DeletePayment(payment *models.LoanPayment) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var t models.Transaction
+	if err := tx.Get(&t, `SELECT `+transactionColumns+` FROM transactions WHERE id = $1`, payment.TransactionID); err != nil {
+		return err
+	}
+	if err := applyBalanceEffect(tx, &t, -1); err != nil {
+		return err
+	}
+	// The payment row goes with its transaction
+	if _, err := tx.Exec(`DELETE FROM transactions WHERE id = $1`, t.ID); err != nil {
+		return err
+	}
+
+	if err := updateLoanStatus(tx, payment.LoanID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// updateLoanStatus marks a loan paid off when its payments cover the principal, and active otherwise
+func updateLoanStatus(tx *sqlx.Tx, loanID uuid.UUID) error {
+	_, err := tx.Exec(`
+		UPDATE loans l SET updated_at = $2, status = CASE
+			WHEN l.principal - COALESCE((SELECT SUM(p.principal) FROM loan_payments p WHERE p.loan_id = l.id), 0) <= 0 THEN $3
+			ELSE $4 END
+		WHERE l.id = $1`,
+		loanID, time.Now(), models.LoanStatusPaidOff, models.LoanStatusActive)
+	return err
+}
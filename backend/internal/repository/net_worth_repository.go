@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type NetWorthRepository struct {
+	db *sqlx.DB
+}
+
+func NewNetWorthRepository(db *sqlx.DB) *NetWorthRepository {
+	return &NetWorthRepository{db: db}
+}
+
+// Calculate returns net worth at the end of the given day. Like the accounts list, it counts
+// the accounts and cards shared into the user's households; gold and loans are personal.
+// Balances are reconstructed by rolling back the effect of every later transaction,
+// gold is valued at the latest gold price known on that day and loans at the
+// principal still outstanding on that day.
+func (r *NetWorthRepository) Calculate(userID uuid.UUID, date time.Time) (*models.NetWorth, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	after := day.AddDate(0, 0, 1)
+	nw := &models.NetWorth{Date: day}
+
+	// Account balances (pockets included) minus the effect of transactions after the day.
+	// Paylater balances are debt, so their transactions count the other way round.
+	accountQuery := `
+		SELECT
+			COALESCE(SUM(b.balance) FILTER (WHERE b.type <> 'paylater'), 0) AS cash,
+			COALESCE(SUM(GREATEST(b.balance, 0)) FILTER (WHERE b.type = 'paylater'), 0) AS paylater_debt
+		FROM (
+			SELECT a.type, a.balance - COALESCE((
+				SELECT SUM(CASE WHEN t.type = 'income' THEN t.amount WHEN t.type = 'expense' THEN -t.amount ELSE 0 END)
+					* CASE WHEN a.type = 'paylater' THEN -1 ELSE 1 END
+				FROM transactions t
+				WHERE t.account_id = a.id AND t.transaction_date >= $2
+			), 0) AS balance
+			FROM accounts a
+			WHERE ` + visibleToUser + `
+		) b
+	`
+	if err := r.db.QueryRow(accountQuery, userID, after).Scan(&nw.Cash, &nw.PaylaterDebt); err != nil {
+		return nil, err
+	}
+
+	cardQuery := `
+		SELECT COALESCE(SUM(c.current_balance - COALESCE((
+			SELECT SUM(CASE WHEN t.type = 'expense' THEN t.amount WHEN t.type = 'income' THEN -t.amount ELSE 0 END)
+			FROM transactions t
+			WHERE t.credit_card_id = c.id AND t.transaction_date >= $2
+		), 0)), 0)
+		FROM credit_cards c
+		WHERE ` + visibleToUser + `
+	`
+	if err := r.db.QueryRow(cardQuery, userID, after).Scan(&nw.CreditCardDebt); err != nil {
+		return nil, err
+	}
+
+	// Before the first recorded price, the earliest known price is the best estimate
+	goldQuery := `
+		SELECT COALESCE(SUM(g.weight_gram), 0) * COALESCE(
+			(SELECT price_per_gram FROM gold_prices WHERE price_date <= $2 ORDER BY price_date DESC LIMIT 1),
+			(SELECT price_per_gram FROM gold_prices ORDER BY price_date ASC LIMIT 1),
+			0)
+		FROM gold_assets g
+		WHERE g.user_id = $1 AND g.purchase_date <= $2
+	`
+	if err := r.db.QueryRow(goldQuery, userID, day).Scan(&nw.Gold); err != nil {
+		return nil, err
+	}
+
+	// Loans count from their start date, less the principal repaid by the day
+	loanQuery := `
+		SELECT
+			COALESCE(SUM(o.outstanding) FILTER (WHERE o.direction = 'lent'), 0) AS loan_receivable,
+			COALESCE(SUM(o.outstanding) FILTER (WHERE o.direction = 'borrowed'), 0) AS loan_debt
+		FROM (
+			SELECT l.direction, GREATEST(l.principal - COALESCE((
+				SELECT SUM(p.principal) FROM loan_payments p
+				WHERE p.loan_id = l.id AND p.payment_date <= $2
+			), 0), 0) AS outstanding
+			FROM loans l
+			WHERE l.user_id = $1 AND l.start_date <= $2
+		) o
+	`
+	if err := r.db.QueryRow(loanQuery, userID, day).Scan(&nw.LoanReceivable, &nw.LoanDebt); err != nil {
+		return nil, err
+	}
+
+	addUpNetWorth(nw)
+	return nw, nil
+}
+
+// Reconstruct returns net worth at the end of each of the given days, worked out the same
+// way as Calculate but for all days in one query: balances are rolled back with running
+// sums of each account's and card's daily transactions, newest day first.
+func (r *NetWorthRepository) Reconstruct(userID uuid.UUID, dates []time.Time) ([]models.NetWorth, error) {
+	days := make(pq.StringArray, len(dates))
+	for i, d := range dates {
+		days[i] = d.Format("2006-01-02")
+	}
+
+	query := `
+		WITH days AS (
+			SELECT DISTINCT unnest($2::date[]) AS day
+		),
+		ledgers AS (
+			SELECT id, CASE WHEN type = 'paylater' THEN 'paylater' ELSE 'cash' END AS kind, balance
+			FROM accounts WHERE ` + visibleToUser + `
+			UNION ALL
+			SELECT id, 'card', current_balance FROM credit_cards WHERE ` + visibleToUser + `
+		),
+		-- How much each ledger moved per day. Paylater and card balances are debt, so
+		-- spending raises them. Every requested day gets a row even without transactions.
+		daily AS (
+			SELECT ledger_id, day, SUM(change) AS change
+			FROM (
+				SELECT l.id AS ledger_id, t.transaction_date::date AS day,
+					CASE WHEN t.type = 'income' THEN t.amount WHEN t.type = 'expense' THEN -t.amount ELSE 0 END
+						* CASE WHEN l.kind = 'cash' THEN 1 ELSE -1 END AS change
+				FROM ledgers l JOIN transactions t ON t.account_id = l.id
+				UNION ALL
+				SELECT l.id, t.transaction_date::date,
+					CASE WHEN t.type = 'expense' THEN t.amount WHEN t.type = 'income' THEN -t.amount ELSE 0 END
+				FROM ledgers l JOIN transactions t ON t.credit_card_id = l.id
+				UNION ALL
+				SELECT l.id, d.day, 0 FROM ledgers l CROSS JOIN days d
+			) changes
+			GROUP BY ledger_id, day
+		),
+		-- A balance at the end of a day is today's balance less everything after that day
+		balances AS (
+			SELECT d.day, l.kind, l.balance - COALESCE(SUM(d.change) OVER (
+				PARTITION BY d.ledger_id ORDER BY d.day DESC ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+			), 0) AS balance
+			FROM daily d JOIN ledgers l ON l.id = d.ledger_id
+		),
+		ledger_totals AS (
+			SELECT day,
+				COALESCE(SUM(balance) FILTER (WHERE kind = 'cash'), 0) AS cash,
+				COALESCE(SUM(GREATEST(balance, 0)) FILTER (WHERE kind = 'paylater'), 0) AS paylater_debt,
+				COALESCE(SUM(balance) FILTER (WHERE kind = 'card'), 0) AS credit_card_debt
+			FROM balances
+			WHERE day IN (SELECT day FROM days)
+			GROUP BY day
+		),
+		gold_totals AS (
+			SELECT d.day, COALESCE(SUM(g.weight_gram), 0) AS grams
+			FROM days d LEFT JOIN gold_assets g ON g.user_id = $1 AND g.purchase_date <= d.day
+			GROUP BY d.day
+		),
+		loan_balances AS (
+			SELECT d.day, l.direction, GREATEST(l.principal - COALESCE(SUM(p.principal), 0), 0) AS outstanding
+			FROM days d
+			JOIN loans l ON l.user_id = $1 AND l.start_date <= d.day
+			LEFT JOIN loan_payments p ON p.loan_id = l.id AND p.payment_date <= d.day
+			GROUP BY d.day, l.id
+		),
+		loan_totals AS (
+			SELECT day,
+				COALESCE(SUM(outstanding) FILTER (WHERE direction = 'lent'), 0) AS loan_receivable,
+				COALESCE(SUM(outstanding) FILTER (WHERE direction = 'borrowed'), 0) AS loan_debt
+			FROM loan_balances
+			GROUP BY day
+		)
+		SELECT d.day AS snapshot_date,
+			COALESCE(lt.cash, 0) AS cash,
+			COALESCE(lt.paylater_debt, 0) AS paylater_debt,
+			COALESCE(lt.credit_card_debt, 0) AS credit_card_debt,
+			gt.grams * COALESCE(
+				(SELECT price_per_gram FROM gold_prices WHERE price_date <= d.day ORDER BY price_date DESC LIMIT 1),
+				(SELECT price_per_gram FROM gold_prices ORDER BY price_date ASC LIMIT 1),
+				0) AS gold,
+			COALESCE(lo.loan_receivable, 0) AS loan_receivable,
+			COALESCE(lo.loan_debt, 0) AS loan_debt
+		FROM days d
+		JOIN gold_totals gt ON gt.day = d.day
+		LEFT JOIN ledger_totals lt ON lt.day = d.day
+		LEFT JOIN loan_totals lo ON lo.day = d.day
+		ORDER BY d.day
+	`
+	history := []models.NetWorth{}
+	if err := r.db.Select(&history, query, userID, days); err != nil {
+		return nil, err
+	}
+	for i := range history {
+		addUpNetWorth(&history[i])
+	}
+	return history, nil
+}
+
+// addUpNetWorth fills in the totals of a reconstructed net worth from its parts
+func addUpNetWorth(nw *models.NetWorth) {
+	nw.TotalAssets = nw.Cash + nw.Gold + nw.LoanReceivable
+	nw.TotalLiabilities = nw.CreditCardDebt + nw.PaylaterDebt + nw.LoanDebt
+	nw.NetWorth = nw.TotalAssets - nw.TotalLiabilities
+	nw.Source = models.NetWorthSourceReconstructed
+}
+
+func (r *NetWorthRepository) UpsertSnapshot(userID uuid.UUID, nw *models.NetWorth) error {
+	query := `
+		INSERT INTO net_worth_snapshots (id, user_id, snapshot_date, cash, gold, loan_receivable, total_assets, credit_card_debt, paylater_debt, loan_debt, total_liabilities, net_worth, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13)
+		ON CONFLICT (user_id, snapshot_date)
+		DO UPDATE SET cash = $4, gold = $5, loan_receivable = $6, total_assets = $7, credit_card_debt = $8, paylater_debt = $9, loan_debt = $10, total_liabilities = $11, net_worth = $12, updated_at = $13
+	`
+	_, err := r.db.Exec(query, uuid.New(), userID, nw.Date, nw.Cash, nw.Gold, nw.LoanReceivable, nw.TotalAssets, nw.CreditCardDebt, nw.PaylaterDebt, nw.LoanDebt, nw.TotalLiabilities, nw.NetWorth, time.Now())
+	return err
+}
+
+// GetSnapshots returns stored snapshots in [from, to] ordered by date
+func (r *NetWorthRepository) GetSnapshots(userID uuid.UUID, from, to time.Time) ([]models.NetWorth, error) {
+	snapshots := []models.NetWorth{}
+	query := `SELECT snapshot_date, cash, gold, loan_receivable, total_assets, credit_card_debt, paylater_debt, loan_debt, total_liabilities, net_worth
+		FROM net_worth_snapshots
+		WHERE user_id = $1 AND snapshot_date >= $2 AND snapshot_date <= $3
+		ORDER BY snapshot_date ASC`
+	if err := r.db.Select(&snapshots, query, userID, from, to); err != nil {
+		return nil, err
+	}
+	for i := range snapshots {
+		snapshots[i].Source = models.NetWorthSourceSnapshot
+	}
+	return snapshots, nil
+}
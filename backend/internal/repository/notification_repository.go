@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type NotificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewNotificationRepository(db *sqlx.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Record stores the notification unless one with the same dedup key already exists.
+// It reports whether the notification is new.
+func (r *NotificationRepository) Record(n *models.Notification) (bool, error) {
+	n.ID = uuid.New()
+	n.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO notifications (id, user_id, credit_card_id, type, title, message, dedup_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, dedup_key) DO NOTHING
+	`
+	res, err := r.db.Exec(query, n.ID, n.UserID, n.CreditCardID, n.Type, n.Title, n.Message, n.DedupKey, n.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create notification: %w", err)
+	}
+	inserted, err := res.RowsAffected()
+	return inserted > 0, err
+}
+
+func (r *NotificationRepository) GetByUserID(userID uuid.UUID, unreadOnly bool, limit int) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	query := `SELECT id, user_id, credit_card_id, type, title, message, dedup_key, read_at, created_at
+		FROM notifications WHERE user_id = $1 AND ($2 = false OR read_at IS NULL)
+		ORDER BY created_at DESC LIMIT $3`
+	err := r.db.Select(&notifications, query, userID, unreadOnly, limit)
+	return notifications, err
+}
+
+// MarkRead marks one of the user's notifications as read. It returns false if there's no such notification.
+func (r *NotificationRepository) MarkRead(id, userID uuid.UUID) (bool, error) {
+	query := `UPDATE notifications SET read_at = COALESCE(read_at, $1) WHERE id = $2 AND user_id = $3`
+	res, err := r.db.Exec(query, time.Now(), id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *NotificationRepository) MarkAllRead(userID uuid.UUID) error {
+	query := `UPDATE notifications SET read_at = $1 WHERE user_id = $2 AND read_at IS NULL`
+	_, err := r.db.Exec(query, time.Now(), userID)
+	return err
+}
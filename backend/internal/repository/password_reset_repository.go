@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrResetTokenInvalid is returned for unknown, expired or already used reset tokens
+var ErrResetTokenInvalid = errors.New("invalid reset token")
+
+type PasswordResetRepository struct {
+	db *sqlx.DB
+}
+
+func NewPasswordResetRepository(db *sqlx.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+// Create issues a reset token for the user, replacing any unused one, and returns the
+// token itself. Only its hash is stored.
+func (r *PasswordResetRepository) Create(userID uuid.UUID, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		uuid.New(), userID, hashToken(token), now.Add(ttl), now)
+	if err != nil {
+		return "", fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	return token, tx.Commit()
+}
+
+// Consume uses up a reset token and sets the password hash for its user. Every session
+// of the user is revoked, since whoever held them may not know the password any more.
+func (r *PasswordResetRepository) Consume(token, passwordHash string) (uuid.UUID, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	var row struct {
+		ID        uuid.UUID  `db:"id"`
+		UserID    uuid.UUID  `db:"user_id"`
+		ExpiresAt time.Time  `db:"expires_at"`
+		UsedAt    *time.Time `db:"used_at"`
+	}
+	err = tx.Get(&row, `SELECT id, user_id, expires_at, used_at FROM password_reset_tokens WHERE token_hash = $1 FOR UPDATE`, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrResetTokenInvalid
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	now := time.Now()
+	if row.UsedAt != nil || !row.ExpiresAt.After(now) {
+		return uuid.Nil, ErrResetTokenInvalid
+	}
+
+	if _, err := tx.Exec(`UPDATE password_reset_tokens SET used_at = $1 WHERE id = $2`, now, row.ID); err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := tx.Exec(`UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`, passwordHash, now, row.UserID); err != nil {
+		return uuid.Nil, err
+	}
+	if err := revokeAllSessions(tx, row.UserID, now); err != nil {
+		return uuid.Nil, err
+	}
+
+	return row.UserID, tx.Commit()
+}
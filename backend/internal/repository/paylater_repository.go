@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PaylaterRepository struct {
+	db *sqlx.DB
+}
+
+func NewPaylaterRepository(db *sqlx.DB) *PaylaterRepository {
+	return &PaylaterRepository{db: db}
+}
+
+// Upsert creates or replaces the profile of a paylater account
+func (r *PaylaterRepository) Upsert(profile *models.PaylaterProfile) error {
+	now := time.Now()
+	profile.CreatedAt = now
+	profile.UpdatedAt = now
+
+	query := `
+		INSERT INTO paylater_profiles (account_id, provider, credit_limit, billing_day, due_day, interest_rate, admin_fee, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (account_id) DO UPDATE SET
+			provider = EXCLUDED.provider, credit_limit = EXCLUDED.credit_limit, billing_day = EXCLUDED.billing_day,
+			due_day = EXCLUDED.due_day, interest_rate = EXCLUDED.interest_rate, admin_fee = EXCLUDED.admin_fee,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at
+	`
+	err := r.db.QueryRow(query, profile.AccountID, profile.Provider, profile.CreditLimit, profile.BillingDay, profile.DueDay, profile.InterestRate, profile.AdminFee, profile.CreatedAt, profile.UpdatedAt).
+		Scan(&profile.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save paylater profile: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PaylaterRepository) GetByAccountID(accountID uuid.UUID) (*models.PaylaterProfile, error) {
+	var profile models.PaylaterProfile
+	query := `SELECT account_id, provider, credit_limit, billing_day, due_day, interest_rate, admin_fee, created_at, updated_at
+		FROM paylater_profiles WHERE account_id = $1`
+	if err := r.db.Get(&profile, query, accountID); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ReportRepository aggregates transactions for the reports page: the user's own and those
+// on accounts and cards shared into their households. Credit card spending counts as expense on its transaction date, while income
+// on a credit card (a card payment) is not counted as income.
+type ReportRepository struct {
+	db *sqlx.DB
+}
+
+func NewReportRepository(db *sqlx.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// GetMonthlyTotals returns one row per month from the month of start to the month of end, including empty months
+func (r *ReportRepository) GetMonthlyTotals(userID uuid.UUID, start, end time.Time) ([]models.MonthlyReport, error) {
+	months := []models.MonthlyReport{}
+	query := `
+		SELECT to_char(m.month, 'YYYY-MM') AS month,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'income' AND NOT ` + creditCondition + `), 0) AS income,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'expense'), 0) AS expense,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'expense' AND ` + creditCondition + `), 0) AS credit_expense
+		FROM generate_series(date_trunc('month', $2::timestamp), date_trunc('month', $3::timestamp), interval '1 month') AS m(month)
+		LEFT JOIN transactions t
+			ON ` + transactionVisibleToUser + `
+			AND t.transaction_date >= m.month
+			AND t.transaction_date < m.month + interval '1 month'
+			AND t.linked_transaction_id IS NULL
+		GROUP BY m.month
+		ORDER BY m.month
+	`
+	if err := r.db.Select(&months, query, userID, start, end); err != nil {
+		return nil, err
+	}
+
+	for i := range months {
+		months[i].Net = months[i].Income - months[i].Expense
+	}
+	return months, nil
+}
+
+// GetPeriodReport returns income, expense and the expense breakdown by category and subcategory in [start, end)
+func (r *ReportRepository) GetPeriodReport(userID uuid.UUID, start, end time.Time) (*models.PeriodReport, error) {
+	report := &models.PeriodReport{
+		StartDate:  start.Format("2006-01-02"),
+		EndDate:    end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Categories: []models.CategoryReport{},
+	}
+
+	totalsQuery := `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE type = 'income' AND NOT ` + creditCondition + `), 0) AS income,
+			COALESCE(SUM(amount) FILTER (WHERE type = 'expense'), 0) AS expense
+		FROM transactions t
+		WHERE ` + transactionVisibleToUser + ` AND transaction_date >= $2 AND transaction_date < $3 AND linked_transaction_id IS NULL
+	`
+	if err := r.db.QueryRow(totalsQuery, userID, start, end).Scan(&report.Income, &report.Expense); err != nil {
+		return nil, err
+	}
+	report.Net = report.Income - report.Expense
+
+	var rows []struct {
+		Category    string  `db:"category"`
+		Subcategory string  `db:"subcategory"`
+		Total       float64 `db:"total"`
+		Count       int     `db:"count"`
+	}
+	breakdownQuery := `
+		SELECT category, subcategory, SUM(amount) AS total, COUNT(*) AS count
+		FROM transactions
+		WHERE ` + transactionVisibleToUser + ` AND type = 'expense' AND transaction_date >= $2 AND transaction_date < $3 AND linked_transaction_id IS NULL
+		GROUP BY category, subcategory
+		ORDER BY category, total DESC
+	`
+	if err := r.db.Select(&rows, breakdownQuery, userID, start, end); err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	for _, row := range rows {
+		i, ok := index[row.Category]
+		if !ok {
+			report.Categories = append(report.Categories, models.CategoryReport{Category: row.Category, Subcategories: []models.SubcategoryReport{}})
+			i = len(report.Categories) - 1
+			index[row.Category] = i
+		}
+		cat := &report.Categories[i]
+		cat.Total += row.Total
+		cat.Count += row.Count
+		cat.Subcategories = append(cat.Subcategories, models.SubcategoryReport{
+			Subcategory: row.Subcategory,
+			Total:       row.Total,
+			Count:       row.Count,
+		})
+	}
+
+	for i := range report.Categories {
+		cat := &report.Categories[i]
+		if report.Expense > 0 {
+			cat.Percent = cat.Total / report.Expense * 100
+		}
+		for j := range cat.Subcategories {
+			if cat.Total > 0 {
+				cat.Subcategories[j].Percent = cat.Subcategories[j].Total / cat.Total * 100
+			}
+		}
+	}
+	sort.SliceStable(report.Categories, func(i, j int) bool {
+		return report.Categories[i].Total > report.Categories[j].Total
+	})
+
+	return report, nil
+}
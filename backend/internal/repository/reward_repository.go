@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type RewardRepository struct {
+	db *sqlx.DB
+}
+
+func NewRewardRepository(db *sqlx.DB) *RewardRepository {
+	return &RewardRepository{db: db}
+}
+
+const rewardRuleColumns = `id, user_id, credit_card_id, category, reward_type, rate, per_amount, point_value, created_at, updated_at`
+
+const rewardEarningColumns = `transaction_id, credit_card_id, rule_id, category, reward_type, rate, per_amount, earned, created_at, updated_at`
+
+// UpsertRule creates the card's rule for the category or replaces the existing one
+func (r *RewardRepository) UpsertRule(rule *models.RewardRule) error {
+	now := time.Now()
+	rule.ID = uuid.New()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	query := `
+		INSERT INTO card_reward_rules (id, user_id, credit_card_id, category, reward_type, rate, per_amount, point_value, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (credit_card_id, category) DO UPDATE SET
+			reward_type = EXCLUDED.reward_type, rate = EXCLUDED.rate, per_amount = EXCLUDED.per_amount,
+			point_value = EXCLUDED.point_value, updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(query, rule.ID, rule.UserID, rule.CreditCardID, rule.Category, rule.RewardType, rule.Rate, rule.PerAmount, rule.PointValue, rule.CreatedAt, rule.UpdatedAt).
+		Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save reward rule: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RewardRepository) GetRules(creditCardID uuid.UUID) ([]models.RewardRule, error) {
+	rules := []models.RewardRule{}
+	query := `SELECT ` + rewardRuleColumns + ` FROM card_reward_rules WHERE credit_card_id = $1 ORDER BY category`
+	err := r.db.Select(&rules, query, creditCardID)
+	return rules, err
+}
+
+// GetRulesByUser returns the rules of all the cards the user can see, their own and those
+// shared into their households, keyed by card
+func (r *RewardRepository) GetRulesByUser(userID uuid.UUID) (map[uuid.UUID][]models.RewardRule, error) {
+	var rules []models.RewardRule
+	query := `SELECT ` + rewardRuleColumns + ` FROM card_reward_rules
+		WHERE credit_card_id IN (SELECT id FROM credit_cards WHERE ` + visibleToUser + `) ORDER BY category`
+	if err := r.db.Select(&rules, query, userID); err != nil {
+		return nil, err
+	}
+
+	byCard := map[uuid.UUID][]models.RewardRule{}
+	for _, rule := range rules {
+		byCard[rule.CreditCardID] = append(byCard[rule.CreditCardID], rule)
+	}
+	return byCard, nil
+}
+
+// DeleteRule deletes one of the card's rules. It returns false if the card has no such rule.
+func (r *RewardRepository) DeleteRule(id, creditCardID uuid.UUID) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM card_reward_rules WHERE id = $1 AND credit_card_id = $2`, id, creditCardID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetEarnings returns what each of the card's purchases earned, newest first
+func (r *RewardRepository) GetEarnings(creditCardID uuid.UUID) ([]models.RewardEarning, error) {
+	earnings := []models.RewardEarning{}
+	query := `SELECT ` + rewardEarningColumns + ` FROM card_reward_earnings WHERE credit_card_id = $1`
+	if err := r.db.Select(&earnings, query, creditCardID); err != nil {
+		return nil, err
+	}
+
+	var transactions []models.Transaction
+	query = `SELECT ` + transactionColumns + ` FROM transactions
+		WHERE id IN (SELECT transaction_id FROM card_reward_earnings WHERE credit_card_id = $1)
+		ORDER BY transaction_date DESC, created_at DESC`
+	if err := r.db.Select(&transactions, query, creditCardID); err != nil {
+		return nil, err
+	}
+
+	byTransaction := map[uuid.UUID]models.RewardEarning{}
+	for _, e := range earnings {
+		byTransaction[e.TransactionID] = e
+	}
+	sorted := make([]models.RewardEarning, 0, len(transactions))
+	for _, t := range transactions {
+		e := byTransaction[t.ID]
+		e.Transaction = t
+		sorted = append(sorted, e)
+	}
+	return sorted, nil
+}
+
+// recordRewardEarning records what a card purchase earns under the card's rules as it posts.
+// Re-posting an edited purchase on the same card and category keeps the rate it was recorded
+// with. Anything that isn't a card purchase, e.g. a payment or the card's interest and fees,
+// earns nothing. db is the database or the transaction the purchase is posted in.
+func recordRewardEarning(db sqlx.Ext, t *models.Transaction) error {
+	if t.CreditCardID == nil || t.Type != models.TransactionTypeExpense || t.Category == models.CategoryCreditCardCharges {
+		_, err := db.Exec(`DELETE FROM card_reward_earnings WHERE transaction_id = $1`, t.ID)
+		return err
+	}
+
+	var earning models.RewardEarning
+	err := sqlx.Get(db, &earning, `SELECT `+rewardEarningColumns+` FROM card_reward_earnings WHERE transaction_id = $1`, t.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err != nil || earning.CreditCardID != *t.CreditCardID || !strings.EqualFold(earning.Category, t.Category) {
+		var rules []models.RewardRule
+		if err := sqlx.Select(db, &rules, `SELECT `+rewardRuleColumns+` FROM card_reward_rules WHERE credit_card_id = $1`, *t.CreditCardID); err != nil {
+			return err
+		}
+		rule := models.MatchRewardRule(rules, t.Category)
+		if rule == nil {
+			_, err := db.Exec(`DELETE FROM card_reward_earnings WHERE transaction_id = $1`, t.ID)
+			return err
+		}
+		earning = models.RewardEarning{RuleID: &rule.ID, RewardType: rule.RewardType, Rate: rule.Rate, PerAmount: rule.PerAmount}
+	}
+
+	rule := models.RewardRule{RewardType: earning.RewardType, Rate: earning.Rate, PerAmount: earning.PerAmount}
+	now := time.Now()
+	query := `
+		INSERT INTO card_reward_earnings (transaction_id, credit_card_id, rule_id, category, reward_type, rate, per_amount, earned, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (transaction_id) DO UPDATE SET
+			credit_card_id = EXCLUDED.credit_card_id, rule_id = EXCLUDED.rule_id, category = EXCLUDED.category,
+			reward_type = EXCLUDED.reward_type, rate = EXCLUDED.rate, per_amount = EXCLUDED.per_amount,
+			earned = EXCLUDED.earned, updated_at = EXCLUDED.updated_at
+	`
+	if _, err := db.Exec(query, t.ID, *t.CreditCardID, earning.RuleID, t.Category, earning.RewardType, earning.Rate, earning.PerAmount, rule.Earn(t.Amount), now); err != nil {
+		return fmt.Errorf("failed to record reward earning: %w", err)
+	}
+	return nil
+}
+
+func (r *RewardRepository) CreateRedemption(redemption *models.RewardRedemption) error {
+	redemption.ID = uuid.New()
+	redemption.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO card_reward_redemptions (id, user_id, credit_card_id, reward_type, amount, description, redeemed_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.Exec(query, redemption.ID, redemption.UserID, redemption.CreditCardID, redemption.RewardType, redemption.Amount, redemption.Description, redemption.RedeemedAt, redemption.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reward redemption: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RewardRepository) GetRedemptions(creditCardID uuid.UUID) ([]models.RewardRedemption, error) {
+	redemptions := []models.RewardRedemption{}
+	query := `SELECT id, user_id, credit_card_id, reward_type, amount, description, redeemed_at, created_at
+		FROM card_reward_redemptions WHERE credit_card_id = $1 ORDER BY redeemed_at DESC, created_at DESC`
+	err := r.db.Select(&redemptions, query, creditCardID)
+	return redemptions, err
+}
+
+// DeleteRedemption deletes one of the card's redemptions. It returns false if the card has no such redemption.
+func (r *RewardRepository) DeleteRedemption(id, creditCardID uuid.UUID) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM card_reward_redemptions WHERE id = $1 AND credit_card_id = $2`, id, creditCardID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetBalances totals the card's recorded earnings and its redemptions per reward type
+func (r *RewardRepository) GetBalances(creditCardID uuid.UUID) ([]models.RewardBalance, error) {
+	var earned []models.RewardBalance
+	query := `SELECT reward_type, SUM(earned) AS earned FROM card_reward_earnings WHERE credit_card_id = $1 GROUP BY reward_type`
+	if err := r.db.Select(&earned, query, creditCardID); err != nil {
+		return nil, err
+	}
+	redemptions, err := r.GetRedemptions(creditCardID)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := map[models.RewardType]*models.RewardBalance{}
+	get := func(t models.RewardType) *models.RewardBalance {
+		if totals[t] == nil {
+			totals[t] = &models.RewardBalance{RewardType: t}
+		}
+		return totals[t]
+	}
+	for _, e := range earned {
+		get(e.RewardType).Earned += e.Earned
+	}
+	for _, red := range redemptions {
+		get(red.RewardType).Redeemed += red.Amount
+	}
+
+	balances := []models.RewardBalance{}
+	for _, t := range []models.RewardType{models.RewardTypeCashback, models.RewardTypePoints} {
+		if b := totals[t]; b != nil {
+			b.Earned = roundAmount(b.Earned)
+			b.Redeemed = roundAmount(b.Redeemed)
+			b.Balance = roundAmount(b.Earned - b.Redeemed)
+			balances = append(balances, *b)
+		}
+	}
+	return balances, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ErrRoleNotFound is returned when assigning a role that doesn't exist
+var ErrRoleNotFound = errors.New("role not found")
+
+type RoleRepository struct {
+	db *sqlx.DB
+}
+
+func NewRoleRepository(db *sqlx.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// GetAll returns every role with its permissions
+func (r *RoleRepository) GetAll() ([]models.Role, error) {
+	roles := []models.Role{}
+	query := `SELECT r.id, r.name, r.description, r.created_at,
+			COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}') AS permissions
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		GROUP BY r.id ORDER BY r.name`
+	err := r.db.Select(&roles, query)
+	return roles, err
+}
+
+// GetRolesByUser returns the names of the user's roles
+func (r *RoleRepository) GetRolesByUser(userID uuid.UUID) ([]string, error) {
+	roles := []string{}
+	err := r.db.Select(&roles, `SELECT r.name FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = $1 ORDER BY r.name`, userID)
+	return roles, err
+}
+
+// GetPermissionsByUser returns every permission the user's roles grant. It implements
+// middleware.PermissionLoader.
+func (r *RoleRepository) GetPermissionsByUser(userID uuid.UUID) ([]string, error) {
+	permissions := []string{}
+	query := `SELECT DISTINCT p.name FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = $1`
+	err := r.db.Select(&permissions, query, userID)
+	return permissions, err
+}
+
+// SetUserRoles replaces the user's roles, returning ErrRoleNotFound if one doesn't exist
+func (r *RoleRepository) SetUserRoles(userID uuid.UUID, roles []string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var roleIDs []uuid.UUID
+	if err := tx.Select(&roleIDs, `SELECT id FROM roles WHERE name = ANY($1)`, pq.StringArray(roles)); err != nil {
+		return err
+	}
+	if len(roleIDs) != countDistinct(roles) {
+		return ErrRoleNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_roles WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, roleID := range roleIDs {
+		if _, err := tx.Exec(`INSERT INTO user_roles (user_id, role_id, created_at) VALUES ($1, $2, $3)`, userID, roleID, now); err != nil {
+			return fmt.Errorf("failed to assign role: %w", err)
+		}
+	}
+	if err := syncIsAdmin(tx, userID, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetRole grants or takes away one role, leaving the user's others alone
+func (r *RoleRepository) SetRole(userID uuid.UUID, role string, granted bool) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var roleID uuid.UUID
+	if err := tx.Get(&roleID, `SELECT id FROM roles WHERE name = $1`, role); err != nil {
+		return ErrRoleNotFound
+	}
+
+	now := time.Now()
+	if granted {
+		_, err = tx.Exec(`INSERT INTO user_roles (user_id, role_id, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, userID, roleID, now)
+	} else {
+		_, err = tx.Exec(`DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`, userID, roleID)
+	}
+	if err != nil {
+		return err
+	}
+	if err := syncIsAdmin(tx, userID, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// syncIsAdmin keeps users.is_admin in step with whether the user holds the admin role
+func syncIsAdmin(tx *sqlx.Tx, userID uuid.UUID, now time.Time) error {
+	_, err := tx.Exec(`UPDATE users SET updated_at = $1, is_admin = EXISTS (
+			SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = users.id AND r.name = $2
+		) WHERE id = $3`, now, models.RoleAdmin, userID)
+	return err
+}
+
+func countDistinct(values []string) int {
+	seen := map[string]bool{}
+	for _, v := range values {
+		seen[v] = true
+	}
+	return len(seen)
+}
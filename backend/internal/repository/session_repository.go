@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned for unknown or expired refresh tokens and revoked sessions
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented
+	// again; the session it belongs to has been revoked
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+type SessionRepository struct {
+	db *sqlx.DB
+}
+
+func NewSessionRepository(db *sqlx.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+const sessionColumns = `id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at`
+
+// lastSeenResolution is how stale last_seen_at may get before a request refreshes it
+const lastSeenResolution = time.Minute
+
+// Create starts a session for the user and returns it with its first refresh token
+func (r *SessionRepository) Create(userID uuid.UUID, ttl time.Duration, client models.SessionClient) (*models.Session, string, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	session := &models.Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	_, err = tx.Exec(`
+		INSERT INTO auth_sessions (id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		session.ID, session.UserID, session.UserAgent, session.IPAddress, session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	token, err := insertRefreshToken(tx, session.ID, now, session.ExpiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", err
+	}
+	return session, token, nil
+}
+
+// Rotate trades a refresh token for a new one and extends its session, recording where
+// the client is now. Each token works once: presenting a used token again means it was
+// copied, so the whole session is revoked and ErrRefreshTokenReused returned.
+func (r *SessionRepository) Rotate(refreshToken string, ttl time.Duration, client models.SessionClient) (*models.Session, string, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	var current struct {
+		ID        uuid.UUID  `db:"id"`
+		SessionID uuid.UUID  `db:"session_id"`
+		ExpiresAt time.Time  `db:"expires_at"`
+		UsedAt    *time.Time `db:"used_at"`
+	}
+	err = tx.Get(&current, `SELECT id, session_id, expires_at, used_at FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`,
+		hashToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	if current.UsedAt != nil {
+		if _, err := tx.Exec(`UPDATE auth_sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`, now, current.SessionID); err != nil {
+			return nil, "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	var session models.Session
+	if err := tx.Get(&session, `SELECT `+sessionColumns+` FROM auth_sessions WHERE id = $1 FOR UPDATE`, current.SessionID); err != nil {
+		return nil, "", err
+	}
+	if session.RevokedAt != nil || !now.Before(current.ExpiresAt) {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET used_at = $1 WHERE id = $2`, now, current.ID); err != nil {
+		return nil, "", err
+	}
+	session.ExpiresAt = now.Add(ttl)
+	session.LastSeenAt = now
+	session.IPAddress = client.IPAddress
+	if client.UserAgent != "" {
+		session.UserAgent = client.UserAgent
+	}
+	_, err = tx.Exec(`UPDATE auth_sessions SET expires_at = $1, last_seen_at = $2, ip_address = $3, user_agent = $4 WHERE id = $5`,
+		session.ExpiresAt, session.LastSeenAt, session.IPAddress, session.UserAgent, session.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := insertRefreshToken(tx, session.ID, now, session.ExpiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", err
+	}
+	return &session, token, nil
+}
+
+func (r *SessionRepository) GetByID(id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.Get(&session, `SELECT `+sessionColumns+` FROM auth_sessions WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveByUser returns the user's open sessions, most recently used first
+func (r *SessionRepository) GetActiveByUser(userID uuid.UUID) ([]models.Session, error) {
+	sessions := []models.Session{}
+	query := `SELECT ` + sessionColumns + ` FROM auth_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC`
+	err := r.db.Select(&sessions, query, userID, time.Now())
+	return sessions, err
+}
+
+// Revoke ends a session; its access and refresh tokens stop working immediately
+func (r *SessionRepository) Revoke(id uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE auth_sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`, time.Now(), id)
+	return err
+}
+
+// RevokeAll ends every session of the user and bumps their token version, so no token
+// issued so far is accepted any more
+func (r *SessionRepository) RevokeAll(userID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := revokeAllSessions(tx, userID, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// revokeAllSessions ends every session of the user and bumps their token version so
+// outstanding access tokens stop working too
+func revokeAllSessions(tx *sqlx.Tx, userID uuid.UUID, now time.Time) error {
+	if _, err := tx.Exec(`UPDATE auth_sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`, now, userID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`UPDATE users SET token_version = token_version + 1, updated_at = $1 WHERE id = $2`, now, userID)
+	return err
+}
+
+// IsAccessTokenValid reports whether an access token's session is still open and its
+// token version current, and marks the session as seen. It implements middleware.TokenValidator.
+func (r *SessionRepository) IsAccessTokenValid(userID, sessionID uuid.UUID, tokenVersion int) (bool, error) {
+	var lastSeen time.Time
+	now := time.Now()
+	query := `SELECT s.last_seen_at FROM auth_sessions s JOIN users u ON u.id = s.user_id
+		WHERE s.id = $1 AND s.user_id = $2 AND s.revoked_at IS NULL AND s.expires_at > $3 AND u.token_version = $4`
+	err := r.db.Get(&lastSeen, query, sessionID, userID, now, tokenVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Only write when it has gone stale, not on every request
+	if now.Sub(lastSeen) >= lastSeenResolution {
+		if _, err := r.db.Exec(`UPDATE auth_sessions SET last_seen_at = $1 WHERE id = $2`, now, sessionID); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func insertRefreshToken(tx *sqlx.Tx, sessionID uuid.UUID, now, expiresAt time.Time) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	_, err := tx.Exec(`INSERT INTO refresh_tokens (id, session_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		uuid.New(), sessionID, hashToken(token), expiresAt, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// hashToken returns the hex SHA-256 of a secret token, the form it is stored in
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
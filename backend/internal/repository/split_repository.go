@@ -0,0 +1,410 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type SplitRepository struct {
+	db *sqlx.DB
+}
+
+func NewSplitRepository(db *sqlx.DB) *SplitRepository {
+	return &SplitRepository{db: db}
+}
+
+// Per-member totals for member m of a group: what they paid, their share of the bills,
+// and confirmed settlements they sent and received
+const (
+	splitPaidExpr  = `COALESCE((SELECT SUM(e.amount) FROM split_expenses e WHERE e.group_id = m.group_id AND e.payer_id = m.user_id), 0)`
+	splitShareExpr = `COALESCE((SELECT SUM(s.amount) FROM split_expense_shares s JOIN split_expenses e ON e.id = s.expense_id
+		WHERE e.group_id = m.group_id AND s.user_id = m.user_id), 0)`
+	splitSentExpr = `COALESCE((SELECT SUM(st.amount) FROM split_settlements st
+		WHERE st.group_id = m.group_id AND st.from_user_id = m.user_id AND st.status = 'confirmed'), 0)`
+	splitReceivedExpr = `COALESCE((SELECT SUM(st.amount) FROM split_settlements st
+		WHERE st.group_id = m.group_id AND st.to_user_id = m.user_id AND st.status = 'confirmed'), 0)`
+	// Positive when the member is owed money
+	splitBalanceExpr = splitPaidExpr + ` - ` + splitShareExpr + ` + ` + splitSentExpr + ` - ` + splitReceivedExpr
+)
+
+const splitSettlementColumns = `id, group_id, from_user_id, to_user_id, amount, settlement_date, status, from_transaction_id, to_transaction_id, created_at, confirmed_at`
+
+const splitInvitationColumns = `i.id, i.group_id, i.user_id, i.invited_by, i.created_at, g.name AS group_name, u.full_name AS invited_by_name`
+
+// CreateGroup creates a group with its creator as the only member and invites the given users
+func (r *SplitRepository) CreateGroup(group *models.SplitGroup, inviteeIDs []uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	group.ID = uuid.New()
+	group.CreatedAt = time.Now()
+	group.UpdatedAt = time.Now()
+	_, err = tx.Exec(`INSERT INTO split_groups (id, name, created_by, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`,
+		group.ID, group.Name, group.CreatedBy, group.CreatedAt, group.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create split group: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO split_group_members (group_id, user_id, joined_at) VALUES ($1, $2, $3)`,
+		group.ID, group.CreatedBy, group.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	for _, userID := range inviteeIDs {
+		if err := insertSplitInvitation(tx, group.ID, userID, group.CreatedBy); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetGroupsByUser returns the groups the user belongs to with the user's balance in each
+func (r *SplitRepository) GetGroupsByUser(userID uuid.UUID) ([]models.SplitGroup, error) {
+	groups := []models.SplitGroup{}
+	query := `SELECT g.id, g.name, g.created_by, g.created_at, g.updated_at, ` + splitBalanceExpr + ` AS my_balance
+		FROM split_groups g JOIN split_group_members m ON m.group_id = g.id
+		WHERE m.user_id = $1 ORDER BY g.updated_at DESC`
+	err := r.db.Select(&groups, query, userID)
+	return groups, err
+}
+
+func (r *SplitRepository) GetGroup(id uuid.UUID) (*models.SplitGroup, error) {
+	var group models.SplitGroup
+	query := `SELECT id, name, created_by, created_at, updated_at FROM split_groups WHERE id = $1`
+	if err := r.db.Get(&group, query, id); err != nil {
+		return nil, err
+	}
+
+	members, err := r.GetMembers(id)
+	if err != nil {
+		return nil, err
+	}
+	group.Members = members
+	return &group, nil
+}
+
+// GetMembers returns the group's members with what they paid, their share and net balance
+func (r *SplitRepository) GetMembers(groupID uuid.UUID) ([]models.SplitMember, error) {
+	members := []models.SplitMember{}
+	query := `SELECT m.user_id, u.username, u.full_name, m.joined_at,
+			` + splitPaidExpr + ` AS paid, ` + splitShareExpr + ` AS share, ` + splitBalanceExpr + ` AS balance
+		FROM split_group_members m JOIN users u ON u.id = m.user_id
+		WHERE m.group_id = $1 ORDER BY m.joined_at ASC, u.full_name ASC`
+	err := r.db.Select(&members, query, groupID)
+	return members, err
+}
+
+// InviteMember invites a user to the group. Members and users already invited are left as they are.
+func (r *SplitRepository) InviteMember(groupID, userID, invitedBy uuid.UUID) error {
+	return insertSplitInvitation(r.db, groupID, userID, invitedBy)
+}
+
+// GetInvitationsByUser returns the invitations waiting for the user's answer
+func (r *SplitRepository) GetInvitationsByUser(userID uuid.UUID) ([]models.SplitGroupInvitation, error) {
+	invitations := []models.SplitGroupInvitation{}
+	query := `SELECT ` + splitInvitationColumns + `
+		FROM split_group_invitations i JOIN split_groups g ON g.id = i.group_id JOIN users u ON u.id = i.invited_by
+		WHERE i.user_id = $1 ORDER BY i.created_at DESC`
+	err := r.db.Select(&invitations, query, userID)
+	return invitations, err
+}
+
+func (r *SplitRepository) GetInvitation(id uuid.UUID) (*models.SplitGroupInvitation, error) {
+	var invitation models.SplitGroupInvitation
+	query := `SELECT ` + splitInvitationColumns + `
+		FROM split_group_invitations i JOIN split_groups g ON g.id = i.group_id JOIN users u ON u.id = i.invited_by
+		WHERE i.id = $1`
+	if err := r.db.Get(&invitation, query, id); err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// AcceptInvitation makes the invited user a member of the group
+func (r *SplitRepository) AcceptInvitation(invitation *models.SplitGroupInvitation) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM split_group_invitations WHERE id = $1`, invitation.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("invitation %s was already answered", invitation.ID)
+	}
+	_, err = tx.Exec(`INSERT INTO split_group_members (group_id, user_id, joined_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+		invitation.GroupID, invitation.UserID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteInvitation removes a declined invitation
+func (r *SplitRepository) DeleteInvitation(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM split_group_invitations WHERE id = $1`, id)
+	return err
+}
+
+func (r *SplitRepository) RemoveMember(groupID, userID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM split_group_members WHERE group_id = $1 AND user_id = $2`, groupID, userID)
+	return err
+}
+
+// DeleteGroup removes a group with its expenses and settlements. Transactions already
+// posted to members' accounts stay, no longer tied to the group.
+func (r *SplitRepository) DeleteGroup(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM split_groups WHERE id = $1`, id)
+	return err
+}
+
+// CreateExpense records a bill with its shares. When t is not nil the whole bill is
+// also posted as that expense on the payer's account.
+func (r *SplitRepository) CreateExpense(expense *models.SplitExpense, t *models.Transaction) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if t != nil {
+		if err := insertSplitTransaction(tx, t, expense.GroupID, now); err != nil {
+			return err
+		}
+		expense.PayerTransactionID = &t.ID
+	}
+
+	expense.ID = uuid.New()
+	expense.CreatedAt = now
+	expense.UpdatedAt = now
+	_, err = tx.Exec(`
+		INSERT INTO split_expenses (id, group_id, payer_id, created_by, description, category, amount, split_mode, expense_date, payer_transaction_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		expense.ID, expense.GroupID, expense.PayerID, expense.CreatedBy, expense.Description, expense.Category, expense.Amount,
+		expense.SplitMode, expense.ExpenseDate, expense.PayerTransactionID, expense.CreatedAt, expense.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create shared expense: %w", err)
+	}
+
+	for i := range expense.Shares {
+		expense.Shares[i].ExpenseID = expense.ID
+		share := expense.Shares[i]
+		if _, err := tx.Exec(`INSERT INTO split_expense_shares (expense_id, user_id, shares, amount) VALUES ($1, $2, $3, $4)`,
+			share.ExpenseID, share.UserID, share.Shares, share.Amount); err != nil {
+			return fmt.Errorf("failed to create expense share: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE split_groups SET updated_at = $1 WHERE id = $2`, now, expense.GroupID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetExpenses returns the group's expenses with their shares, newest first
+func (r *SplitRepository) GetExpenses(groupID uuid.UUID) ([]models.SplitExpense, error) {
+	expenses := []models.SplitExpense{}
+	query := `SELECT id, group_id, payer_id, created_by, description, category, amount, split_mode, expense_date, payer_transaction_id, created_at, updated_at
+		FROM split_expenses WHERE group_id = $1 ORDER BY expense_date DESC, created_at DESC`
+	if err := r.db.Select(&expenses, query, groupID); err != nil {
+		return nil, err
+	}
+
+	var shares []models.SplitExpenseShare
+	query = `SELECT s.expense_id, s.user_id, s.shares, s.amount FROM split_expense_shares s
+		JOIN split_expenses e ON e.id = s.expense_id WHERE e.group_id = $1`
+	if err := r.db.Select(&shares, query, groupID); err != nil {
+		return nil, err
+	}
+	byExpense := map[uuid.UUID][]models.SplitExpenseShare{}
+	for _, s := range shares {
+		byExpense[s.ExpenseID] = append(byExpense[s.ExpenseID], s)
+	}
+	for i := range expenses {
+		expenses[i].Shares = byExpense[expenses[i].ID]
+	}
+	return expenses, nil
+}
+
+func (r *SplitRepository) GetExpense(groupID, id uuid.UUID) (*models.SplitExpense, error) {
+	var expense models.SplitExpense
+	query := `SELECT id, group_id, payer_id, created_by, description, category, amount, split_mode, expense_date, payer_transaction_id, created_at, updated_at
+		FROM split_expenses WHERE id = $1 AND group_id = $2`
+	if err := r.db.Get(&expense, query, id, groupID); err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// DeleteExpense removes an expense and reverses the payer's posting, if any
+func (r *SplitRepository) DeleteExpense(expense *models.SplitExpense) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM split_expenses WHERE id = $1`, expense.ID); err != nil {
+		return err
+	}
+	if err := deleteSplitTransaction(tx, expense.PayerTransactionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateSettlement records a pending settlement, posting it as that expense on the payer's account
+func (r *SplitRepository) CreateSettlement(settlement *models.SplitSettlement, t *models.Transaction) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := insertSplitTransaction(tx, t, settlement.GroupID, now); err != nil {
+		return err
+	}
+
+	settlement.ID = uuid.New()
+	settlement.Status = models.SplitSettlementPending
+	settlement.FromTransactionID = &t.ID
+	settlement.CreatedAt = now
+	_, err = tx.Exec(`
+		INSERT INTO split_settlements (id, group_id, from_user_id, to_user_id, amount, settlement_date, status, from_transaction_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		settlement.ID, settlement.GroupID, settlement.FromUserID, settlement.ToUserID, settlement.Amount, settlement.SettlementDate,
+		settlement.Status, settlement.FromTransactionID, settlement.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create settlement: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ConfirmSettlement marks a settlement received, posting it as that income on the receiver's account
+func (r *SplitRepository) ConfirmSettlement(settlement *models.SplitSettlement, t *models.Transaction) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := insertSplitTransaction(tx, t, settlement.GroupID, now); err != nil {
+		return err
+	}
+
+	settlement.Status = models.SplitSettlementConfirmed
+	settlement.ToTransactionID = &t.ID
+	settlement.ConfirmedAt = &now
+	res, err := tx.Exec(`UPDATE split_settlements SET status = $1, to_transaction_id = $2, confirmed_at = $3 WHERE id = $4 AND status = $5`,
+		settlement.Status, settlement.ToTransactionID, settlement.ConfirmedAt, settlement.ID, models.SplitSettlementPending)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("settlement %s is no longer pending", settlement.ID)
+	}
+
+	if _, err := tx.Exec(`UPDATE split_groups SET updated_at = $1 WHERE id = $2`, now, settlement.GroupID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteSettlement removes a settlement and reverses the postings on both sides
+func (r *SplitRepository) DeleteSettlement(settlement *models.SplitSettlement) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM split_settlements WHERE id = $1`, settlement.ID); err != nil {
+		return err
+	}
+	for _, id := range []*uuid.UUID{settlement.FromTransactionID, settlement.ToTransactionID} {
+		if err := deleteSplitTransaction(tx, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetSettlements returns the group's settlements, optionally only those with the given status
+func (r *SplitRepository) GetSettlements(groupID uuid.UUID, status models.SplitSettlementStatus) ([]models.SplitSettlement, error) {
+	settlements := []models.SplitSettlement{}
+	query := `SELECT ` + splitSettlementColumns + ` FROM split_settlements WHERE group_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY settlement_date DESC, created_at DESC`
+	err := r.db.Select(&settlements, query, groupID, status)
+	return settlements, err
+}
+
+func (r *SplitRepository) GetSettlement(groupID, id uuid.UUID) (*models.SplitSettlement, error) {
+	var settlement models.SplitSettlement
+	query := `SELECT ` + splitSettlementColumns + ` FROM split_settlements WHERE id = $1 AND group_id = $2`
+	if err := r.db.Get(&settlement, query, id, groupID); err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+// insertSplitTransaction inserts a transaction posted for a group and applies it to its account
+func insertSplitTransaction(tx *sqlx.Tx, t *models.Transaction, groupID uuid.UUID, now time.Time) error {
+	t.ID = uuid.New()
+	t.SplitGroupID = &groupID
+	t.CreatedAt, t.UpdatedAt = now, now
+	insert := `
+		INSERT INTO transactions (id, user_id, account_id, type, category, subcategory, amount, description, transaction_date, split_group_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	if _, err := tx.Exec(insert, t.ID, t.UserID, t.AccountID, t.Type, t.Category, t.Subcategory, t.Amount, t.Description, t.TransactionDate, t.SplitGroupID, t.CreatedAt, t.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+	return applyBalanceEffect(tx, t, 1)
+}
+
+// deleteSplitTransaction reverses and deletes a transaction posted for a group; nil is a no-op
+func deleteSplitTransaction(tx *sqlx.Tx, id *uuid.UUID) error {
+	if id == nil {
+		return nil
+	}
+	var t models.Transaction
+	if err := tx.Get(&t, `SELECT `+transactionColumns+` FROM transactions WHERE id = $1`, *id); err != nil {
+		return err
+	}
+	if err := applyBalanceEffect(tx, &t, -1); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM transactions WHERE id = $1`, t.ID)
+	return err
+}
+
+// insertSplitInvitation invites a user to a group unless they are a member or invited already
+func insertSplitInvitation(db sqlx.Execer, groupID, userID, invitedBy uuid.UUID) error {
+	_, err := db.Exec(`
+		INSERT INTO split_group_invitations (id, group_id, user_id, invited_by, created_at)
+		SELECT $1, $2, $3, $4, $5
+		WHERE NOT EXISTS (SELECT 1 FROM split_group_members WHERE group_id = $2 AND user_id = $3)
+		ON CONFLICT (group_id, user_id) DO NOTHING`,
+		uuid.New(), groupID, userID, invitedBy, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to invite group member: %w", err)
+	}
+	return nil
+}
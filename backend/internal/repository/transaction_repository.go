@@ -9,6 +9,18 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// transactionColumns is the column list matching models.Transaction
+const transactionColumns = `id, user_id, account_id, credit_card_id, type, category, subcategory, amount, description, transaction_date, linked_transaction_id, installment_plan_id, installment_number, loan_id, iou_id, split_group_id, created_at, updated_at`
+
+// transactionVisibleToUser matches transactions user $1 made or that were posted to an
+// account or card shared into one of their households
+const transactionVisibleToUser = `(user_id = $1
+	OR account_id IN (SELECT id FROM accounts WHERE ` + visibleToUser + `)
+	OR credit_card_id IN (SELECT id FROM credit_cards WHERE ` + visibleToUser + `))`
+
+// creditCondition matches transactions made on credit: on a card or on a paylater account
+const creditCondition = `(t.credit_card_id IS NOT NULL OR t.account_id IN (SELECT a.id FROM accounts a WHERE a.type = 'paylater'))`
+
 type TransactionRepository struct {
 	db *sqlx.DB
 }
@@ -17,29 +29,41 @@ func NewTransactionRepository(db *sqlx.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
+// Create records the transaction together with the reward it earns if it's a card purchase
 func (r *TransactionRepository) Create(tx *models.Transaction) error {
 	tx.ID = uuid.New()
 	tx.CreatedAt = time.Now()
 	tx.UpdatedAt = time.Now()
 
+	dbTx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
 	query := `
-		INSERT INTO transactions (id, user_id, account_id, credit_card_id, type, category, amount, description, transaction_date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO transactions (id, user_id, account_id, credit_card_id, type, category, subcategory, amount, description, transaction_date, linked_transaction_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
-	_, err := r.db.Exec(query, tx.ID, tx.UserID, tx.AccountID, tx.CreditCardID, tx.Type, tx.Category, tx.Amount, tx.Description, tx.TransactionDate, tx.CreatedAt, tx.UpdatedAt)
+	_, err = dbTx.Exec(query, tx.ID, tx.UserID, tx.AccountID, tx.CreditCardID, tx.Type, tx.Category, tx.Subcategory, tx.Amount, tx.Description, tx.TransactionDate, tx.LinkedTransactionID, tx.CreatedAt, tx.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create transaction: %w", err)
 	}
+	if err := recordRewardEarning(dbTx, tx); err != nil {
+		return err
+	}
 
-	return nil
+	return dbTx.Commit()
 }
 
+// GetByUserID returns the user's transactions together with everything posted to
+// accounts and cards shared into their households
 func (r *TransactionRepository) GetByUserID(userID uuid.UUID, limit, offset int) ([]models.Transaction, error) {
 	var transactions []models.Transaction
 	query := `
-		SELECT id, user_id, account_id, credit_card_id, type, category, amount, description, transaction_date, created_at, updated_at 
+		SELECT ` + transactionColumns + `
 		FROM transactions 
-		WHERE user_id = $1 
+		WHERE ` + transactionVisibleToUser + `
 		ORDER BY transaction_date DESC, created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -52,7 +76,7 @@ func (r *TransactionRepository) GetByUserID(userID uuid.UUID, limit, offset int)
 
 func (r *TransactionRepository) GetByID(id uuid.UUID) (*models.Transaction, error) {
 	var transaction models.Transaction
-	query := `SELECT id, user_id, account_id, credit_card_id, type, category, amount, description, transaction_date, created_at, updated_at FROM transactions WHERE id = $1`
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1`
 	err := r.db.Get(&transaction, query, id)
 	if err != nil {
 		return nil, err
@@ -60,11 +84,25 @@ func (r *TransactionRepository) GetByID(id uuid.UUID) (*models.Transaction, erro
 	return &transaction, nil
 }
 
+// Update saves the transaction and re-records its reward earning
 func (r *TransactionRepository) Update(tx *models.Transaction) error {
 	tx.UpdatedAt = time.Now()
-	query := `UPDATE transactions SET account_id = $1, credit_card_id = $2, category = $3, amount = $4, description = $5, transaction_date = $6, updated_at = $7 WHERE id = $8`
-	_, err := r.db.Exec(query, tx.AccountID, tx.CreditCardID, tx.Category, tx.Amount, tx.Description, tx.TransactionDate, tx.UpdatedAt, tx.ID)
-	return err
+
+	dbTx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	query := `UPDATE transactions SET account_id = $1, credit_card_id = $2, category = $3, subcategory = $4, amount = $5, description = $6, transaction_date = $7, updated_at = $8 WHERE id = $9`
+	if _, err := dbTx.Exec(query, tx.AccountID, tx.CreditCardID, tx.Category, tx.Subcategory, tx.Amount, tx.Description, tx.TransactionDate, tx.UpdatedAt, tx.ID); err != nil {
+		return err
+	}
+	if err := recordRewardEarning(dbTx, tx); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
 }
 
 func (r *TransactionRepository) Delete(id uuid.UUID) error {
@@ -76,17 +114,19 @@ func (r *TransactionRepository) Delete(id uuid.UUID) error {
 func (r *TransactionRepository) GetSummary(userID uuid.UUID) (*models.TransactionSummary, error) {
 	summary := &models.TransactionSummary{}
 	
-	// Only calculate income and expense from account transactions (exclude credit card transactions)
-	// Credit card expenses don't reduce cash balance, they only increase debt
-	// Credit card income (payments) don't increase cash balance, they only reduce debt
+	// Only calculate income and expense from cash account transactions (exclude credit card and paylater transactions)
+	// Credit card and paylater expenses don't reduce cash balance, they only increase debt
+	// Credit card and paylater income (payments) don't increase cash balance, they only reduce debt
+	// Card spending is reported separately so it isn't lost from the picture
 	query := `
 		SELECT 
-			COALESCE(SUM(CASE WHEN type = 'income' AND credit_card_id IS NULL THEN amount ELSE 0 END), 0) as total_income,
-			COALESCE(SUM(CASE WHEN type = 'expense' AND credit_card_id IS NULL THEN amount ELSE 0 END), 0) as total_expense
-		FROM transactions 
-		WHERE user_id = $1
+			COALESCE(SUM(CASE WHEN type = 'income' AND NOT ` + creditCondition + ` THEN amount ELSE 0 END), 0) as total_income,
+			COALESCE(SUM(CASE WHEN type = 'expense' AND NOT ` + creditCondition + ` THEN amount ELSE 0 END), 0) as total_expense,
+			COALESCE(SUM(CASE WHEN type = 'expense' AND ` + creditCondition + ` THEN amount ELSE 0 END), 0) as total_credit_expense
+		FROM transactions t
+		WHERE ` + transactionVisibleToUser + `
 	`
-	err := r.db.QueryRow(query, userID).Scan(&summary.TotalIncome, &summary.TotalExpense)
+	err := r.db.QueryRow(query, userID).Scan(&summary.TotalIncome, &summary.TotalExpense, &summary.TotalCreditExpense)
 	if err != nil {
 		return nil, err
 	}
@@ -94,3 +134,140 @@ func (r *TransactionRepository) GetSummary(userID uuid.UUID) (*models.Transactio
 	summary.Balance = summary.TotalIncome - summary.TotalExpense
 	return summary, nil
 }
+
+// GetAccountTransactionsBetween returns income and expense transactions on accounts (not credit cards) in [start, end).
+// Linked transfers such as card payments are left out.
+func (r *TransactionRepository) GetAccountTransactionsBetween(userID uuid.UUID, start, end time.Time) ([]models.Transaction, error) {
+	transactions := []models.Transaction{}
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE ` + transactionVisibleToUser + ` AND account_id IS NOT NULL AND type IN ('income', 'expense') AND linked_transaction_id IS NULL
+			AND transaction_date >= $2 AND transaction_date < $3
+		ORDER BY transaction_date ASC
+	`
+	err := r.db.Select(&transactions, query, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// CreateLinkedPair records both halves of a transfer and applies their balance effects atomically.
+// The debit leg is an expense on an account, the credit leg an income on a credit card (a card payment).
+func (r *TransactionRepository) CreateLinkedPair(debit, credit *models.Transaction) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	debit.ID, credit.ID = uuid.New(), uuid.New()
+	debit.CreatedAt, debit.UpdatedAt = now, now
+	credit.CreatedAt, credit.UpdatedAt = now, now
+	debit.LinkedTransactionID = &credit.ID
+	credit.LinkedTransactionID = &debit.ID
+
+	insert := `
+		INSERT INTO transactions (id, user_id, account_id, credit_card_id, type, category, subcategory, amount, description, transaction_date, linked_transaction_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+	// The first row can only point at its partner once the partner exists
+	for _, t := range []*models.Transaction{debit, credit} {
+		var link *uuid.UUID
+		if t == credit {
+			link = t.LinkedTransactionID
+		}
+		if _, err := tx.Exec(insert, t.ID, t.UserID, t.AccountID, t.CreditCardID, t.Type, t.Category, t.Subcategory, t.Amount, t.Description, t.TransactionDate, link, t.CreatedAt, t.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`UPDATE transactions SET linked_transaction_id = $1 WHERE id = $2`, credit.ID, debit.ID); err != nil {
+		return err
+	}
+
+	for _, t := range []*models.Transaction{debit, credit} {
+		if err := applyBalanceEffect(tx, t, 1); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteLinkedPair deletes a transaction together with its linked partner and reverses both balance effects
+func (r *TransactionRepository) DeleteLinkedPair(t *models.Transaction) error {
+	if t.LinkedTransactionID == nil {
+		return fmt.Errorf("transaction %s is not linked", t.ID)
+	}
+	partner, err := r.GetByID(*t.LinkedTransactionID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, leg := range []*models.Transaction{t, partner} {
+		if err := applyBalanceEffect(tx, leg, -1); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM transactions WHERE id IN ($1, $2)`, t.ID, partner.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetLinkedCardPayments returns card payments made from accounts, newest first
+func (r *TransactionRepository) GetLinkedCardPayments(creditCardID uuid.UUID) ([]models.CardPayment, error) {
+	var cardLegs []models.Transaction
+	query := `SELECT ` + transactionColumns + ` FROM transactions
+		WHERE credit_card_id = $1 AND linked_transaction_id IS NOT NULL
+		ORDER BY transaction_date DESC, created_at DESC`
+	if err := r.db.Select(&cardLegs, query, creditCardID); err != nil {
+		return nil, err
+	}
+
+	payments := make([]models.CardPayment, 0, len(cardLegs))
+	for _, leg := range cardLegs {
+		accountLeg, err := r.GetByID(*leg.LinkedTransactionID)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, models.CardPayment{CardTransaction: leg, AccountTransaction: *accountLeg})
+	}
+	return payments, nil
+}
+
+// applyBalanceEffect adds (sign=1) or reverses (sign=-1) a transaction's effect on its account or card.
+// Income raises a cash balance and lowers card or paylater debt; expense does the opposite.
+func applyBalanceEffect(tx *sqlx.Tx, t *models.Transaction, sign float64) error {
+	var delta float64
+	switch t.Type {
+	case models.TransactionTypeIncome:
+		delta = t.Amount
+	case models.TransactionTypeExpense:
+		delta = -t.Amount
+	default:
+		return nil
+	}
+	delta *= sign
+
+	now := time.Now()
+	if t.AccountID != nil {
+		// A paylater balance is debt, so the effect is inverted
+		_, err := tx.Exec(`UPDATE accounts SET balance = balance + CASE WHEN type = 'paylater' THEN -$1::numeric ELSE $1::numeric END, updated_at = $2 WHERE id = $3`, delta, now, *t.AccountID)
+		return err
+	}
+	if t.CreditCardID != nil {
+		_, err := tx.Exec(`UPDATE credit_cards SET current_balance = current_balance - $1, updated_at = $2 WHERE id = $3`, delta, now, *t.CreditCardID)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/financial-tracker/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrChallengeInvalid is returned for unknown, expired, used or exhausted login challenges
+var ErrChallengeInvalid = errors.New("invalid two-factor challenge")
+
+const (
+	// maxChallengeAttempts is how many wrong codes a login challenge takes before it is dropped
+	maxChallengeAttempts = 5
+	recoveryCodeCount    = 10
+	// No 0/o or 1/l/i, so codes can be typed from a printout
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+type TwoFactorRepository struct {
+	db *sqlx.DB
+}
+
+func NewTwoFactorRepository(db *sqlx.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// GetSecret returns the user's TOTP secret and whether enrolment has been confirmed.
+// The secret is empty if 2FA was never set up.
+func (r *TwoFactorRepository) GetSecret(userID uuid.UUID) (string, bool, error) {
+	var row struct {
+		Secret    *string    `db:"totp_secret"`
+		EnabledAt *time.Time `db:"totp_enabled_at"`
+	}
+	if err := r.db.Get(&row, `SELECT totp_secret, totp_enabled_at FROM users WHERE id = $1`, userID); err != nil {
+		return "", false, err
+	}
+	if row.Secret == nil {
+		return "", false, nil
+	}
+	return *row.Secret, row.EnabledAt != nil, nil
+}
+
+// SetPendingSecret stores a new secret awaiting confirmation. It does nothing once 2FA is enabled.
+func (r *TwoFactorRepository) SetPendingSecret(userID uuid.UUID, secret string) error {
+	res, err := r.db.Exec(`UPDATE users SET totp_secret = $1, totp_last_step = 0, updated_at = $2 WHERE id = $3 AND totp_enabled_at IS NULL`,
+		secret, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("two-factor authentication is already enabled")
+	}
+	return nil
+}
+
+// Enable confirms enrolment with the step of the first valid code and returns a fresh
+// set of recovery codes
+func (r *TwoFactorRepository) Enable(userID uuid.UUID, step int64) ([]string, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	res, err := tx.Exec(`UPDATE users SET totp_enabled_at = $1, totp_last_step = $2, updated_at = $1
+		WHERE id = $3 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL`, now, step, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("no pending two-factor setup")
+	}
+
+	codes, err := replaceRecoveryCodes(tx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	return codes, tx.Commit()
+}
+
+// Disable removes the secret, recovery codes and any pending login challenges
+func (r *TwoFactorRepository) Disable(userID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = $1 WHERE id = $2`,
+		time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM two_factor_challenges WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseStep records that a code for the time step was accepted. It reports false if a
+// code for that step or a later one was already used, so a code can't be replayed.
+func (r *TwoFactorRepository) UseStep(userID uuid.UUID, step int64) (bool, error) {
+	res, err := r.db.Exec(`UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1`, step, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes and returns the new ones
+func (r *TwoFactorRepository) RegenerateRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	codes, err := replaceRecoveryCodes(tx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return codes, tx.Commit()
+}
+
+// UseRecoveryCode spends one of the user's unused recovery codes, reporting whether it matched
+func (r *TwoFactorRepository) UseRecoveryCode(userID uuid.UUID, code string) (bool, error) {
+	res, err := r.db.Exec(`UPDATE recovery_codes SET used_at = $1
+		WHERE id = (SELECT id FROM recovery_codes WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL LIMIT 1)`,
+		time.Now(), userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func (r *TwoFactorRepository) CountRecoveryCodes(userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.Get(&count, `SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID)
+	return count, err
+}
+
+// Login challenges
+
+// CreateChallenge starts a login challenge for a user who passed the password check and
+// returns its token
+func (r *TwoFactorRepository) CreateChallenge(userID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	_, err := r.db.Exec(`INSERT INTO two_factor_challenges (id, user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		uuid.New(), userID, hashToken(token), expiresAt, now)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create challenge: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// GetChallenge returns an open challenge by token, or ErrChallengeInvalid
+func (r *TwoFactorRepository) GetChallenge(token string) (*models.TwoFactorChallenge, error) {
+	var challenge models.TwoFactorChallenge
+	query := `SELECT id, user_id, attempts, expires_at, used_at FROM two_factor_challenges WHERE token_hash = $1`
+	err := r.db.Get(&challenge, query, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrChallengeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if challenge.UsedAt != nil || !challenge.ExpiresAt.After(time.Now()) || challenge.Attempts >= maxChallengeAttempts {
+		return nil, ErrChallengeInvalid
+	}
+	return &challenge, nil
+}
+
+// RecordChallengeFailure counts a wrong code against the challenge
+func (r *TwoFactorRepository) RecordChallengeFailure(id uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE two_factor_challenges SET attempts = attempts + 1 WHERE id = $1`, id)
+	return err
+}
+
+// CompleteChallenge closes the challenge, reporting false if it was completed already
+func (r *TwoFactorRepository) CompleteChallenge(id uuid.UUID) (bool, error) {
+	res, err := r.db.Exec(`UPDATE two_factor_challenges SET used_at = $1 WHERE id = $2 AND used_at IS NULL`, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and stores a new set, returning
+// the codes formatted like "abcde-fghjk"
+func replaceRecoveryCodes(tx *sqlx.Tx, userID uuid.UUID, now time.Time) ([]string, error) {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		var b strings.Builder
+		for j := 0; j < 10; j++ {
+			if j == 5 {
+				b.WriteByte('-')
+			}
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+			if err != nil {
+				return nil, err
+			}
+			b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+		}
+		code := b.String()
+
+		_, err := tx.Exec(`INSERT INTO recovery_codes (id, user_id, code_hash, created_at) VALUES ($1, $2, $3, $4)`,
+			uuid.New(), userID, hashToken(normalizeRecoveryCode(code)), now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store recovery code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode ignores case, spaces and dashes in what the user typed
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
@@ -18,6 +18,8 @@ func NewUserRepository(db *sqlx.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+const userColumns = `id, email, username, password_hash, full_name, is_admin, token_version, email_verified_at, totp_enabled_at, two_factor_required, created_at, updated_at`
+
 func (r *UserRepository) Create(user *models.User) error {
 	user.ID = uuid.New()
 	user.CreatedAt = time.Now()
@@ -37,7 +39,7 @@ func (r *UserRepository) Create(user *models.User) error {
 
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, email, username, password_hash, full_name, is_admin, created_at, updated_at FROM users WHERE email = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 	err := r.db.Get(&user, query, email)
 	if err != nil {
 		return nil, err
@@ -47,7 +49,7 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 
 func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, email, username, password_hash, full_name, is_admin, created_at, updated_at FROM users WHERE username = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = $1`
 	err := r.db.Get(&user, query, username)
 	if err != nil {
 		return nil, err
@@ -67,7 +69,7 @@ func (r *UserRepository) GetByEmailOrUsername(usernameOrEmail string) (*models.U
 
 func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, email, username, password_hash, full_name, is_admin, created_at, updated_at FROM users WHERE id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 	err := r.db.Get(&user, query, id)
 	if err != nil {
 		return nil, err
@@ -79,7 +81,8 @@ func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 
 func (r *UserRepository) GetAll() ([]models.User, error) {
 	var users []models.User
-	query := `SELECT id, email, username, full_name, is_admin, created_at, updated_at FROM users ORDER BY created_at DESC`
+	query := `SELECT id, email, username, full_name, is_admin, email_verified_at, totp_enabled_at, two_factor_required, created_at, updated_at
+		FROM users ORDER BY created_at DESC`
 	err := r.db.Select(&users, query)
 	if err != nil {
 		return nil, err
@@ -89,20 +92,73 @@ func (r *UserRepository) GetAll() ([]models.User, error) {
 
 func (r *UserRepository) Update(user *models.User) error {
 	user.UpdatedAt = time.Now()
-	query := `UPDATE users SET full_name = $1, is_admin = $2, updated_at = $3 WHERE id = $4`
-	_, err := r.db.Exec(query, user.FullName, user.IsAdmin, user.UpdatedAt, user.ID)
+	query := `UPDATE users SET full_name = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.Exec(query, user.FullName, user.UpdatedAt, user.ID)
 	return err
 }
 
-func (r *UserRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM users WHERE id = $1`
-	_, err := r.db.Exec(query, id)
+// ChangePassword sets a new password hash and revokes every session of the user
+func (r *UserRepository) ChangePassword(id uuid.UUID, passwordHash string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`, passwordHash, now, id); err != nil {
+		return err
+	}
+	if err := revokeAllSessions(tx, id, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetTwoFactorRequired makes TOTP enrolment mandatory for the user, or optional again
+func (r *UserRepository) SetTwoFactorRequired(id uuid.UUID, required bool) error {
+	_, err := r.db.Exec(`UPDATE users SET two_factor_required = $1, updated_at = $2 WHERE id = $3`, required, time.Now(), id)
 	return err
 }
 
-func (r *UserRepository) SetAdmin(id uuid.UUID, isAdmin bool) error {
-	query := `UPDATE users SET is_admin = $1, updated_at = $2 WHERE id = $3`
-	_, err := r.db.Exec(query, isAdmin, time.Now(), id)
+// MarkEmailVerified records that the user proved they own their email address
+func (r *UserRepository) MarkEmailVerified(id uuid.UUID) error {
+	now := time.Now()
+	_, err := r.db.Exec(`UPDATE users SET email_verified_at = $1, updated_at = $1 WHERE id = $2 AND email_verified_at IS NULL`, now, id)
+	return err
+}
+
+// IsEmailVerified implements middleware.EmailVerificationChecker
+func (r *UserRepository) IsEmailVerified(id uuid.UUID) (bool, error) {
+	var verified bool
+	err := r.db.Get(&verified, `SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`, id)
+	return verified, err
+}
+
+// RecordVerificationSend notes that a verification email went out to the user
+func (r *UserRepository) RecordVerificationSend(id uuid.UUID) error {
+	_, err := r.db.Exec(`INSERT INTO email_verification_sends (id, user_id, sent_at) VALUES ($1, $2, $3)`, uuid.New(), id, time.Now())
+	return err
+}
+
+// GetVerificationSends returns how many verification emails the user was sent since the
+// given time and when the last one went out, if any
+func (r *UserRepository) GetVerificationSends(id uuid.UUID, since time.Time) (int, *time.Time, error) {
+	var row struct {
+		Count  int        `db:"count"`
+		LastAt *time.Time `db:"last_at"`
+	}
+	query := `SELECT COUNT(*) FILTER (WHERE sent_at >= $2) AS count, MAX(sent_at) AS last_at
+		FROM email_verification_sends WHERE user_id = $1`
+	if err := r.db.Get(&row, query, id, since); err != nil {
+		return 0, nil, err
+	}
+	return row.Count, row.LastAt, nil
+}
+
+func (r *UserRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM users WHERE id = $1`
+	_, err := r.db.Exec(query, id)
 	return err
 }
 
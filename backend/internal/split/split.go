@@ -0,0 +1,158 @@
+// Package split divides a shared bill between participants and reduces
+// the resulting debts to a minimal set of transfers.
+package split
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Mode is how a bill is divided
+type Mode string
+
+const (
+	ModeEqual  Mode = "equal"
+	ModeShares Mode = "shares"
+	ModeExact  Mode = "exact"
+)
+
+// Participant is one person in a bill. Shares is the weight in ModeShares,
+// Amount the fixed part in ModeExact; both are ignored in ModeEqual.
+type Participant struct {
+	UserID uuid.UUID
+	Shares float64
+	Amount float64
+}
+
+// Allocate returns what each participant owes of total, in the order given.
+// Equal and share splits are computed in cents, with leftover cents going to
+// the largest remainders so the parts always add up to the total.
+func Allocate(total float64, mode Mode, participants []Participant) ([]float64, error) {
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("at least one participant is required")
+	}
+	totalCents := toCents(total)
+
+	if mode == ModeExact {
+		parts := make([]float64, len(participants))
+		var sum int64
+		for i, p := range participants {
+			if p.Amount < 0 {
+				return nil, fmt.Errorf("amounts cannot be negative")
+			}
+			sum += toCents(p.Amount)
+			parts[i] = fromCents(toCents(p.Amount))
+		}
+		if sum != totalCents {
+			return nil, fmt.Errorf("amounts add up to %.2f, not %.2f", fromCents(sum), total)
+		}
+		return parts, nil
+	}
+
+	weights := make([]float64, len(participants))
+	var weightSum float64
+	for i, p := range participants {
+		weights[i] = 1
+		if mode == ModeShares {
+			if p.Shares <= 0 {
+				return nil, fmt.Errorf("shares must be greater than zero")
+			}
+			weights[i] = p.Shares
+		}
+		weightSum += weights[i]
+	}
+
+	cents := make([]int64, len(participants))
+	remainders := make([]float64, len(participants))
+	var allocated int64
+	for i, w := range weights {
+		exact := float64(totalCents) * w / weightSum
+		cents[i] = int64(math.Floor(exact))
+		remainders[i] = exact - float64(cents[i])
+		allocated += cents[i]
+	}
+
+	order := make([]int, len(participants))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for i := 0; allocated < totalCents; i++ {
+		cents[order[i%len(order)]]++
+		allocated++
+	}
+
+	parts := make([]float64, len(participants))
+	for i, c := range cents {
+		parts[i] = fromCents(c)
+	}
+	return parts, nil
+}
+
+// Transfer is one payment that settles debts between two people
+type Transfer struct {
+	FromUserID uuid.UUID `json:"from_user_id"`
+	ToUserID   uuid.UUID `json:"to_user_id"`
+	Amount     float64   `json:"amount"`
+}
+
+// Simplify turns net balances (positive when a person is owed money) into transfers
+// that settle everyone, repeatedly matching the largest debtor with the largest creditor.
+// This needs at most one transfer fewer than the number of people with a balance.
+func Simplify(balances map[uuid.UUID]float64) []Transfer {
+	type entry struct {
+		userID uuid.UUID
+		cents  int64
+	}
+	var debtors, creditors []entry
+	for userID, balance := range balances {
+		c := toCents(balance)
+		switch {
+		case c > 0:
+			creditors = append(creditors, entry{userID, c})
+		case c < 0:
+			debtors = append(debtors, entry{userID, -c})
+		}
+	}
+	// Largest first, ties by ID so the result is stable
+	byAmount := func(list []entry) {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].cents != list[j].cents {
+				return list[i].cents > list[j].cents
+			}
+			return list[i].userID.String() < list[j].userID.String()
+		})
+	}
+
+	transfers := []Transfer{}
+	for len(debtors) > 0 && len(creditors) > 0 {
+		byAmount(debtors)
+		byAmount(creditors)
+		d, c := &debtors[0], &creditors[0]
+		amount := d.cents
+		if c.cents < amount {
+			amount = c.cents
+		}
+		transfers = append(transfers, Transfer{FromUserID: d.userID, ToUserID: c.userID, Amount: fromCents(amount)})
+		d.cents -= amount
+		c.cents -= amount
+		if d.cents == 0 {
+			debtors = debtors[1:]
+		}
+		if c.cents == 0 {
+			creditors = creditors[1:]
+		}
+	}
+	return transfers
+}
+
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func fromCents(c int64) float64 {
+	return float64(c) / 100
+}
@@ -0,0 +1,16 @@
+-- Migration 014: Automatic monthly budget rollover
+-- Stores per-user options used to copy last month's budgets at the start of each month
+
+CREATE TABLE IF NOT EXISTS budget_rollover_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    mode VARCHAR(20) NOT NULL DEFAULT 'skip',
+    adjust_percent DECIMAL(7, 2) NOT NULL DEFAULT 0,
+    amount_source VARCHAR(30) NOT NULL DEFAULT 'budget',
+    last_run_month INTEGER,
+    last_run_year INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_budget_rollover_settings_enabled ON budget_rollover_settings(enabled);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_budgets_credit_card_id;
+DROP INDEX IF EXISTS idx_budgets_account_id;
+
+-- Scoped budgets can't survive the narrower unique index
+DELETE FROM budgets WHERE account_id IS NOT NULL OR credit_card_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_budgets_unique;
+CREATE UNIQUE INDEX idx_budgets_unique ON budgets(user_id, category, budget_month, budget_year);
+
+ALTER TABLE budgets DROP CONSTRAINT IF EXISTS budgets_single_scope_check;
+ALTER TABLE budgets
+DROP COLUMN IF EXISTS credit_card_id,
+DROP COLUMN IF EXISTS account_id;
@@ -0,0 +1,24 @@
+-- Migration 015: Budgets scoped to accounts or credit cards
+-- A budget may target one account (including its pockets) or one credit card in addition to a category
+
+ALTER TABLE budgets
+ADD COLUMN account_id UUID REFERENCES accounts(id) ON DELETE CASCADE,
+ADD COLUMN credit_card_id UUID REFERENCES credit_cards(id) ON DELETE CASCADE;
+
+ALTER TABLE budgets
+ADD CONSTRAINT budgets_single_scope_check
+CHECK (account_id IS NULL OR credit_card_id IS NULL);
+
+-- Replace the (user, category, month, year) unique index so scoped budgets can coexist
+DROP INDEX IF EXISTS idx_budgets_unique;
+CREATE UNIQUE INDEX idx_budgets_unique ON budgets(
+    user_id,
+    category,
+    budget_month,
+    budget_year,
+    COALESCE(account_id, '00000000-0000-0000-0000-000000000000'::uuid),
+    COALESCE(credit_card_id, '00000000-0000-0000-0000-000000000000'::uuid)
+);
+
+CREATE INDEX idx_budgets_account_id ON budgets(account_id);
+CREATE INDEX idx_budgets_credit_card_id ON budgets(credit_card_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_user_category;
+DROP INDEX IF EXISTS idx_transactions_user_date;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS subcategory;
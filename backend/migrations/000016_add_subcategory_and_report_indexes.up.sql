@@ -0,0 +1,8 @@
+-- Migration 016: Subcategories and report indexes
+-- Reports break expenses down by category and subcategory over date ranges
+
+ALTER TABLE transactions ADD COLUMN subcategory VARCHAR(100) NOT NULL DEFAULT '';
+
+-- Range scans per user by date are the backbone of every report query
+CREATE INDEX idx_transactions_user_date ON transactions(user_id, transaction_date);
+CREATE INDEX idx_transactions_user_category ON transactions(user_id, category, subcategory);
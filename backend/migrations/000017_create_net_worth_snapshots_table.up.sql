@@ -0,0 +1,16 @@
+-- Migration 017: Daily net worth snapshots
+CREATE TABLE IF NOT EXISTS net_worth_snapshots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    snapshot_date DATE NOT NULL,
+    cash DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    gold DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    total_assets DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    credit_card_debt DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    paylater_debt DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    total_liabilities DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    net_worth DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, snapshot_date)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_linked;
+ALTER TABLE transactions DROP COLUMN IF EXISTS linked_transaction_id;
@@ -0,0 +1,6 @@
+-- Migration 018: Linked transaction pairs
+-- A card payment from a bank account is stored as two transactions pointing at each other
+
+ALTER TABLE transactions ADD COLUMN linked_transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_transactions_linked ON transactions(linked_transaction_id) WHERE linked_transaction_id IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_installment;
+ALTER TABLE transactions DROP COLUMN IF EXISTS installment_number;
+ALTER TABLE transactions DROP COLUMN IF EXISTS installment_plan_id;
+DROP TABLE IF EXISTS installment_plans;
@@ -0,0 +1,26 @@
+-- Migration 019: Credit card installment plans (cicilan)
+CREATE TABLE IF NOT EXISTS installment_plans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id) ON DELETE CASCADE,
+    description VARCHAR(255) NOT NULL,
+    category VARCHAR(100) NOT NULL,
+    subcategory VARCHAR(100) NOT NULL DEFAULT '',
+    principal DECIMAL(15, 2) NOT NULL CHECK (principal > 0),
+    tenor_months INTEGER NOT NULL CHECK (tenor_months BETWEEN 1 AND 60),
+    interest_rate DECIMAL(6, 3) NOT NULL DEFAULT 0,
+    admin_fee DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    purchase_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_installment_plans_card ON installment_plans(credit_card_id);
+CREATE INDEX idx_installment_plans_active ON installment_plans(status) WHERE status = 'active';
+
+-- Installment postings are card expenses pointing back at their plan
+ALTER TABLE transactions ADD COLUMN installment_plan_id UUID REFERENCES installment_plans(id) ON DELETE CASCADE;
+ALTER TABLE transactions ADD COLUMN installment_number INTEGER;
+
+CREATE UNIQUE INDEX idx_transactions_installment ON transactions(installment_plan_id, installment_number) WHERE installment_plan_id IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notifications;
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS utilization_alert_percent;
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS reminder_days_before;
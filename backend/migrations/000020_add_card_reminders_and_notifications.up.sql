@@ -0,0 +1,20 @@
+-- Migration 020: Credit card reminders and notifications
+-- Each card carries its own reminder lead time and utilization alert threshold (0 disables)
+ALTER TABLE credit_cards ADD COLUMN reminder_days_before INTEGER NOT NULL DEFAULT 3 CHECK (reminder_days_before BETWEEN 0 AND 31);
+ALTER TABLE credit_cards ADD COLUMN utilization_alert_percent DECIMAL(5, 2) NOT NULL DEFAULT 80 CHECK (utilization_alert_percent BETWEEN 0 AND 100);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credit_card_id UUID REFERENCES credit_cards(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    -- The same event is only ever notified once, e.g. one due reminder per statement
+    dedup_key VARCHAR(255) NOT NULL,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, dedup_key)
+);
+
+CREATE INDEX idx_notifications_user_created ON notifications(user_id, created_at DESC);
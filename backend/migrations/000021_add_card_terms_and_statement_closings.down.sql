@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS card_statement_closings;
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS min_payment_floor;
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS min_payment_percent;
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS annual_fee_month;
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS annual_fee;
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS late_fee;
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS interest_rate;
@@ -0,0 +1,19 @@
+-- Migration 021: Credit card interest, fees and minimum payment rule
+-- Charges default to zero so existing cards aren't billed until their terms are filled in
+ALTER TABLE credit_cards ADD COLUMN interest_rate DECIMAL(6, 3) NOT NULL DEFAULT 0;
+ALTER TABLE credit_cards ADD COLUMN late_fee DECIMAL(15, 2) NOT NULL DEFAULT 0;
+ALTER TABLE credit_cards ADD COLUMN annual_fee DECIMAL(15, 2) NOT NULL DEFAULT 0;
+ALTER TABLE credit_cards ADD COLUMN annual_fee_month INTEGER NOT NULL DEFAULT 0 CHECK (annual_fee_month BETWEEN 0 AND 12);
+ALTER TABLE credit_cards ADD COLUMN min_payment_percent DECIMAL(5, 2) NOT NULL DEFAULT 10;
+ALTER TABLE credit_cards ADD COLUMN min_payment_floor DECIMAL(15, 2) NOT NULL DEFAULT 50000;
+
+-- One row per card and statement date once the monthly close has posted its charges
+CREATE TABLE IF NOT EXISTS card_statement_closings (
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id) ON DELETE CASCADE,
+    statement_date DATE NOT NULL,
+    interest DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    late_fee DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    annual_fee DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (credit_card_id, statement_date)
+);
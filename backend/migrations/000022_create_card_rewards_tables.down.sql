@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS card_reward_earnings;
+DROP TABLE IF EXISTS card_reward_redemptions;
+DROP TABLE IF EXISTS card_reward_rules;
@@ -0,0 +1,45 @@
+-- Migration 022: Credit card rewards
+-- One rule per card and category; the empty category is the card's base rate
+CREATE TABLE IF NOT EXISTS card_reward_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id) ON DELETE CASCADE,
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    reward_type VARCHAR(20) NOT NULL CHECK (reward_type IN ('cashback', 'points')),
+    rate DECIMAL(10, 4) NOT NULL DEFAULT 0,
+    per_amount DECIMAL(15, 2) NOT NULL DEFAULT 1,
+    point_value DECIMAL(15, 4) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (credit_card_id, category)
+);
+
+CREATE TABLE IF NOT EXISTS card_reward_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id) ON DELETE CASCADE,
+    reward_type VARCHAR(20) NOT NULL CHECK (reward_type IN ('cashback', 'points')),
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    redeemed_at DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_card_reward_redemptions_card ON card_reward_redemptions(credit_card_id, redeemed_at DESC);
+
+-- What each purchase earned, with the rule's rate as it was when the purchase posted,
+-- so later rule changes don't rewrite past rewards
+CREATE TABLE IF NOT EXISTS card_reward_earnings (
+    transaction_id UUID PRIMARY KEY REFERENCES transactions(id) ON DELETE CASCADE,
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id) ON DELETE CASCADE,
+    rule_id UUID REFERENCES card_reward_rules(id) ON DELETE SET NULL,
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    reward_type VARCHAR(20) NOT NULL CHECK (reward_type IN ('cashback', 'points')),
+    rate DECIMAL(10, 4) NOT NULL DEFAULT 0,
+    per_amount DECIMAL(15, 2) NOT NULL DEFAULT 1,
+    earned DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_card_reward_earnings_card ON card_reward_earnings(credit_card_id);
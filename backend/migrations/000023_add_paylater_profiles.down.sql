@@ -0,0 +1,8 @@
+DELETE FROM installment_plans WHERE account_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_installment_plans_account;
+ALTER TABLE installment_plans DROP CONSTRAINT IF EXISTS installment_plans_single_target;
+ALTER TABLE installment_plans DROP COLUMN IF EXISTS account_id;
+ALTER TABLE installment_plans ALTER COLUMN credit_card_id SET NOT NULL;
+
+DROP TABLE IF EXISTS paylater_profiles;
+UPDATE accounts SET balance = -balance WHERE type = 'paylater';
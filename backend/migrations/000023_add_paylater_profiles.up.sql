@@ -0,0 +1,22 @@
+-- Migration 023: Paylater accounts as liabilities
+-- A paylater balance now holds the amount owed, so existing (negative) balances flip sign
+UPDATE accounts SET balance = -balance WHERE type = 'paylater';
+
+CREATE TABLE IF NOT EXISTS paylater_profiles (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL DEFAULT 'other',
+    credit_limit DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    billing_day INTEGER NOT NULL CHECK (billing_day BETWEEN 1 AND 31),
+    due_day INTEGER NOT NULL CHECK (due_day BETWEEN 1 AND 31),
+    interest_rate DECIMAL(6, 3) NOT NULL DEFAULT 0,
+    admin_fee DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Installment plans can be billed to a paylater account instead of a card
+ALTER TABLE installment_plans ALTER COLUMN credit_card_id DROP NOT NULL;
+ALTER TABLE installment_plans ADD COLUMN account_id UUID REFERENCES accounts(id) ON DELETE CASCADE;
+ALTER TABLE installment_plans ADD CONSTRAINT installment_plans_single_target
+    CHECK ((credit_card_id IS NULL) <> (account_id IS NULL));
+CREATE INDEX idx_installment_plans_account ON installment_plans(account_id);
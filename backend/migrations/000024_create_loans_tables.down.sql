@@ -0,0 +1,6 @@
+ALTER TABLE net_worth_snapshots DROP COLUMN IF EXISTS loan_debt;
+ALTER TABLE net_worth_snapshots DROP COLUMN IF EXISTS loan_receivable;
+
+DROP TABLE IF EXISTS loan_payments;
+ALTER TABLE transactions DROP COLUMN IF EXISTS loan_id;
+DROP TABLE IF EXISTS loans;
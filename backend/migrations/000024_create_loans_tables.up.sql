@@ -0,0 +1,39 @@
+-- Migration 024: Loans (KPR, vehicle, family hutang/piutang) and their payments
+CREATE TABLE IF NOT EXISTS loans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'other',
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('borrowed', 'lent')),
+    counterparty VARCHAR(100) NOT NULL DEFAULT '',
+    principal DECIMAL(15, 2) NOT NULL CHECK (principal > 0),
+    interest_rate DECIMAL(6, 3) NOT NULL DEFAULT 0,
+    method VARCHAR(10) NOT NULL DEFAULT 'annuity' CHECK (method IN ('annuity', 'flat')),
+    tenor_months INTEGER NOT NULL CHECK (tenor_months BETWEEN 1 AND 360),
+    start_date DATE NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_loans_user ON loans(user_id);
+
+-- Payments move money through an account, so each one is backed by a transaction
+ALTER TABLE transactions ADD COLUMN loan_id UUID REFERENCES loans(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS loan_payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    loan_id UUID NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+    transaction_id UUID NOT NULL UNIQUE REFERENCES transactions(id) ON DELETE CASCADE,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    principal DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    interest DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    payment_date DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_loan_payments_loan ON loan_payments(loan_id, payment_date);
+
+ALTER TABLE net_worth_snapshots ADD COLUMN loan_receivable DECIMAL(15, 2) NOT NULL DEFAULT 0;
+ALTER TABLE net_worth_snapshots ADD COLUMN loan_debt DECIMAL(15, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS iou_settlements;
+ALTER TABLE transactions DROP COLUMN IF EXISTS iou_id;
+DROP TABLE IF EXISTS ious;
+DROP TABLE IF EXISTS contacts;
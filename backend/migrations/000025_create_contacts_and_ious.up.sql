@@ -0,0 +1,47 @@
+-- Migration 025: Contacts and IOUs (money fronted to or by friends)
+CREATE TABLE IF NOT EXISTS contacts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    phone VARCHAR(30) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    share_token VARCHAR(64) UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_contacts_user ON contacts(user_id);
+
+CREATE TABLE IF NOT EXISTS ious (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    contact_id UUID NOT NULL REFERENCES contacts(id) ON DELETE CASCADE,
+    direction VARCHAR(20) NOT NULL CHECK (direction IN ('i_owe', 'owed_to_me')),
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    iou_date DATE NOT NULL,
+    due_date DATE,
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ious_contact ON ious(contact_id);
+CREATE INDEX idx_ious_user_status ON ious(user_id, status);
+
+-- Settlement transactions point back at their IOU and are managed through it
+ALTER TABLE transactions ADD COLUMN iou_id UUID REFERENCES ious(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS iou_settlements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    iou_id UUID NOT NULL REFERENCES ious(id) ON DELETE CASCADE,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    settlement_date DATE NOT NULL,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    transaction_id UUID UNIQUE REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_iou_settlements_iou ON iou_settlements(iou_id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS split_settlements;
+DROP TABLE IF EXISTS split_expense_shares;
+DROP TABLE IF EXISTS split_expenses;
+ALTER TABLE transactions DROP COLUMN IF EXISTS split_group_id;
+DROP TABLE IF EXISTS split_group_invitations;
+DROP TABLE IF EXISTS split_group_members;
+DROP TABLE IF EXISTS split_groups;
@@ -0,0 +1,74 @@
+-- Migration 026: Shared expenses split between registered users
+CREATE TABLE IF NOT EXISTS split_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS split_group_members (
+    group_id UUID NOT NULL REFERENCES split_groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX idx_split_group_members_user ON split_group_members(user_id);
+
+-- Users only join a group by accepting an invitation from one of its members
+CREATE TABLE IF NOT EXISTS split_group_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES split_groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invited_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (group_id, user_id)
+);
+
+CREATE INDEX idx_split_group_invitations_user ON split_group_invitations(user_id);
+
+-- Transactions posted for a group (the payer's bill and both sides of settlements) are managed through it
+ALTER TABLE transactions ADD COLUMN split_group_id UUID REFERENCES split_groups(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS split_expenses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES split_groups(id) ON DELETE CASCADE,
+    payer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    description VARCHAR(255) NOT NULL,
+    category VARCHAR(100) NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    split_mode VARCHAR(10) NOT NULL CHECK (split_mode IN ('equal', 'shares', 'exact')),
+    expense_date DATE NOT NULL,
+    payer_transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_split_expenses_group ON split_expenses(group_id, expense_date);
+
+CREATE TABLE IF NOT EXISTS split_expense_shares (
+    expense_id UUID NOT NULL REFERENCES split_expenses(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    shares DECIMAL(10, 3) NOT NULL DEFAULT 1,
+    amount DECIMAL(15, 2) NOT NULL,
+    PRIMARY KEY (expense_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS split_settlements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES split_groups(id) ON DELETE CASCADE,
+    from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    settlement_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    from_transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    to_transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    confirmed_at TIMESTAMP,
+    CHECK (from_user_id <> to_user_id)
+);
+
+CREATE INDEX idx_split_settlements_group ON split_settlements(group_id);
@@ -0,0 +1,6 @@
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS household_id;
+ALTER TABLE budgets DROP COLUMN IF EXISTS household_id;
+ALTER TABLE accounts DROP COLUMN IF EXISTS household_id;
+DROP TABLE IF EXISTS household_invitations;
+DROP TABLE IF EXISTS household_members;
+DROP TABLE IF EXISTS households;
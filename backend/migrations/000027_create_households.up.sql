@@ -0,0 +1,42 @@
+-- Migration 027: Households (shared workspaces) with member roles and invitations
+CREATE TABLE IF NOT EXISTS households (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS household_members (
+    household_id UUID NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (household_id, user_id)
+);
+
+CREATE INDEX idx_household_members_user ON household_members(user_id);
+
+CREATE TABLE IF NOT EXISTS household_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    household_id UUID NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('editor', 'viewer')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    invited_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    responded_at TIMESTAMP
+);
+
+CREATE INDEX idx_household_invitations_email ON household_invitations(LOWER(email), status);
+-- Only one open invitation per address and household
+CREATE UNIQUE INDEX idx_household_invitations_pending ON household_invitations(household_id, LOWER(email)) WHERE status = 'pending';
+
+-- Shared records stay owned by their creator; household_id makes them visible to the members
+ALTER TABLE accounts ADD COLUMN household_id UUID REFERENCES households(id) ON DELETE SET NULL;
+ALTER TABLE budgets ADD COLUMN household_id UUID REFERENCES households(id) ON DELETE SET NULL;
+ALTER TABLE credit_cards ADD COLUMN household_id UUID REFERENCES households(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_accounts_household ON accounts(household_id) WHERE household_id IS NOT NULL;
+CREATE INDEX idx_budgets_household ON budgets(household_id) WHERE household_id IS NOT NULL;
+CREATE INDEX idx_credit_cards_household ON credit_cards(household_id) WHERE household_id IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Migration 029: Device metadata on sessions, for listing where the user is logged in
+ALTER TABLE auth_sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE auth_sessions ADD COLUMN ip_address VARCHAR(45) NOT NULL DEFAULT '';
+ALTER TABLE auth_sessions ADD COLUMN last_seen_at TIMESTAMP NOT NULL DEFAULT NOW();
@@ -0,0 +1,13 @@
+-- Migration 031: Email verification
+-- Accounts created before verification existed are treated as verified
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+UPDATE users SET email_verified_at = created_at;
+
+-- Each verification email sent, for rate limiting re-sends
+CREATE TABLE IF NOT EXISTS email_verification_sends (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_verification_sends_user ON email_verification_sends(user_id, sent_at);
@@ -0,0 +1,53 @@
+-- Migration 035: Role-based access control
+-- Users get permissions through roles. users.is_admin is kept in step with the admin
+-- role for the user listings that show it.
+CREATE TABLE IF NOT EXISTS roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id UUID NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX idx_user_roles_role ON user_roles(role_id);
+
+INSERT INTO permissions (name, description) VALUES
+('stats:read', 'View the admin dashboard statistics'),
+('users:read', 'List users and their roles'),
+('users:write', 'Edit and delete users and require 2FA of them'),
+('roles:write', 'Assign roles to users, including admin'),
+('api_configs:read', 'View API configurations'),
+('api_configs:write', 'Change API configurations'),
+('gold_prices:write', 'Set the daily gold price');
+
+INSERT INTO roles (name, description) VALUES
+('admin', 'Full access to administration'),
+('gold_price_editor', 'Sets the daily gold price');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p WHERE r.name = 'admin';
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON p.name = 'gold_prices:write' WHERE r.name = 'gold_price_editor';
+
+-- Existing admins keep their access
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u CROSS JOIN roles r WHERE u.is_admin AND r.name = 'admin';
@@ -0,0 +1,60 @@
+'use client'
+
+import { useEffect, useRef } from 'react'
+import { useParams, useRouter } from 'next/navigation'
+import { apiClient, isTwoFactorChallenge } from '@/lib/api'
+import { useAuth } from '@/contexts/AuthContext'
+import { useToast } from '@/contexts/ToastContext'
+
+// Where a sign-in provider sends the user back to, with ?code=...&state=...
+export default function OidcCallbackPage() {
+  const router = useRouter()
+  const params = useParams<{ provider: string }>()
+  const { login } = useAuth()
+  const toast = useToast()
+  // The code works once; don't send it twice when effects run twice in development
+  const handled = useRef(false)
+
+  useEffect(() => {
+    if (handled.current) return
+    handled.current = true
+
+    const query = new URLSearchParams(window.location.search)
+    const code = query.get('code')
+    const state = query.get('state')
+    const expectedState = sessionStorage.getItem('oidc_state')
+    sessionStorage.removeItem('oidc_state')
+
+    if (!code || !state || state !== expectedState) {
+      toast.error(query.get('error_description') || 'Login gagal, silakan coba lagi')
+      router.replace('/login')
+      return
+    }
+
+    apiClient.oidcCallback(params.provider, code, state)
+      .then(response => {
+        if (isTwoFactorChallenge(response)) {
+          // The login page asks for the code
+          sessionStorage.setItem('oidc_challenge', response.challenge_token)
+          router.replace('/login')
+          return
+        }
+        login(response.token, response.user)
+        router.replace('/dashboard')
+        toast.success('Login berhasil! Selamat datang kembali! 🎉')
+      })
+      .catch((error: any) => {
+        toast.error(error?.message || error?.error || 'Login gagal, silakan coba lagi')
+        router.replace('/login')
+      })
+  }, [params.provider, login, router, toast])
+
+  return (
+    <div className="min-h-screen bg-white dark:bg-dark-950 flex items-center justify-center">
+      <div className="text-center">
+        <div className="animate-spin rounded-full h-12 w-12 border-b-2 border-primary-500 mx-auto mb-4"></div>
+        <p className="text-light-600 dark:text-dark-400">Loading...</p>
+      </div>
+    </div>
+  )
+}
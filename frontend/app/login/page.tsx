@@ -2,11 +2,11 @@
 
 import { useState, useEffect } from 'react'
 import { useRouter } from 'next/navigation'
-import { User, Lock, Sparkles } from 'lucide-react'
+import { User, Lock, Sparkles, ShieldCheck } from 'lucide-react'
 import AuthLayout from '@/components/layout/AuthLayout'
 import Input from '@/components/ui/Input'
 import Button from '@/components/ui/Button'
-import { apiClient } from '@/lib/api'
+import { apiClient, isTwoFactorChallenge, LoginResponse, OidcProvider } from '@/lib/api'
 import { useAuth } from '@/contexts/AuthContext'
 import { useToast } from '@/contexts/ToastContext'
 
@@ -19,6 +19,10 @@ export default function LoginPage() {
     username_or_email: '',
     password: '',
   })
+  // Set when the password was right and the account wants a second factor
+  const [challengeToken, setChallengeToken] = useState<string | null>(null)
+  const [twoFactorCode, setTwoFactorCode] = useState('')
+  const [providers, setProviders] = useState<OidcProvider[]>([])
 
   useEffect(() => {
     if (!authLoading && isAuthenticated) {
@@ -26,6 +30,17 @@ export default function LoginPage() {
     }
   }, [isAuthenticated, authLoading, router])
 
+  useEffect(() => {
+    apiClient.getOidcProviders().then(setProviders).catch(() => setProviders([]))
+
+    // A provider sign-in that still needs the second factor comes back here
+    const pendingChallenge = sessionStorage.getItem('oidc_challenge')
+    if (pendingChallenge) {
+      sessionStorage.removeItem('oidc_challenge')
+      setChallengeToken(pendingChallenge)
+    }
+  }, [])
+
   if (authLoading) {
     return (
       <div className="min-h-screen bg-white dark:bg-dark-950 flex items-center justify-center">
@@ -74,15 +89,13 @@ export default function LoginPage() {
         username_or_email: formData.username_or_email,
         password: formData.password,
       })
-      
-      // Store token and update auth context
-      login(response.token, response.user)
-      
-      // Redirect to dashboard immediately
-      router.push('/dashboard')
-      
-      // Show success toast after navigation starts
-      toast.success('Login berhasil! Selamat datang kembali! 🎉')
+
+      if (isTwoFactorChallenge(response)) {
+        setChallengeToken(response.challenge_token)
+        return
+      }
+
+      completeLogin(response)
     } catch (error: any) {
       const errorMessage = error?.message || error?.error || 'Terjadi kesalahan saat login'
       
@@ -93,6 +106,83 @@ export default function LoginPage() {
     }
   }
 
+  const handleProviderLogin = async (provider: string) => {
+    try {
+      const { authorization_url, state } = await apiClient.startOidcLogin(provider)
+      // The callback page checks the provider sends this back, so nobody else's sign-in is completed here
+      sessionStorage.setItem('oidc_state', state)
+      window.location.href = authorization_url
+    } catch (error: any) {
+      toast.error(error?.message || error?.error || 'Gagal memulai login')
+    }
+  }
+
+  const completeLogin = (response: LoginResponse) => {
+    // Store token and update auth context
+    login(response.token, response.user)
+
+    // Redirect to dashboard immediately
+    router.push('/dashboard')
+
+    // Show success toast after navigation starts
+    toast.success('Login berhasil! Selamat datang kembali! 🎉')
+  }
+
+  const handleTwoFactorSubmit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    if (!challengeToken || !twoFactorCode) return
+
+    setLoading(true)
+    try {
+      // Six digits is an authenticator code, anything else a recovery code
+      const code = twoFactorCode.trim()
+      const response = await apiClient.verifyTwoFactor(
+        challengeToken,
+        /^\d{6}$/.test(code) ? { code } : { recovery_code: code },
+      )
+      completeLogin(response)
+    } catch (error: any) {
+      toast.error(error?.message || error?.error || 'Kode verifikasi salah')
+      if (error?.error?.includes('log in again')) {
+        setChallengeToken(null)
+      }
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  if (challengeToken) {
+    return (
+      <AuthLayout
+        title="Verifikasi 2 Langkah 🔐"
+        subtitle="Masukkan kode dari aplikasi authenticator lo, atau salah satu recovery code"
+        type="login"
+      >
+        <form onSubmit={handleTwoFactorSubmit} className="space-y-4 sm:space-y-5">
+          <Input
+            label="Kode Verifikasi"
+            type="text"
+            name="two_factor_code"
+            placeholder="123456"
+            value={twoFactorCode}
+            onChange={(e) => setTwoFactorCode(e.target.value)}
+            icon={<ShieldCheck size={20} />}
+            required
+          />
+
+          <Button
+            type="submit"
+            fullWidth
+            loading={loading}
+            className="mt-4 sm:mt-6"
+          >
+            Verifikasi
+          </Button>
+        </form>
+      </AuthLayout>
+    )
+  }
+
   return (
     <AuthLayout
       title="Selamat Datang! 👋"
@@ -154,42 +244,31 @@ export default function LoginPage() {
       </form>
 
       {/* Social Login Divider */}
-      <div className="relative my-6 sm:my-8">
-        <div className="absolute inset-0 flex items-center">
-          <div className="w-full border-t border-light-300 dark:border-dark-700"></div>
-        </div>
-        <div className="relative flex justify-center text-xs sm:text-sm">
-          <span className="px-3 sm:px-4 bg-white dark:bg-dark-800/50 text-light-500 dark:text-dark-500">atau lanjut dengan</span>
+      {providers.length > 0 && (
+        <div className="relative my-6 sm:my-8">
+          <div className="absolute inset-0 flex items-center">
+            <div className="w-full border-t border-light-300 dark:border-dark-700"></div>
+          </div>
+          <div className="relative flex justify-center text-xs sm:text-sm">
+            <span className="px-3 sm:px-4 bg-white dark:bg-dark-800/50 text-light-500 dark:text-dark-500">atau lanjut dengan</span>
+          </div>
         </div>
-      </div>
+      )}
 
       {/* Social Login Buttons */}
       <div className="grid grid-cols-2 gap-3 sm:gap-4">
-        <button
-          type="button"
-          className="flex items-center justify-center gap-1.5 sm:gap-2 px-3 sm:px-4 py-2.5 sm:py-3 bg-light-100 dark:bg-dark-800/50 border border-light-300 dark:border-dark-700 rounded-lg sm:rounded-xl hover:bg-light-200 dark:hover:bg-dark-700 hover:border-light-400 dark:hover:border-dark-600 transition-all duration-300 group"
-        >
-          <svg className="w-4 h-4 sm:w-5 sm:h-5" viewBox="0 0 24 24">
-            <path fill="#EA4335" d="M5.26620003,9.76452941 C6.19878754,6.93863203 8.85444915,4.90909091 12,4.90909091 C13.6909091,4.90909091 15.2181818,5.50909091 16.4181818,6.49090909 L19.9090909,3 C17.7818182,1.14545455 15.0545455,0 12,0 C7.27006974,0 3.1977497,2.69829785 1.23999023,6.65002441 L5.26620003,9.76452941 Z"/>
-            <path fill="#34A853" d="M16.0407269,18.0125889 C14.9509167,18.7163016 13.5660892,19.0909091 12,19.0909091 C8.86648613,19.0909091 6.21911939,17.076871 5.27698177,14.2678769 L1.23746264,17.3349879 C3.19279051,21.2936293 7.26500293,24 12,24 C14.9328362,24 17.7353462,22.9573905 19.834192,20.9995801 L16.0407269,18.0125889 Z"/>
-            <path fill="#4A90E2" d="M19.834192,20.9995801 C22.0291676,18.9520994 23.4545455,15.903663 23.4545455,12 C23.4545455,11.2909091 23.3454545,10.5272727 23.1818182,9.81818182 L12,9.81818182 L12,14.4545455 L18.4363636,14.4545455 C18.1187732,16.013626 17.2662994,17.2212117 16.0407269,18.0125889 L19.834192,20.9995801 Z"/>
-            <path fill="#FBBC05" d="M5.27698177,14.2678769 C5.03832634,13.556323 4.90909091,12.7937589 4.90909091,12 C4.90909091,11.2182781 5.03443647,10.4668121 5.26620003,9.76452941 L1.23999023,6.65002441 C0.43658717,8.26043162 0,10.0753848 0,12 C0,13.9195484 0.444780743,15.7301709 1.23746264,17.3349879 L5.27698177,14.2678769 Z"/>
-          </svg>
-          <span className="text-light-700 dark:text-dark-300 group-hover:text-light-900 dark:group-hover:text-dark-100 transition-colors text-xs sm:text-sm font-medium">
-            Google
-          </span>
-        </button>
-        <button
-          type="button"
-          className="flex items-center justify-center gap-1.5 sm:gap-2 px-3 sm:px-4 py-2.5 sm:py-3 bg-light-100 dark:bg-dark-800/50 border border-light-300 dark:border-dark-700 rounded-lg sm:rounded-xl hover:bg-light-200 dark:hover:bg-dark-700 hover:border-light-400 dark:hover:border-dark-600 transition-all duration-300 group"
-        >
-          <svg className="w-4 h-4 sm:w-5 sm:h-5 text-light-700 dark:text-dark-300 group-hover:text-light-900 dark:group-hover:text-dark-100 transition-colors" fill="currentColor" viewBox="0 0 24 24">
-            <path d="M12 0c-6.626 0-12 5.373-12 12 0 5.302 3.438 9.8 8.207 11.387.599.111.793-.261.793-.577v-2.234c-3.338.726-4.033-1.416-4.033-1.416-.546-1.387-1.333-1.756-1.333-1.756-1.089-.745.083-.729.083-.729 1.205.084 1.839 1.237 1.839 1.237 1.07 1.834 2.807 1.304 3.492.997.107-.775.418-1.305.762-1.604-2.665-.305-5.467-1.334-5.467-5.931 0-1.311.469-2.381 1.236-3.221-.124-.303-.535-1.524.117-3.176 0 0 1.008-.322 3.301 1.23.957-.266 1.983-.399 3.003-.404 1.02.005 2.047.138 3.006.404 2.291-1.552 3.297-1.23 3.297-1.23.653 1.653.242 2.874.118 3.176.77.84 1.235 1.911 1.235 3.221 0 4.609-2.807 5.624-5.479 5.921.43.372.823 1.102.823 2.222v3.293c0 .319.192.694.801.576 4.765-1.589 8.199-6.086 8.199-11.386 0-6.627-5.373-12-12-12z"/>
-          </svg>
-          <span className="text-light-700 dark:text-dark-300 group-hover:text-light-900 dark:group-hover:text-dark-100 transition-colors text-xs sm:text-sm font-medium">
-            GitHub
-          </span>
-        </button>
+        {providers.map(provider => (
+          <button
+            key={provider.name}
+            type="button"
+            onClick={() => handleProviderLogin(provider.name)}
+            className="flex items-center justify-center gap-1.5 sm:gap-2 px-3 sm:px-4 py-2.5 sm:py-3 bg-light-100 dark:bg-dark-800/50 border border-light-300 dark:border-dark-700 rounded-lg sm:rounded-xl hover:bg-light-200 dark:hover:bg-dark-700 hover:border-light-400 dark:hover:border-dark-600 transition-all duration-300 group"
+          >
+            <span className="text-light-700 dark:text-dark-300 group-hover:text-light-900 dark:group-hover:text-dark-100 transition-colors text-xs sm:text-sm font-medium">
+              {provider.display_name}
+            </span>
+          </button>
+        ))}
       </div>
     </AuthLayout>
   )
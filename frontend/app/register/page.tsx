@@ -6,7 +6,7 @@ import { Mail, Lock, User, AtSign, Rocket, Check, X } from 'lucide-react'
 import AuthLayout from '@/components/layout/AuthLayout'
 import Input from '@/components/ui/Input'
 import Button from '@/components/ui/Button'
-import { apiClient } from '@/lib/api'
+import { apiClient, isTwoFactorChallenge } from '@/lib/api'
 import { useAuth } from '@/contexts/AuthContext'
 import { useToast } from '@/contexts/ToastContext'
 
@@ -111,6 +111,11 @@ export default function RegisterPage() {
         password: formData.password,
       })
       
+      if (isTwoFactorChallenge(loginResponse)) {
+        router.push('/login')
+        return
+      }
+
       // Store token and update auth context
       login(loginResponse.token, loginResponse.user)
       
@@ -50,7 +50,8 @@ export function AuthProvider({ children }: { children: ReactNode }) {
   }
 
   const logout = () => {
-    apiClient.setToken(null)
+    // Revoke the session server-side; local tokens are cleared either way
+    apiClient.logout().catch(() => {})
     setUser(null)
     router.push('/login')
   }
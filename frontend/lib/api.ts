@@ -20,15 +20,66 @@ export interface User {
   username?: string | null;
   full_name: string;
   is_admin: boolean;
+  email_verified_at?: string | null;
+  two_factor_enabled_at?: string | null;
+  two_factor_required?: boolean;
   created_at: string;
   updated_at: string;
 }
 
 export interface LoginResponse {
   token: string;
+  expires_at: string;
+  refresh_token: string;
   user: User;
 }
 
+export interface Session {
+  id: string;
+  user_agent: string;
+  ip_address: string;
+  device: string;
+  current: boolean;
+  created_at: string;
+  last_seen_at: string;
+  expires_at: string;
+}
+
+// Returned by login instead of tokens when the user has two-factor authentication on
+export interface TwoFactorChallenge {
+  two_factor_required: true;
+  challenge_token: string;
+  expires_at: string;
+}
+
+export function isTwoFactorChallenge(response: LoginResponse | TwoFactorChallenge): response is TwoFactorChallenge {
+  return 'two_factor_required' in response && response.two_factor_required === true;
+}
+
+export interface OidcProvider {
+  name: string;
+  display_name: string;
+}
+
+export interface TwoFactorStatus {
+  enabled: boolean;
+  required: boolean;
+  recovery_codes_remaining: number;
+}
+
+// A personal access token for scripts; `token` is only present right after creating it
+export interface ApiToken {
+  id: string;
+  name: string;
+  token_prefix: string;
+  scopes: string[];
+  expires_at: string | null;
+  last_used_at: string | null;
+  last_used_ip: string;
+  created_at: string;
+  token?: string;
+}
+
 export interface ApiError {
   message: string;
   error?: string;
@@ -55,12 +106,15 @@ async function handleResponse<T>(response: Response): Promise<T> {
 class ApiClient {
   private baseUrl: string;
   private token: string | null = null;
+  private refreshToken: string | null = null;
+  private refreshing: Promise<boolean> | null = null;
 
   constructor(baseUrl: string) {
     this.baseUrl = baseUrl;
-    // Load token from localStorage on initialization
+    // Load tokens from localStorage on initialization
     if (typeof window !== 'undefined') {
       this.token = localStorage.getItem('auth_token');
+      this.refreshToken = localStorage.getItem('refresh_token');
     }
   }
 
@@ -73,6 +127,51 @@ class ApiClient {
         localStorage.removeItem('auth_token');
       }
     }
+    // Clearing the access token ends the session on this device
+    if (!token) {
+      this.setRefreshToken(null);
+    }
+  }
+
+  private setRefreshToken(refreshToken: string | null) {
+    this.refreshToken = refreshToken;
+    if (typeof window !== 'undefined') {
+      if (refreshToken) {
+        localStorage.setItem('refresh_token', refreshToken);
+      } else {
+        localStorage.removeItem('refresh_token');
+      }
+    }
+  }
+
+  // Trades the refresh token for a new pair. Concurrent 401s share one refresh,
+  // since each refresh token can only be used once.
+  private refreshSession(): Promise<boolean> {
+    if (!this.refreshToken) {
+      return Promise.resolve(false);
+    }
+    if (!this.refreshing) {
+      this.refreshing = fetch(`${this.baseUrl}/api/auth/refresh`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: this.refreshToken }),
+      })
+        .then(async (response) => {
+          if (!response.ok) {
+            this.setToken(null);
+            return false;
+          }
+          const data: LoginResponse = await response.json();
+          this.setToken(data.token);
+          this.setRefreshToken(data.refresh_token);
+          return true;
+        })
+        .catch(() => false)
+        .finally(() => {
+          this.refreshing = null;
+        });
+    }
+    return this.refreshing;
   }
 
   getToken(): string | null {
@@ -81,7 +180,8 @@ class ApiClient {
 
   private async request<T>(
     endpoint: string,
-    options: RequestInit = {}
+    options: RequestInit = {},
+    retry = true
   ): Promise<T> {
     const url = `${this.baseUrl}${endpoint}`;
     const headers: Record<string, string> = {
@@ -100,6 +200,10 @@ class ApiClient {
 
     try {
       const response = await fetch(url, config);
+      // The access token is short-lived: renew it once and replay the request
+      if (response.status === 401 && retry && this.token && await this.refreshSession()) {
+        return this.request<T>(endpoint, options, false);
+      }
       return handleResponse<T>(response);
     } catch (error) {
       if (error instanceof Error) {
@@ -110,11 +214,152 @@ class ApiClient {
   }
 
   // Auth endpoints
-  async login(credentials: LoginRequest): Promise<LoginResponse> {
-    return this.request<LoginResponse>('/api/auth/login', {
+  async login(credentials: LoginRequest): Promise<LoginResponse | TwoFactorChallenge> {
+    const response = await this.request<LoginResponse | TwoFactorChallenge>('/api/auth/login', {
       method: 'POST',
       body: JSON.stringify(credentials),
     });
+    if (!isTwoFactorChallenge(response)) {
+      this.setRefreshToken(response.refresh_token);
+    }
+    return response;
+  }
+
+  // Completes a two-factor login with an authenticator code or a recovery code
+  async verifyTwoFactor(challengeToken: string, code: { code?: string; recovery_code?: string }): Promise<LoginResponse> {
+    const response = await this.request<LoginResponse>('/api/auth/2fa/verify', {
+      method: 'POST',
+      body: JSON.stringify({ challenge_token: challengeToken, ...code }),
+    });
+    this.setRefreshToken(response.refresh_token);
+    return response;
+  }
+
+  async getOidcProviders(): Promise<OidcProvider[]> {
+    return this.request<OidcProvider[]>('/api/auth/oidc/providers');
+  }
+
+  // Returns where to send the browser; keep `state` to check the callback against
+  async startOidcLogin(provider: string): Promise<{ authorization_url: string; state: string }> {
+    return this.request<{ authorization_url: string; state: string }>(`/api/auth/oidc/${provider}/start`, { method: 'POST' });
+  }
+
+  // Finishes a provider sign-in with the code and state it redirected back with
+  async oidcCallback(provider: string, code: string, state: string): Promise<LoginResponse | TwoFactorChallenge> {
+    const response = await this.request<LoginResponse | TwoFactorChallenge>(`/api/auth/oidc/${provider}/callback`, {
+      method: 'POST',
+      body: JSON.stringify({ code, state }),
+    });
+    if (!isTwoFactorChallenge(response)) {
+      this.setRefreshToken(response.refresh_token);
+    }
+    return response;
+  }
+
+  async getTwoFactorStatus(): Promise<TwoFactorStatus> {
+    return this.request<TwoFactorStatus>('/api/auth/2fa');
+  }
+
+  async setupTwoFactor(): Promise<{ secret: string; otpauth_uri: string }> {
+    return this.request<{ secret: string; otpauth_uri: string }>('/api/auth/2fa/setup', { method: 'POST' });
+  }
+
+  async enableTwoFactor(code: string): Promise<{ recovery_codes: string[]; token: string; expires_at: string }> {
+    const response = await this.request<{ recovery_codes: string[]; token: string; expires_at: string }>('/api/auth/2fa/enable', {
+      method: 'POST',
+      body: JSON.stringify({ code }),
+    });
+    this.setToken(response.token);
+    return response;
+  }
+
+  async disableTwoFactor(password: string, code: { code?: string; recovery_code?: string }): Promise<{ message: string }> {
+    return this.request<{ message: string }>('/api/auth/2fa/disable', {
+      method: 'POST',
+      body: JSON.stringify({ password, ...code }),
+    });
+  }
+
+  async regenerateRecoveryCodes(code: { code?: string; recovery_code?: string }): Promise<{ recovery_codes: string[] }> {
+    return this.request<{ recovery_codes: string[] }>('/api/auth/2fa/recovery-codes', {
+      method: 'POST',
+      body: JSON.stringify(code),
+    });
+  }
+
+  async logout(): Promise<void> {
+    try {
+      await this.request<{ message: string }>('/api/auth/logout', { method: 'POST' });
+    } finally {
+      this.setToken(null);
+    }
+  }
+
+  async logoutAll(): Promise<void> {
+    try {
+      await this.request<{ message: string }>('/api/auth/logout-all', { method: 'POST' });
+    } finally {
+      this.setToken(null);
+    }
+  }
+
+  async getSessions(): Promise<Session[]> {
+    return this.request<Session[]>('/api/auth/sessions');
+  }
+
+  async revokeSession(id: string): Promise<{ message: string }> {
+    return this.request<{ message: string }>(`/api/auth/sessions/${id}`, { method: 'DELETE' });
+  }
+
+  async getApiTokens(): Promise<ApiToken[]> {
+    return this.request<ApiToken[]>('/api/auth/tokens');
+  }
+
+  async createApiToken(data: { name: string; scopes: string[]; expires_in_days?: number }): Promise<ApiToken> {
+    return this.request<ApiToken>('/api/auth/tokens', {
+      method: 'POST',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async revokeApiToken(id: string): Promise<{ message: string }> {
+    return this.request<{ message: string }>(`/api/auth/tokens/${id}`, { method: 'DELETE' });
+  }
+
+  // Logs out every session, so keep the fresh tokens it returns
+  async changePassword(currentPassword: string, newPassword: string): Promise<LoginResponse> {
+    const response = await this.request<LoginResponse>('/api/auth/change-password', {
+      method: 'POST',
+      body: JSON.stringify({ current_password: currentPassword, new_password: newPassword }),
+    });
+    this.setToken(response.token);
+    this.setRefreshToken(response.refresh_token);
+    return response;
+  }
+
+  async forgotPassword(email: string): Promise<{ message: string }> {
+    return this.request<{ message: string }>('/api/auth/forgot-password', {
+      method: 'POST',
+      body: JSON.stringify({ email }),
+    });
+  }
+
+  async resetPassword(token: string, newPassword: string): Promise<{ message: string }> {
+    return this.request<{ message: string }>('/api/auth/reset-password', {
+      method: 'POST',
+      body: JSON.stringify({ token, new_password: newPassword }),
+    });
+  }
+
+  async verifyEmail(token: string): Promise<{ message: string }> {
+    return this.request<{ message: string }>('/api/auth/verify-email', {
+      method: 'POST',
+      body: JSON.stringify({ token }),
+    });
+  }
+
+  async resendVerification(): Promise<{ message: string }> {
+    return this.request<{ message: string }>('/api/auth/verify-email/resend', { method: 'POST' });
   }
 
   async register(data: RegisterRequest): Promise<{ message: string; user: User }> {